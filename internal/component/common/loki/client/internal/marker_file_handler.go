@@ -3,6 +3,7 @@ package internal
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -35,6 +36,10 @@ type markerFileHandler struct {
 	logger                    log.Logger
 	lastMarkedSegmentDir      string
 	lastMarkedSegmentFilePath string
+
+	// legacyMarkedSegmentFilePath is the marker file shared by all clients before each client got its own marker. It's
+	// only read from, if the client marker file doesn't exist yet.
+	legacyMarkedSegmentFilePath string
 }
 
 var (
@@ -58,20 +63,45 @@ func NewMarkerFileHandler(logger log.Logger, walDir string) (MarkerFileHandler,
 	return mfh, nil
 }
 
+// NewClientMarkerFileHandler creates a new markerFileHandler that keeps the marker of the given client in its own
+// folder, so that several clients reading from the same WAL each replay the data they haven't delivered yet. If the
+// client doesn't have a marker yet, the marker shared by all clients in previous versions is used.
+func NewClientMarkerFileHandler(logger log.Logger, walDir, clientName string) (MarkerFileHandler, error) {
+	markerDir := filepath.Join(walDir, MarkerFolderName, url.PathEscape(clientName))
+	// attempt to create dir if doesn't exist
+	if err := os.MkdirAll(markerDir, MarkerFolderMode); err != nil {
+		return nil, fmt.Errorf("error creating segment marker folder %q: %w", markerDir, err)
+	}
+
+	mfh := &markerFileHandler{
+		logger:                      logger,
+		lastMarkedSegmentDir:        markerDir,
+		lastMarkedSegmentFilePath:   filepath.Join(markerDir, MarkerFileName),
+		legacyMarkedSegmentFilePath: filepath.Join(walDir, MarkerFolderName, MarkerFileName),
+	}
+
+	return mfh, nil
+}
+
 // LastMarkedSegment implements wlog.Marker.
 func (mfh *markerFileHandler) LastMarkedSegment() int {
-	bs, err := os.ReadFile(mfh.lastMarkedSegmentFilePath)
+	path := mfh.lastMarkedSegmentFilePath
+	if _, err := os.Stat(path); os.IsNotExist(err) && mfh.legacyMarkedSegmentFilePath != "" {
+		path = mfh.legacyMarkedSegmentFilePath
+	}
+
+	bs, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
-		level.Warn(mfh.logger).Log("msg", "marker segment file does not exist", "file", mfh.lastMarkedSegmentFilePath)
+		level.Warn(mfh.logger).Log("msg", "marker segment file does not exist", "file", path)
 		return -1
 	} else if err != nil {
-		level.Error(mfh.logger).Log("msg", "could not access segment marker file", "file", mfh.lastMarkedSegmentFilePath, "err", err)
+		level.Error(mfh.logger).Log("msg", "could not access segment marker file", "file", path, "err", err)
 		return -1
 	}
 
 	savedSegment, err := DecodeMarkerV1(bs)
 	if err != nil {
-		level.Error(mfh.logger).Log("msg", "could not decode segment marker file", "file", mfh.lastMarkedSegmentFilePath, "err", err)
+		level.Error(mfh.logger).Log("msg", "could not decode segment marker file", "file", path, "err", err)
 		return -1
 	}
 
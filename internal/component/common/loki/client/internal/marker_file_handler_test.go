@@ -73,3 +73,41 @@ func TestMarkerFileHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestClientMarkerFileHandler(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	t.Run("each client keeps its own marker", func(t *testing.T) {
+		dir := t.TempDir()
+		fh1, err := NewClientMarkerFileHandler(logger, dir, "client-1")
+		require.NoError(t, err)
+		fh2, err := NewClientMarkerFileHandler(logger, dir, "client/2")
+		require.NoError(t, err)
+
+		fh1.MarkSegment(3)
+		require.Equal(t, 3, fh1.LastMarkedSegment())
+		require.Equal(t, -1, fh2.LastMarkedSegment())
+
+		fh2.MarkSegment(1)
+		require.Equal(t, 3, fh1.LastMarkedSegment())
+		require.Equal(t, 1, fh2.LastMarkedSegment())
+
+		_, err = os.Stat(filepath.Join(dir, MarkerFolderName, "client%2F2", MarkerFileName))
+		require.NoError(t, err)
+	})
+
+	t.Run("falls back to the shared marker until the client marks a segment", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy, err := NewMarkerFileHandler(logger, dir)
+		require.NoError(t, err)
+		legacy.MarkSegment(7)
+
+		fh, err := NewClientMarkerFileHandler(logger, dir, "client")
+		require.NoError(t, err)
+		require.Equal(t, 7, fh.LastMarkedSegment())
+
+		fh.MarkSegment(8)
+		require.Equal(t, 8, fh.LastMarkedSegment())
+		require.Equal(t, 7, legacy.LastMarkedSegment())
+	})
+}
@@ -52,6 +52,7 @@ type positions struct {
 	cfg       Config
 	mtx       sync.Mutex
 	positions map[Entry]string
+	inodes    map[Entry]uint64
 	quit      chan struct{}
 	done      chan struct{}
 }
@@ -71,6 +72,9 @@ type Entry struct {
 // File format for the positions data.
 type File struct {
 	Positions map[Entry]string `yaml:"positions"`
+	// Inodes holds the inode of the file each position was recorded for, so
+	// that a file which was rotated can be recognized after a restart.
+	Inodes map[Entry]uint64 `yaml:"inodes,omitempty"`
 }
 
 type Positions interface {
@@ -88,6 +92,12 @@ type Positions interface {
 	PutString(path, labels string, pos string)
 	// Put records (asynchronously) how far we've read through a file.
 	Put(path, labels string, pos int64)
+	// GetInode returns the inode of the file the position of a path was
+	// recorded for, or 0 if it is unknown.
+	GetInode(path, labels string) uint64
+	// PutInode records (asynchronously) the inode of the file the position
+	// of a path is recorded for.
+	PutInode(path, labels string, inode uint64)
 	// Remove removes the position tracking for a filepath
 	Remove(path, labels string)
 	// SyncPeriod returns how often the positions file gets resynced
@@ -157,7 +167,7 @@ func readLegacyFile(legacyPath string, l log.Logger) *LegacyFile {
 
 // New makes a new Positions.
 func New(logger log.Logger, cfg Config) (Positions, error) {
-	positionData, err := readPositionsFile(cfg, logger)
+	positionData, err := readFile(cfg, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +175,8 @@ func New(logger log.Logger, cfg Config) (Positions, error) {
 	p := &positions{
 		logger:    logger,
 		cfg:       cfg,
-		positions: positionData,
+		positions: positionData.Positions,
+		inodes:    positionData.Inodes,
 		quit:      make(chan struct{}),
 		done:      make(chan struct{}),
 	}
@@ -205,6 +216,18 @@ func (p *positions) Get(path, labels string) (int64, error) {
 	return strconv.ParseInt(pos, 10, 64)
 }
 
+func (p *positions) GetInode(path, labels string) uint64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.inodes[Entry{path, labels}]
+}
+
+func (p *positions) PutInode(path, labels string, inode uint64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.inodes[Entry{path, labels}] = inode
+}
+
 func (p *positions) Remove(path, labels string) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
@@ -213,6 +236,7 @@ func (p *positions) Remove(path, labels string) {
 
 func (p *positions) remove(path, labels string) {
 	delete(p.positions, Entry{path, labels})
+	delete(p.inodes, Entry{path, labels})
 }
 
 func (p *positions) SyncPeriod() time.Duration {
@@ -247,9 +271,13 @@ func (p *positions) save() {
 	for k, v := range p.positions {
 		positions[k] = v
 	}
+	inodes := make(map[Entry]uint64, len(p.inodes))
+	for k, v := range p.inodes {
+		inodes[k] = v
+	}
 	p.mtx.Unlock()
 
-	if err := writePositionFile(p.cfg.PositionsFile, positions); err != nil {
+	if err := writeFile(p.cfg.PositionsFile, File{Positions: positions, Inodes: inodes}); err != nil {
 		level.Error(p.logger).Log("msg", "error writing positions file", "error", err)
 	}
 }
@@ -288,13 +316,23 @@ func (p *positions) cleanup() {
 }
 
 func readPositionsFile(cfg Config, logger log.Logger) (map[Entry]string, error) {
+	f, err := readFile(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return f.Positions, nil
+}
+
+func readFile(cfg Config, logger log.Logger) (File, error) {
+	empty := File{Positions: map[Entry]string{}, Inodes: map[Entry]uint64{}}
+
 	cleanfn := filepath.Clean(cfg.PositionsFile)
 	buf, err := os.ReadFile(cleanfn)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return map[Entry]string{}, nil
+			return empty, nil
 		}
-		return nil, err
+		return File{}, err
 	}
 
 	var p File
@@ -303,16 +341,23 @@ func readPositionsFile(cfg Config, logger log.Logger) (map[Entry]string, error)
 		// return empty if cfg option enabled
 		if cfg.IgnoreInvalidYaml {
 			level.Debug(logger).Log("msg", "ignoring invalid positions file", "file", cleanfn, "error", err)
-			return map[Entry]string{}, nil
+			return empty, nil
 		}
 
-		return nil, fmt.Errorf("invalid yaml positions file [%s]: %v", cleanfn, err)
+		return File{}, fmt.Errorf("invalid yaml positions file [%s]: %v", cleanfn, err)
 	}
 
 	// p.Positions will be nil if the file exists but is empty
 	if p.Positions == nil {
 		p.Positions = map[Entry]string{}
 	}
+	if p.Inodes == nil {
+		p.Inodes = map[Entry]uint64{}
+	}
+
+	return p, nil
+}
 
-	return p.Positions, nil
+func writePositionFile(filename string, positions map[Entry]string) error {
+	return writeFile(filename, File{Positions: positions})
 }
@@ -365,3 +365,41 @@ positions:
 		Labels: ``,
 	}])
 }
+
+func TestInodes(t *testing.T) {
+	temp := tempFilename(t)
+	defer func() {
+		_ = os.Remove(temp)
+	}()
+
+	logFile := filepath.Join(t.TempDir(), "random.log")
+	require.NoError(t, os.WriteFile(logFile, nil, 0644))
+
+	p, err := New(log.NewNopLogger(), Config{
+		SyncPeriod:    20 * time.Nanosecond,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(0), p.GetInode(logFile, `{job="tmp"}`))
+	p.Put(logFile, `{job="tmp"}`, 100)
+	p.PutInode(logFile, `{job="tmp"}`, 42)
+	p.Stop()
+
+	// Inodes are persisted alongside the positions.
+	f, err := readFile(Config{PositionsFile: temp}, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, "100", f.Positions[Entry{Path: logFile, Labels: `{job="tmp"}`}])
+	require.Equal(t, uint64(42), f.Inodes[Entry{Path: logFile, Labels: `{job="tmp"}`}])
+
+	p, err = New(log.NewNopLogger(), Config{
+		SyncPeriod:    20 * time.Nanosecond,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+	defer p.Stop()
+
+	require.Equal(t, uint64(42), p.GetInode(logFile, `{job="tmp"}`))
+	p.Remove(logFile, `{job="tmp"}`)
+	require.Equal(t, uint64(0), p.GetInode(logFile, `{job="tmp"}`))
+}
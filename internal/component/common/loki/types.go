@@ -6,6 +6,7 @@ package loki
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
 // finalEntryTimeout is how long NewEntryMutatorHandler will wait before giving
@@ -23,6 +26,10 @@ import (
 // to an outage or erroring (such as limits being hit).
 const finalEntryTimeout = 5 * time.Second
 
+func init() {
+	argument.RegisterCapsuleType("LogsReceiver", reflect.TypeFor[LogsReceiver]())
+}
+
 // LogsReceiver is an interface providing `chan Entry` which is used for component
 // communication.
 type LogsReceiver interface {
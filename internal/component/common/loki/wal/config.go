@@ -31,6 +31,10 @@ type Config struct {
 	// Note that this functionality will likely be deprecated in favour of a programmatic cleanup mechanism.
 	MaxSegmentAge time.Duration
 
+	// MaxSize is the threshold in bytes over which the oldest WAL segments are cleaned up, even if they are not older
+	// than MaxSegmentAge. The segment currently being written is never cleaned up. Zero means no limit. Default: 0.
+	MaxSize int64
+
 	// WatchConfig configures the backoff retry used by a WAL watcher when reading from segments not via
 	// the notification channel.
 	WatchConfig WatchConfig
@@ -54,6 +58,11 @@ type WatchConfig struct {
 	// DrainTimeout is the maximum amount of time that the Watcher can spend draining the remaining segments in the WAL.
 	// After that time, the Watcher is stopped immediately, dropping all the work in process.
 	DrainTimeout time.Duration
+
+	// ReplayUnmarked makes the Watcher read the WAL from the oldest segment when the Marker has no segment marked,
+	// instead of tailing the last segment. This prevents losing data that was written to the WAL, but never delivered,
+	// before a restart.
+	ReplayUnmarked bool
 }
 
 // UnmarshalYAML implement YAML Unmarshaler
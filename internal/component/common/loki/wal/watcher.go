@@ -92,6 +92,9 @@ type Watcher struct {
 	drainTimeout time.Duration
 	marker       Marker
 	savedSegment int
+
+	// replayUnmarked makes the Watcher start from the oldest segment if the marker has no segment marked.
+	replayUnmarked bool
 }
 
 // NewWatcher creates a new Watcher.
@@ -111,6 +114,8 @@ func NewWatcher(walDir, id string, metrics *WatcherMetrics, writeTo WriteTo, log
 		minReadFreq:  config.MinReadFrequency,
 		maxReadFreq:  config.MaxReadFrequency,
 		drainTimeout: config.DrainTimeout,
+
+		replayUnmarked: config.ReplayUnmarked,
 	}
 }
 
@@ -152,7 +157,7 @@ func (w *Watcher) mainLoop() {
 
 // Run the watcher, which will tail the WAL until the quit channel is closed or an error case is hit.
 func (w *Watcher) run() error {
-	_, lastSegment, err := w.firstAndLast()
+	firstSegment, lastSegment, err := w.firstAndLast()
 	if err != nil {
 		return fmt.Errorf("wal.Segments: %w", err)
 	}
@@ -166,6 +171,11 @@ func (w *Watcher) run() error {
 		// keep a separate metric that will help us track when the segment in the marker is used. This should be considered
 		// a replay event
 		w.metrics.replaySegment.WithLabelValues(w.id).Set(float64(currentSegment))
+	} else if w.marker != nil && w.savedSegment == -1 && w.replayUnmarked && firstSegment != -1 {
+		// nothing has been marked as consumed yet, so every segment left in the WAL might hold data that was never
+		// delivered. Replay all of them.
+		currentSegment = firstSegment
+		w.metrics.replaySegment.WithLabelValues(w.id).Set(float64(currentSegment))
 	} else {
 		level.Debug(w.logger).Log("msg", fmt.Sprintf("failed to find segment for marked index %d", w.savedSegment), "err", err)
 	}
@@ -173,6 +183,9 @@ func (w *Watcher) run() error {
 	level.Debug(w.logger).Log("msg", "Tailing WAL", "currentSegment", currentSegment, "lastSegment", lastSegment)
 	for !w.state.IsStopping() {
 		w.metrics.currentSegment.WithLabelValues(w.id).Set(float64(currentSegment))
+		if _, last, err := w.firstAndLast(); err == nil {
+			w.metrics.segmentLag.WithLabelValues(w.id).Set(float64(last - currentSegment))
+		}
 
 		// On start, we have a pointer to what is the latest segment. On subsequent calls to this function,
 		// currentSegment will have been incremented, and we should open that segment.
@@ -235,6 +248,7 @@ func (w *Watcher) watch(segmentNum int, tail bool) error {
 			if err != nil {
 				return fmt.Errorf("segments: %w", err)
 			}
+			w.metrics.segmentLag.WithLabelValues(w.id).Set(float64(last - segmentNum))
 
 			// Check if new segments exists, or we are draining the WAL, which means that either:
 			// - This is the last segment, and we can consume it fully because we are draining the WAL
@@ -340,11 +354,20 @@ func (w *Watcher) decodeAndDispatch(b []byte, segmentNum int) (bool, error) {
 	w.actions.StoreSeries(rec.Series, segmentNum)
 	readData = true
 
+	var lastTimestamp time.Time
 	for _, entries := range rec.RefEntries {
+		for _, e := range entries.Entries {
+			if e.Timestamp.After(lastTimestamp) {
+				lastTimestamp = e.Timestamp
+			}
+		}
 		if err := w.actions.AppendEntries(entries, segmentNum); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
+	if !lastTimestamp.IsZero() {
+		w.metrics.lastReadTimestamp.WithLabelValues(w.id).Set(float64(lastTimestamp.Unix()))
+	}
 
 	return readData, firstErr
 }
@@ -374,7 +397,7 @@ func (w *Watcher) Stop() {
 }
 
 // firstAndLast finds the first and last segment number for a WAL directory.
-func (w *Watcher) firstAndLast() (int, int, error) { //nolint:unparam
+func (w *Watcher) firstAndLast() (int, int, error) {
 	refs, err := readSegmentNumbers(w.walDir)
 	if err != nil {
 		return -1, -1, err
@@ -12,6 +12,8 @@ type WatcherMetrics struct {
 	segmentRead               *prometheus.CounterVec
 	currentSegment            *prometheus.GaugeVec
 	replaySegment             *prometheus.GaugeVec
+	segmentLag                *prometheus.GaugeVec
+	lastReadTimestamp         *prometheus.GaugeVec
 	watchersRunning           *prometheus.GaugeVec
 }
 
@@ -71,6 +73,24 @@ func NewWatcherMetrics(reg prometheus.Registerer) *WatcherMetrics {
 			},
 			[]string{"id"},
 		),
+		segmentLag: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "loki_write",
+				Subsystem: "wal_watcher",
+				Name:      "segment_lag",
+				Help:      "Number of WAL segments between the segment the WAL watcher is reading and the last written one.",
+			},
+			[]string{"id"},
+		),
+		lastReadTimestamp: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "loki_write",
+				Subsystem: "wal_watcher",
+				Name:      "last_read_timestamp",
+				Help:      "Latest timestamp that was read from the WAL by the WAL watcher.",
+			},
+			[]string{"id"},
+		),
 		watchersRunning: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: "loki_write",
@@ -88,6 +108,9 @@ func NewWatcherMetrics(reg prometheus.Registerer) *WatcherMetrics {
 		m.droppedWriteNotifications = util.MustRegisterOrGet(reg, m.droppedWriteNotifications).(*prometheus.CounterVec)
 		m.segmentRead = util.MustRegisterOrGet(reg, m.segmentRead).(*prometheus.CounterVec)
 		m.currentSegment = util.MustRegisterOrGet(reg, m.currentSegment).(*prometheus.GaugeVec)
+		m.replaySegment = util.MustRegisterOrGet(reg, m.replaySegment).(*prometheus.GaugeVec)
+		m.segmentLag = util.MustRegisterOrGet(reg, m.segmentLag).(*prometheus.GaugeVec)
+		m.lastReadTimestamp = util.MustRegisterOrGet(reg, m.lastReadTimestamp).(*prometheus.GaugeVec)
 		m.watchersRunning = util.MustRegisterOrGet(reg, m.watchersRunning).(*prometheus.GaugeVec)
 	}
 
@@ -110,11 +110,11 @@ func NewWriter(walCfg Config, logger log.Logger, reg prometheus.Registerer) (*Wr
 		_ = reg.Register(wrt.lastWrittenTimestamp)
 	}
 
-	wrt.start(walCfg.MaxSegmentAge)
+	wrt.start(walCfg.MaxSegmentAge, walCfg.MaxSize)
 	return wrt, nil
 }
 
-func (wrt *Writer) start(maxSegmentAge time.Duration) {
+func (wrt *Writer) start(maxSegmentAge time.Duration, maxSize int64) {
 	wrt.wg.Add(1)
 	// main WAL writer routine
 	go func() {
@@ -151,7 +151,7 @@ func (wrt *Writer) start(maxSegmentAge time.Duration) {
 			select {
 			case <-trigger.C:
 				level.Debug(wrt.log).Log("msg", "Running wal old segments cleanup")
-				if err := wrt.cleanSegments(maxSegmentAge); err != nil {
+				if err := wrt.cleanSegments(maxSegmentAge, maxSize); err != nil {
 					level.Error(wrt.log).Log("msg", "Error cleaning old segments", "err", err)
 				}
 			case <-wrt.closeCleaner:
@@ -178,11 +178,12 @@ func (wrt *Writer) Stop() {
 	wrt.wal.Close()
 }
 
-// cleanSegments will remove segments older than maxAge from the WAL directory. If there's just one segment, none will be
-// deleted since it's likely there's active readers on it. In case there's multiple segments, each will be deleted if:
+// cleanSegments will remove segments older than maxAge from the WAL directory, and the oldest segments while the WAL
+// directory is bigger than maxSize, if set. If there's just one segment, none will be deleted since it's likely there's
+// active readers on it. In case there's multiple segments, each will be deleted if:
 // - It's not the last (highest numbered) segment
-// - It's last modified date is older than the max allowed age
-func (wrt *Writer) cleanSegments(maxAge time.Duration) error {
+// - It's last modified date is older than the max allowed age, or the segments up to it take more than maxSize bytes
+func (wrt *Writer) cleanSegments(maxAge time.Duration, maxSize int64) error {
 	maxModifiedAt := time.Now().Add(-maxAge)
 	walDir := wrt.wal.Dir()
 	segments, err := listSegments(walDir)
@@ -196,23 +197,36 @@ func (wrt *Writer) cleanSegments(maxAge time.Duration) error {
 	// find the most recent, or head segment to avoid cleaning it up
 	lastSegment := -1
 	maxReclaimed := -1
+	var totalSize int64
 	for _, segment := range segments {
 		if lastSegment < segment.number {
 			lastSegment = segment.number
 		}
+		totalSize += segment.size
 	}
+	// segments are sorted from oldest to newest, so the oldest ones are the first to be cleaned up if the WAL is too big
 	for _, segment := range segments {
-		if segment.lastModified.Before(maxModifiedAt) && segment.number != lastSegment {
-			// segment is older than allowed age, cleaning up
-			if err := os.Remove(filepath.Join(walDir, segment.name)); err != nil {
-				level.Error(wrt.log).Log("msg", "Error old wal segment", "err", err, "segmentNum", segment.number)
-			}
-			level.Debug(wrt.log).Log("msg", "Deleted old wal segment", "segmentNum", segment.number)
-			wrt.reclaimedOldSegmentsSpaceCounter.WithLabelValues().Add(float64(segment.size))
-			// keep track of the largest segment number reclaimed
-			if segment.number > maxReclaimed {
-				maxReclaimed = segment.number
-			}
+		if segment.number == lastSegment {
+			continue
+		}
+		tooOld := segment.lastModified.Before(maxModifiedAt)
+		tooBig := maxSize > 0 && totalSize > maxSize
+		if !tooOld && !tooBig {
+			continue
+		}
+		if !tooOld {
+			level.Warn(wrt.log).Log("msg", "WAL is bigger than the max allowed size, deleting oldest segment", "segmentNum", segment.number, "size", totalSize, "maxSize", maxSize)
+		}
+		// segment is older than allowed age, or the WAL is too big, cleaning up
+		if err := os.Remove(filepath.Join(walDir, segment.name)); err != nil {
+			level.Error(wrt.log).Log("msg", "Error old wal segment", "err", err, "segmentNum", segment.number)
+		}
+		level.Debug(wrt.log).Log("msg", "Deleted old wal segment", "segmentNum", segment.number)
+		wrt.reclaimedOldSegmentsSpaceCounter.WithLabelValues().Add(float64(segment.size))
+		totalSize -= segment.size
+		// keep track of the largest segment number reclaimed
+		if segment.number > maxReclaimed {
+			maxReclaimed = segment.number
 		}
 	}
 	// if we reclaimed at least one segment, notify all subscribers
@@ -156,6 +156,54 @@ func TestWriter_OldSegmentsAreCleanedUp(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestWriter_OldestSegmentsAreCleanedUpOverMaxSize(t *testing.T) {
+	logger := level.NewFilter(log.NewLogfmtLogger(os.Stdout), level.AllowDebug())
+	dir := t.TempDir()
+
+	reclaimed := []int{}
+
+	writer, err := NewWriter(Config{
+		Dir:     dir,
+		Enabled: true,
+		// segments are not old enough to be cleaned up during the test
+		MaxSegmentAge: time.Hour,
+		MaxSize:       1,
+	}, logger, prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer func() {
+		writer.Stop()
+	}()
+
+	writer.SubscribeCleanup(notifySegmentsCleanedFunc(func(num int) {
+		reclaimed = append(reclaimed, num)
+	}))
+
+	// write an entry in each of three segments
+	for i := 0; i < 3; i++ {
+		writer.Chan() <- loki.Entry{
+			Labels: model.LabelSet{"testing": "log"},
+			Entry: logproto.Entry{
+				Timestamp: time.Now(),
+				Line:      fmt.Sprintf("line %d", i),
+			},
+		}
+		require.NoError(t, writer.wal.Sync(), "failed to sync wal")
+		_, err = writer.wal.NextSegment()
+		require.NoError(t, err, "error closing current segment")
+	}
+
+	require.NoError(t, writer.cleanSegments(time.Hour, 1))
+
+	// all segments but the head one are over the size limit
+	for _, segment := range []string{"00000000", "00000001", "00000002"} {
+		_, err = os.Stat(filepath.Join(dir, segment))
+		require.ErrorIs(t, err, os.ErrNotExist, "expected segment %s to be cleaned up", segment)
+	}
+	_, err = os.Stat(filepath.Join(dir, "00000003"))
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, reclaimed)
+}
+
 func TestWriter_NoSegmentIsCleanedUpIfTheresOnlyOne(t *testing.T) {
 	logger := level.NewFilter(log.NewLogfmtLogger(os.Stdout), level.AllowDebug())
 	dir := t.TempDir()
@@ -9,6 +9,7 @@ import (
 	dskit "github.com/grafana/dskit/server"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"google.golang.org/grpc"
 )
 
 // TargetServer is wrapper around dskit.Server that handles some common
@@ -62,6 +63,12 @@ func NewTargetServer(logger log.Logger, metricsNamespace string, reg prometheus.
 
 // MountAndRun mounts the handlers and starting the server.
 func (ts *TargetServer) MountAndRun(mountRoute func(router *mux.Router)) error {
+	return ts.MountAndRunWithGRPC(mountRoute, func(*grpc.Server) {})
+}
+
+// MountAndRunWithGRPC mounts the HTTP handlers, registers the gRPC services
+// and starts the server.
+func (ts *TargetServer) MountAndRunWithGRPC(mountRoute func(router *mux.Router), registerServices func(server *grpc.Server)) error {
 	level.Info(ts.logger).Log("msg", "starting server")
 	srv, err := dskit.New(*ts.config)
 	if err != nil {
@@ -70,6 +77,7 @@ func (ts *TargetServer) MountAndRun(mountRoute func(router *mux.Router)) error {
 
 	ts.server = srv
 	mountRoute(ts.server.HTTP)
+	registerServices(ts.server.GRPC)
 
 	go func() {
 		err := srv.Run()
@@ -26,6 +26,8 @@ import (
 	"github.com/grafana/regexp"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
 // Action is the relabelling action to be performed.
@@ -326,6 +328,10 @@ func ComponentToPromRelabelConfigs(rcs []*Config) []*relabel.Config {
 	return res
 }
 
+func init() {
+	argument.RegisterCapsuleType("RelabelRules", reflect.TypeFor[Rules]())
+}
+
 // Rules returns the relabel configs in use for a relabeling component.
 type Rules []*Config
 
@@ -0,0 +1,218 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component/loki/process/metric"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// MetricsForwarding configures forwarding the metrics of the metrics stages
+// to Prometheus receivers.
+type MetricsForwarding struct {
+	ForwardTo []storage.Appendable `alloy:"forward_to,attr"`
+	Interval  time.Duration        `alloy:"interval,attr,optional"`
+}
+
+// DefaultMetricsForwarding provides the default settings of the
+// metrics_forwarding block.
+var DefaultMetricsForwarding = MetricsForwarding{
+	Interval: time.Minute,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (m *MetricsForwarding) SetToDefault() {
+	*m = DefaultMetricsForwarding
+}
+
+// Validate implements syntax.Validator.
+func (m *MetricsForwarding) Validate() error {
+	if m.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	return nil
+}
+
+// metricsStageRegisterer registers collectors with the component's
+// registerer, and additionally registers the collectors of the metrics
+// stages with a dedicated registry so that they can be gathered and
+// forwarded on their own.
+type metricsStageRegisterer struct {
+	prometheus.Registerer
+	metricsStages *prometheus.Registry
+}
+
+// Register implements prometheus.Registerer.
+func (r *metricsStageRegisterer) Register(c prometheus.Collector) error {
+	if err := r.Registerer.Register(c); err != nil {
+		return err
+	}
+	if isMetricsStageCollector(c) {
+		return r.metricsStages.Register(c)
+	}
+	return nil
+}
+
+// MustRegister implements prometheus.Registerer.
+func (r *metricsStageRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister implements prometheus.Registerer.
+func (r *metricsStageRegisterer) Unregister(c prometheus.Collector) bool {
+	if isMetricsStageCollector(c) {
+		r.metricsStages.Unregister(c)
+	}
+	return r.Registerer.Unregister(c)
+}
+
+func isMetricsStageCollector(c prometheus.Collector) bool {
+	switch c.(type) {
+	case *metric.Counters, *metric.Gauges, *metric.Histograms:
+		return true
+	default:
+		return false
+	}
+}
+
+// forwardMetrics periodically forwards the metrics of the metrics stages
+// until ctx is canceled.
+func (c *Component) forwardMetrics(ctx context.Context) {
+	for {
+		c.mut.RLock()
+		var interval time.Duration
+		if c.metricsForwarding != nil {
+			interval = c.metricsForwarding.Interval
+		}
+		c.mut.RUnlock()
+
+		// Metrics aren't forwarded if forwarding is disabled, until the
+		// component is updated.
+		var (
+			timer *time.Timer
+			tick  <-chan time.Time
+		)
+		if interval > 0 {
+			timer = time.NewTimer(interval)
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-c.metricsForwardingUpdated:
+		case <-tick:
+			if err := c.flushMetrics(ctx, time.Now()); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to forward metrics", "err", err)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// flushMetrics appends the current value of every series of the metrics
+// stages, and staleness markers for the series which were forwarded in the
+// previous flush but no longer exist.
+func (c *Component) flushMetrics(ctx context.Context, now time.Time) error {
+	c.mut.RLock()
+	registry := c.metricsStagesRegistry
+	c.mut.RUnlock()
+
+	families, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	ts := now.UnixMilli()
+	app := c.metricsFanout.Appender(ctx)
+	forwarded := make(map[uint64]labels.Labels, len(c.lastForwarded))
+	appendSample := func(lbls labels.Labels, v float64) error {
+		forwarded[lbls.Hash()] = lbls
+		_, err := app.Append(0, lbls, ts, v)
+		return err
+	}
+
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if err := appendMetric(mf.GetName(), mf.GetType(), m, appendSample); err != nil {
+				_ = app.Rollback()
+				return err
+			}
+		}
+	}
+
+	for h, lbls := range c.lastForwarded {
+		if _, ok := forwarded[h]; ok {
+			continue
+		}
+		if _, err := app.Append(0, lbls, ts, math.Float64frombits(value.StaleNaN)); err != nil {
+			_ = app.Rollback()
+			return err
+		}
+	}
+	c.lastForwarded = forwarded
+
+	return app.Commit()
+}
+
+// appendMetric converts a metric to samples, with the same series a scrape of
+// the metric would produce.
+func appendMetric(name string, typ dto.MetricType, m *dto.Metric, appendSample func(labels.Labels, float64) error) error {
+	series := func(name string, extra ...string) labels.Labels {
+		b := labels.NewScratchBuilder(len(m.GetLabel()) + 2)
+		b.Add(labels.MetricName, name)
+		for _, l := range m.GetLabel() {
+			b.Add(l.GetName(), l.GetValue())
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			b.Add(extra[i], extra[i+1])
+		}
+		b.Sort()
+		return b.Labels()
+	}
+
+	switch typ {
+	case dto.MetricType_COUNTER:
+		return appendSample(series(name), m.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		return appendSample(series(name), m.GetGauge().GetValue())
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		for _, b := range h.GetBucket() {
+			if math.IsInf(b.GetUpperBound(), +1) {
+				continue
+			}
+			le := strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)
+			if err := appendSample(series(name+"_bucket", labels.BucketLabel, le), float64(b.GetCumulativeCount())); err != nil {
+				return err
+			}
+		}
+		if err := appendSample(series(name+"_bucket", labels.BucketLabel, "+Inf"), float64(h.GetSampleCount())); err != nil {
+			return err
+		}
+		if err := appendSample(series(name+"_sum"), h.GetSampleSum()); err != nil {
+			return err
+		}
+		return appendSample(series(name+"_count"), float64(h.GetSampleCount()))
+	default:
+		return fmt.Errorf("unsupported metric type %s for metric %q", typ, name)
+	}
+}
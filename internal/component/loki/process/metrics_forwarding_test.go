@@ -0,0 +1,145 @@
+package process
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/loki/process/stages"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/internal/util/testappender"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestMetricsForwarding(t *testing.T) {
+	stagesConfig := func(counterName string) []stages.StageConfig {
+		type cfg struct {
+			Stages []stages.StageConfig `alloy:"stage,enum"`
+		}
+		var stagesCfg cfg
+		err := syntax.Unmarshal([]byte(fmt.Sprintf(`
+			stage.regex {
+				expression = "^(?P<size>\\d+)"
+			}
+			stage.metrics {
+				metric.counter {
+					name      = %q
+					action    = "inc"
+					match_all = true
+				}
+				metric.histogram {
+					name    = "size"
+					source  = "size"
+					buckets = [10, 100]
+				}
+			}`, counterName)), &stagesCfg)
+		require.NoError(t, err)
+		return stagesCfg.Stages
+	}
+
+	appender := testappender.NewCollectingAppender()
+	out := loki.NewLogsReceiver()
+	logger := util.TestAlloyLogger(t)
+	opts := component.Options{
+		Logger:        logger,
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		GetServiceData: func(name string) (interface{}, error) {
+			switch name {
+			case labelstore.ServiceName:
+				return labelstore.New(logger, prometheus.NewRegistry()), nil
+			case livedebugging.ServiceName:
+				return livedebugging.NewLiveDebugging(), nil
+			default:
+				return nil, fmt.Errorf("service not found %s", name)
+			}
+		},
+	}
+	args := Arguments{
+		ForwardTo: []loki.LogsReceiver{out},
+		Stages:    stagesConfig("lines"),
+		MetricsForwarding: &MetricsForwarding{
+			ForwardTo: []storage.Appendable{testappender.ConstantAppendable{Inner: appender}},
+			// Metrics are flushed explicitly by the test.
+			Interval: time.Hour,
+		},
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	go c.Run(t.Context())
+
+	c.receiver.Chan() <- loki.Entry{
+		Labels: model.LabelSet{"app": "api"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "42 bytes"},
+	}
+	select {
+	case <-out.Chan():
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line")
+	}
+
+	require.NoError(t, c.flushMetrics(t.Context(), time.UnixMilli(1000)))
+
+	expected := map[string]float64{
+		`{__name__="loki_process_custom_lines", app="api"}`:                  1,
+		`{__name__="loki_process_custom_size_bucket", app="api", le="10"}`:   0,
+		`{__name__="loki_process_custom_size_bucket", app="api", le="100"}`:  1,
+		`{__name__="loki_process_custom_size_bucket", app="api", le="+Inf"}`: 1,
+		`{__name__="loki_process_custom_size_sum", app="api"}`:               42,
+		`{__name__="loki_process_custom_size_count", app="api"}`:             1,
+	}
+	samples := appender.CollectedSamples()
+	require.Len(t, samples, len(expected))
+	for series, v := range expected {
+		require.Contains(t, samples, series)
+		require.Equal(t, v, samples[series].Value, series)
+		require.Equal(t, int64(1000), samples[series].Timestamp, series)
+	}
+
+	// Renaming the counter replaces its series, so a staleness marker is
+	// forwarded for the previous one.
+	args.Stages = stagesConfig("log_lines")
+	require.NoError(t, c.Update(args))
+	require.NoError(t, c.flushMetrics(t.Context(), time.UnixMilli(2000)))
+
+	stale := appender.LatestSampleFor(`{__name__="loki_process_custom_lines", app="api"}`)
+	require.Equal(t, int64(2000), stale.Timestamp)
+	require.True(t, value.IsStaleNaN(stale.Value))
+	// The new metrics don't have any series until a log line is processed.
+	require.Len(t, appender.CollectedSamples(), len(expected))
+	require.True(t, math.IsNaN(appender.LatestSampleFor(`{__name__="loki_process_custom_size_count", app="api"}`).Value))
+}
+
+func TestMetricsForwarding_Validate(t *testing.T) {
+	var args Arguments
+	err := syntax.Unmarshal([]byte(`
+		forward_to = []
+		metrics_forwarding {
+			forward_to = []
+			interval   = "0s"
+		}
+	`), &args)
+	require.ErrorContains(t, err, "interval must be greater than 0")
+
+	err = syntax.Unmarshal([]byte(`
+		forward_to = []
+		metrics_forwarding {
+			forward_to = []
+		}
+	`), &args)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, args.MetricsForwarding.Interval)
+}
@@ -10,11 +10,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/common/loki"
 	"github.com/grafana/alloy/internal/component/loki/process/stages"
+	alloyprom "github.com/grafana/alloy/internal/component/prometheus"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/labelstore"
 	"github.com/grafana/alloy/internal/service/livedebugging"
 )
 
@@ -38,8 +43,9 @@ func init() {
 // Arguments holds values which are used to configure the loki.process
 // component.
 type Arguments struct {
-	ForwardTo []loki.LogsReceiver  `alloy:"forward_to,attr"`
-	Stages    []stages.StageConfig `alloy:"stage,enum,optional"`
+	ForwardTo         []loki.LogsReceiver  `alloy:"forward_to,attr"`
+	Stages            []stages.StageConfig `alloy:"stage,enum,optional"`
+	MetricsForwarding *MetricsForwarding   `alloy:"metrics_forwarding,block,optional"`
 }
 
 // Exports exposes the receiver that can be used to send log entries to
@@ -67,6 +73,16 @@ type Component struct {
 	fanoutMut sync.RWMutex
 	fanout    []loki.LogsReceiver
 
+	// The metrics of the metrics stages are registered with
+	// metricsStagesRegistry, which is replaced every time the pipeline is
+	// created, so that they can be forwarded.
+	metricsStagesRegistry    *prometheus.Registry
+	metricsForwarding        *MetricsForwarding
+	metricsForwardingUpdated chan struct{}
+	metricsFanout            *alloyprom.Fanout
+	// lastForwarded is only used by the goroutine forwarding metrics.
+	lastForwarded map[uint64]labels.Labels
+
 	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
@@ -78,8 +94,9 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	}
 
 	c := &Component{
-		opts:               o,
-		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+		opts:                     o,
+		debugDataPublisher:       debugDataPublisher.(livedebugging.DebugDataPublisher),
+		metricsForwardingUpdated: make(chan struct{}, 1),
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -116,6 +133,11 @@ func (c *Component) Run(ctx context.Context) error {
 	go c.handleIn(ctx, wgIn)
 	wgOut.Add(1)
 	go c.handleOut(handleOutShutdown, wgOut)
+	wgIn.Add(1)
+	go func() {
+		defer wgIn.Done()
+		c.forwardMetrics(ctx)
+	}()
 
 	wgIn.Wait()
 	return nil
@@ -134,6 +156,22 @@ func (c *Component) Update(args component.Arguments) error {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
+	if newArgs.MetricsForwarding != nil {
+		if c.metricsFanout == nil {
+			data, err := c.opts.GetServiceData(labelstore.ServiceName)
+			if err != nil {
+				return err
+			}
+			c.metricsFanout = alloyprom.NewFanout(nil, c.opts.ID, c.opts.Registerer, data.(labelstore.LabelStore))
+		}
+		c.metricsFanout.UpdateChildren(newArgs.MetricsForwarding.ForwardTo)
+	}
+	c.metricsForwarding = newArgs.MetricsForwarding
+	select {
+	case c.metricsForwardingUpdated <- struct{}{}:
+	default:
+	}
+
 	// We want to create a new pipeline if the config changed or if this is the
 	// first load. This will allow a component with no stages to function
 	// properly.
@@ -142,10 +180,13 @@ func (c *Component) Update(args component.Arguments) error {
 			c.entryHandler.Stop()
 		}
 
-		pipeline, err := stages.NewPipeline(c.opts.Logger, newArgs.Stages, &c.opts.ID, c.opts.Registerer, c.opts.MinStability)
+		metricsStagesRegistry := prometheus.NewRegistry()
+		registerer := &metricsStageRegisterer{Registerer: c.opts.Registerer, metricsStages: metricsStagesRegistry}
+		pipeline, err := stages.NewPipeline(c.opts.Logger, newArgs.Stages, &c.opts.ID, registerer, c.opts.MinStability)
 		if err != nil {
 			return err
 		}
+		c.metricsStagesRegistry = metricsStagesRegistry
 		entryHandler := loki.NewEntryHandler(c.processOut, func() { pipeline.Cleanup() })
 		c.entryHandler = pipeline.Wrap(entryHandler)
 		c.processIn = c.entryHandler.Chan()
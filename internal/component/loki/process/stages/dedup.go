@@ -0,0 +1,183 @@
+package stages
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Configuration errors.
+var (
+	ErrDedupStageInvalidWindow     = errors.New("dedup stage window must be greater than 0")
+	ErrDedupStageInvalidMaxEntries = errors.New("dedup stage max_entries must be greater than 0")
+	ErrDedupStageEmptySource       = errors.New("dedup stage source must not contain empty names")
+)
+
+var defaultDedupReason = "dedup_stage"
+
+// DedupConfig contains the configuration for a dedupStage.
+type DedupConfig struct {
+	Source     []string      `alloy:"source,attr,optional"`
+	Window     time.Duration `alloy:"window,attr,optional"`
+	MaxEntries int           `alloy:"max_entries,attr,optional"`
+	DropReason string        `alloy:"drop_counter_reason,attr,optional"`
+}
+
+// DefaultDedupConfig contains the default DedupConfig values.
+var DefaultDedupConfig = DedupConfig{
+	Window:     time.Minute,
+	MaxEntries: 10000,
+	DropReason: defaultDedupReason,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *DedupConfig) SetToDefault() {
+	*args = DefaultDedupConfig
+}
+
+// Validate implements syntax.Validator.
+func (args *DedupConfig) Validate() error {
+	if args.Window <= 0 {
+		return ErrDedupStageInvalidWindow
+	}
+	if args.MaxEntries <= 0 {
+		return ErrDedupStageInvalidMaxEntries
+	}
+	for _, s := range args.Source {
+		if s == "" {
+			return ErrDedupStageEmptySource
+		}
+	}
+	return nil
+}
+
+// dedupStage drops log entries which were already seen for the same label set
+// within a time window.
+type dedupStage struct {
+	logger    log.Logger
+	cfg       DedupConfig
+	dropCount *prometheus.CounterVec
+	seen      *lru.Cache[uint64, time.Time]
+	now       func() time.Time
+}
+
+// newDedupStage creates a dedupStage from config.
+func newDedupStage(logger log.Logger, cfg DedupConfig, registerer prometheus.Registerer) (Stage, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	seen, err := lru.New[uint64, time.Time](cfg.MaxEntries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dedup cache: %w", err)
+	}
+
+	return &dedupStage{
+		logger:    log.With(logger, "component", "stage", "type", StageTypeDedup),
+		cfg:       cfg,
+		dropCount: getDropCountMetric(registerer),
+		seen:      seen,
+		now:       time.Now,
+	}, nil
+}
+
+// Run implements Stage.
+func (d *dedupStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		counter := d.dropCount.WithLabelValues(d.cfg.DropReason)
+		for e := range in {
+			if d.isDuplicate(e) {
+				counter.Inc()
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// isDuplicate reports whether the entry was already seen within the window,
+// and records it otherwise. The time an entry was first seen is kept so that
+// a line repeating more often than the window is still forwarded once per
+// window.
+func (d *dedupStage) isDuplicate(e Entry) bool {
+	key, ok := d.key(e)
+	if !ok {
+		return false
+	}
+
+	now := d.now()
+	if firstSeen, ok := d.seen.Get(key); ok && now.Sub(firstSeen) < d.cfg.Window {
+		if Debug {
+			level.Debug(d.logger).Log("msg", "dropping duplicate entry", "labels", e.Labels.String())
+		}
+		return true
+	}
+	d.seen.Add(key, now)
+	return false
+}
+
+// key hashes the label set of the entry together with either the log line or
+// the configured extracted values. It returns false if none of the configured
+// extracted values are present, in which case the entry can't be deduplicated.
+func (d *dedupStage) key(e Entry) (uint64, bool) {
+	h := xxhash.New()
+	_, _ = h.Write(binary.LittleEndian.AppendUint64(nil, uint64(e.Labels.Fingerprint())))
+
+	if len(d.cfg.Source) == 0 {
+		_, _ = h.WriteString(e.Line)
+		return h.Sum64(), true
+	}
+
+	found := false
+	for _, name := range d.cfg.Source {
+		_, _ = h.WriteString(name)
+		_, _ = h.Write(dedupSep)
+
+		v, ok := e.Extracted[name]
+		if !ok {
+			_, _ = h.Write(dedupMissing)
+			continue
+		}
+		s, err := getString(v)
+		if err != nil {
+			if Debug {
+				level.Debug(d.logger).Log("msg", "failed to convert extracted value to string", "source", name, "err", err)
+			}
+			_, _ = h.Write(dedupMissing)
+			continue
+		}
+		found = true
+		_, _ = h.WriteString(s)
+		_, _ = h.Write(dedupSep)
+	}
+	return h.Sum64(), found
+}
+
+// dedupSep separates the values written to the hash so that, for example, the
+// values "ab" and "c" do not collide with "a" and "bc". dedupMissing is
+// written in place of extracted values which are not set.
+var (
+	dedupSep     = []byte{'\xff'}
+	dedupMissing = []byte{'\xfe', '\xff'}
+)
+
+// Name implements Stage.
+func (d *dedupStage) Name() string {
+	return StageTypeDedup
+}
+
+// Cleanup implements Stage.
+func (d *dedupStage) Cleanup() {
+	d.seen.Purge()
+}
@@ -0,0 +1,218 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+var testDedupAlloyLine = `
+stage.dedup {
+	window = "1m"
+}
+`
+
+var testDedupAlloySource = `
+stage.json {
+	expressions = { "id" = "", "msg" = "" }
+}
+
+stage.dedup {
+	source = ["id"]
+}
+`
+
+var testDedupAlloyMatch = `
+stage.match {
+	selector = "{app=\"ha\"}"
+
+	stage.dedup {
+		drop_counter_reason = "ha_duplicate"
+	}
+}
+`
+
+func TestPipeline_Dedup(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config        string
+		entries       []Entry
+		expectedLines []string
+	}{
+		"drops identical lines of the same stream": {
+			config: testDedupAlloyLine,
+			entries: []Entry{
+				newEntry(nil, model.LabelSet{"app": "a"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "a"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "a"}, "world", time.Now()),
+			},
+			expectedLines: []string{"hello", "world"},
+		},
+		"keeps identical lines of different streams": {
+			config: testDedupAlloyLine,
+			entries: []Entry{
+				newEntry(nil, model.LabelSet{"app": "a"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "b"}, "hello", time.Now()),
+			},
+			expectedLines: []string{"hello", "hello"},
+		},
+		"dedups on extracted values": {
+			config: testDedupAlloySource,
+			entries: []Entry{
+				newEntry(nil, nil, `{"id":"1","msg":"first"}`, time.Now()),
+				newEntry(nil, nil, `{"id":"1","msg":"retry"}`, time.Now()),
+				newEntry(nil, nil, `{"id":"2","msg":"first"}`, time.Now()),
+			},
+			expectedLines: []string{`{"id":"1","msg":"first"}`, `{"id":"2","msg":"first"}`},
+		},
+		"keeps entries without extracted values": {
+			config: testDedupAlloySource,
+			entries: []Entry{
+				newEntry(nil, nil, `{"msg":"first"}`, time.Now()),
+				newEntry(nil, nil, `{"msg":"first"}`, time.Now()),
+			},
+			expectedLines: []string{`{"msg":"first"}`, `{"msg":"first"}`},
+		},
+		"only dedups inside matching match block": {
+			config: testDedupAlloyMatch,
+			entries: []Entry{
+				newEntry(nil, model.LabelSet{"app": "ha"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "ha"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "other"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "other"}, "hello", time.Now()),
+			},
+			expectedLines: []string{"hello", "hello", "hello"},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(testData.config), nil, prometheus.NewRegistry(), featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err)
+
+			out := processEntries(pl, testData.entries...)
+
+			var lines []string
+			for _, e := range out {
+				lines = append(lines, e.Line)
+			}
+			assert.Equal(t, testData.expectedLines, lines)
+		})
+	}
+}
+
+func TestDedupStage_Window(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	cfg := DefaultDedupConfig
+	cfg.Window = time.Minute
+	st, err := newDedupStage(util.TestAlloyLogger(t), cfg, registry)
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	s := st.(*dedupStage)
+	s.now = func() time.Time { return now }
+
+	e := newEntry(nil, model.LabelSet{"app": "a"}, "hello", now)
+	assert.False(t, s.isDuplicate(e))
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, s.isDuplicate(e))
+
+	// The window starts from the first time the line was seen, so repeats
+	// are forwarded again once it elapses.
+	now = now.Add(30 * time.Second)
+	assert.False(t, s.isDuplicate(e))
+	now = now.Add(59 * time.Second)
+	assert.True(t, s.isDuplicate(e))
+}
+
+func TestDedupStage_MaxEntries(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultDedupConfig
+	cfg.MaxEntries = 2
+	st, err := newDedupStage(util.TestAlloyLogger(t), cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	s := st.(*dedupStage)
+
+	for _, line := range []string{"a", "b", "c"} {
+		assert.False(t, s.isDuplicate(newEntry(nil, nil, line, time.Now())))
+	}
+	assert.Equal(t, 2, s.seen.Len())
+
+	// "a" was evicted as the least recently used entry.
+	assert.False(t, s.isDuplicate(newEntry(nil, nil, "a", time.Now())))
+	assert.True(t, s.isDuplicate(newEntry(nil, nil, "a", time.Now())))
+}
+
+func TestDedupStage_DropMetric(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(testDedupAlloyMatch), nil, registry, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	lbls := model.LabelSet{"app": "ha"}
+	processEntries(pl,
+		newEntry(nil, lbls, "hello", time.Now()),
+		newEntry(nil, lbls, "hello", time.Now()),
+		newEntry(nil, lbls, "hello", time.Now()),
+	)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(getDropCountMetric(registry).WithLabelValues("ha_duplicate")))
+}
+
+func TestDedupConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config string
+		err    error
+	}{
+		"defaults": {
+			config: ``,
+		},
+		"invalid window": {
+			config: `window = "0s"`,
+			err:    ErrDedupStageInvalidWindow,
+		},
+		"invalid max entries": {
+			config: `max_entries = 0`,
+			err:    ErrDedupStageInvalidMaxEntries,
+		},
+		"empty source": {
+			config: `source = [""]`,
+			err:    ErrDedupStageEmptySource,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			var cfg DedupConfig
+			err := syntax.Unmarshal([]byte(tt.config), &cfg)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, DefaultDedupConfig, cfg)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/jmespath/go-jmespath"
+	json "github.com/json-iterator/go"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Config Errors
+const (
+	ErrEmptyJSONSplitStageConfig = "empty json_split stage configuration"
+	ErrEmptyJSONSplitStageSource = "empty source"
+	ErrEmptyJSONSplitKeepField   = "keep_fields must not contain empty field names"
+	ErrJSONSplitNotArray         = "json_split expression did not evaluate to an array"
+)
+
+// JSONSplitConfig represents a JSON split Stage configuration.
+type JSONSplitConfig struct {
+	Expression    string   `alloy:"expression,attr,optional"`
+	Source        *string  `alloy:"source,attr,optional"`
+	KeepFields    []string `alloy:"keep_fields,attr,optional"`
+	DropMalformed bool     `alloy:"drop_malformed,attr,optional"`
+}
+
+// DefaultJSONSplitConfig contains the default JSONSplitConfig values.
+var DefaultJSONSplitConfig = JSONSplitConfig{
+	Expression: "@",
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *JSONSplitConfig) SetToDefault() {
+	*args = DefaultJSONSplitConfig
+}
+
+// validateJSONSplitConfig validates a json_split config and returns the
+// compiled jmespath expression that selects the array to split.
+func validateJSONSplitConfig(c *JSONSplitConfig) (*jmespath.JMESPath, error) {
+	if c == nil {
+		return nil, errors.New(ErrEmptyJSONSplitStageConfig)
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, errors.New(ErrEmptyJSONSplitStageSource)
+	}
+
+	for _, f := range c.KeepFields {
+		if f == "" {
+			return nil, errors.New(ErrEmptyJSONSplitKeepField)
+		}
+	}
+
+	expr := c.Expression
+	if expr == "" {
+		expr = DefaultJSONSplitConfig.Expression
+	}
+	jmes, err := jmespath.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrCouldNotCompileJMES, err)
+	}
+	return jmes, nil
+}
+
+// jsonSplitAPI sorts object keys so that the emitted lines are deterministic,
+// and keeps numbers as-is to avoid losing precision on large integers.
+var jsonSplitAPI = json.Config{
+	SortMapKeys: true,
+	UseNumber:   true,
+}.Froze()
+
+// jsonSplitStage fans a single log entry out into one entry per element of a
+// JSON array found in the log line.
+type jsonSplitStage struct {
+	cfg        *JSONSplitConfig
+	expression *jmespath.JMESPath
+	logger     log.Logger
+}
+
+// newJSONSplitStage creates a new json_split pipeline stage from a config.
+func newJSONSplitStage(logger log.Logger, cfg JSONSplitConfig) (Stage, error) {
+	expression, err := validateJSONSplitConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonSplitStage{
+		cfg:        &cfg,
+		expression: expression,
+		logger:     log.With(logger, "component", "stage", "type", StageTypeJSONSplit),
+	}, nil
+}
+
+// Run implements Stage.
+func (j *jsonSplitStage) Run(in chan Entry) chan Entry {
+	return RunWithSkipOrSendMany(in, func(e Entry) ([]Entry, bool) {
+		lines, err := j.split(e.Extracted, e.Line)
+		if err != nil {
+			return []Entry{e}, j.cfg.DropMalformed
+		}
+		if lines == nil {
+			// Nothing to split; forward the entry untouched.
+			return []Entry{e}, false
+		}
+
+		out := make([]Entry, 0, len(lines))
+		for _, line := range lines {
+			out = append(out, e.clone(line))
+		}
+		return out, false
+	})
+}
+
+// split returns the log lines for every element of the selected array. A nil
+// slice means that the entry should be forwarded as-is.
+func (j *jsonSplitStage) split(extracted map[string]interface{}, line string) ([]string, error) {
+	// If a source key is provided, the array is looked up in the extracted
+	// value instead of the log line.
+	input := line
+
+	if j.cfg.Source != nil {
+		if _, ok := extracted[*j.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(j.logger).Log("msg", "source does not exist in the set of extracted values", "source", *j.cfg.Source)
+			}
+			return nil, nil
+		}
+
+		value, err := getString(extracted[*j.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(j.logger).Log("msg", "failed to convert source value to string", "source", *j.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*j.cfg.Source]))
+			}
+			return nil, nil
+		}
+
+		input = value
+	}
+
+	var data interface{}
+	if err := jsonSplitAPI.Unmarshal([]byte(input), &data); err != nil {
+		if Debug {
+			level.Debug(j.logger).Log("msg", "failed to unmarshal log line", "err", err)
+		}
+		return nil, errors.New(ErrMalformedJSON)
+	}
+
+	r, err := j.expression.Search(data)
+	if err != nil {
+		if Debug {
+			level.Debug(j.logger).Log("msg", "failed to search JMES expression", "err", err)
+		}
+		return nil, nil
+	}
+
+	elements, ok := r.([]interface{})
+	if !ok {
+		if Debug {
+			level.Debug(j.logger).Log("msg", ErrJSONSplitNotArray, "value_type", reflect.TypeOf(r))
+		}
+		return nil, nil
+	}
+
+	parent, _ := data.(map[string]interface{})
+
+	lines := make([]string, 0, len(elements))
+	for _, el := range elements {
+		line, err := j.elementLine(parent, el)
+		if err != nil {
+			if Debug {
+				level.Debug(j.logger).Log("msg", "failed to marshal array element", "err", err)
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// elementLine builds the log line of a single array element. Fields listed in
+// keep_fields are copied from the parent object into object elements, unless
+// the element already defines them.
+func (j *jsonSplitStage) elementLine(parent map[string]interface{}, el interface{}) (string, error) {
+	switch v := el.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		for _, f := range j.cfg.KeepFields {
+			pv, ok := parent[f]
+			if !ok {
+				continue
+			}
+			if _, exists := v[f]; !exists {
+				v[f] = pv
+			}
+		}
+	}
+
+	b, err := jsonSplitAPI.Marshal(el)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Name implements Stage
+func (j *jsonSplitStage) Name() string {
+	return StageTypeJSONSplit
+}
+
+// Cleanup implements Stage.
+func (*jsonSplitStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,192 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testJSONSplitAlloyRoot = `
+stage.json_split {}
+`
+
+var testJSONSplitAlloyNested = `
+stage.json_split {
+	expression  = "events"
+	keep_fields = ["host", "service"]
+}
+
+stage.json {
+	expressions = { "msg" = "", "host" = "" }
+}
+`
+
+var testJSONSplitAlloyMatch = `
+stage.match {
+	selector = "{app=\"batch\"}"
+
+	stage.json_split {
+		expression = "events"
+	}
+}
+`
+
+var testJSONSplitAlloyDropMalformed = `
+stage.json_split {
+	drop_malformed = true
+}
+`
+
+func TestPipeline_JSONSplit(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	tests := map[string]struct {
+		config        string
+		labels        model.LabelSet
+		entry         string
+		expectedLines []string
+	}{
+		"split root array": {
+			config:        testJSONSplitAlloyRoot,
+			entry:         `[{"msg":"a"},{"msg":"b"},"plain",3]`,
+			expectedLines: []string{`{"msg":"a"}`, `{"msg":"b"}`, `plain`, `3`},
+		},
+		"split nested array keeping parent fields": {
+			config: testJSONSplitAlloyNested,
+			entry:  `{"host":"h1","service":"api","events":[{"msg":"a"},{"msg":"b","host":"h2"}]}`,
+			expectedLines: []string{
+				`{"host":"h1","msg":"a","service":"api"}`,
+				`{"host":"h2","msg":"b","service":"api"}`,
+			},
+		},
+		"not an array is passed through": {
+			config:        testJSONSplitAlloyRoot,
+			entry:         `{"msg":"a"}`,
+			expectedLines: []string{`{"msg":"a"}`},
+		},
+		"malformed json is passed through": {
+			config:        testJSONSplitAlloyRoot,
+			entry:         `not json`,
+			expectedLines: []string{`not json`},
+		},
+		"malformed json is dropped": {
+			config:        testJSONSplitAlloyDropMalformed,
+			entry:         `not json`,
+			expectedLines: nil,
+		},
+		"empty array emits nothing": {
+			config:        testJSONSplitAlloyRoot,
+			entry:         `[]`,
+			expectedLines: nil,
+		},
+		"split inside matching match block": {
+			config:        testJSONSplitAlloyMatch,
+			labels:        model.LabelSet{"app": "batch"},
+			entry:         `{"events":[1,2]}`,
+			expectedLines: []string{`1`, `2`},
+		},
+		"not split inside non-matching match block": {
+			config:        testJSONSplitAlloyMatch,
+			labels:        model.LabelSet{"app": "other"},
+			entry:         `{"events":[1,2]}`,
+			expectedLines: []string{`{"events":[1,2]}`},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err)
+
+			out := processEntries(pl, newEntry(nil, testData.labels, testData.entry, time.Now()))
+
+			var lines []string
+			for _, e := range out {
+				lines = append(lines, e.Line)
+			}
+			assert.Equal(t, testData.expectedLines, lines)
+		})
+	}
+}
+
+func TestJSONSplit_CopiesParentEntry(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(testJSONSplitAlloyNested), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	ts := time.Now()
+	in := newEntry(map[string]interface{}{"parent": "value"}, model.LabelSet{"app": "batch"}, `{"host":"h1","events":[{"msg":"a"},{"msg":"b"}]}`, ts)
+	in.StructuredMetadata = push.LabelsAdapter{{Name: "trace_id", Value: "abc"}}
+
+	out := processEntries(pl, in)
+	require.Len(t, out, 2)
+
+	for i, msg := range []string{"a", "b"} {
+		assert.Equal(t, model.LabelSet{"app": "batch"}, out[i].Labels)
+		assert.Equal(t, ts, out[i].Timestamp)
+		assert.Equal(t, in.StructuredMetadata, out[i].StructuredMetadata)
+		assert.Equal(t, "value", out[i].Extracted["parent"])
+		assert.Equal(t, msg, out[i].Extracted["msg"])
+		assert.Equal(t, "h1", out[i].Extracted["host"])
+	}
+
+	// Each emitted entry must own its own copy of the mutable fields.
+	out[0].Labels["app"] = "changed"
+	out[0].Extracted["parent"] = "changed"
+	out[0].StructuredMetadata[0].Value = "changed"
+	assert.Equal(t, model.LabelValue("batch"), out[1].Labels["app"])
+	assert.Equal(t, "value", out[1].Extracted["parent"])
+	assert.Equal(t, "abc", out[1].StructuredMetadata[0].Value)
+}
+
+func TestJSONSplitConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	emptyString := ""
+	tests := map[string]struct {
+		config JSONSplitConfig
+		err    string
+	}{
+		"default": {
+			config: DefaultJSONSplitConfig,
+		},
+		"empty source": {
+			config: JSONSplitConfig{Source: &emptyString},
+			err:    ErrEmptyJSONSplitStageSource,
+		},
+		"empty keep field": {
+			config: JSONSplitConfig{KeepFields: []string{""}},
+			err:    ErrEmptyJSONSplitKeepField,
+		},
+		"invalid expression": {
+			config: JSONSplitConfig{Expression: "events[?"},
+			err:    ErrCouldNotCompileJMES,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			_, err := validateJSONSplitConfig(&tt.config)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
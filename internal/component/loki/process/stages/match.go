@@ -33,8 +33,42 @@ type MatchConfig struct {
 	DropReason   string        `alloy:"drop_counter_reason,attr,optional"`
 }
 
+// Selector matches log entries against a LogQL stream selector with optional
+// line filters, for example `{app="foo"} |= "error"`.
+type Selector struct {
+	matchers []*labels.Matcher
+	filter   logql.Filter
+}
+
+// ParseSelector parses a LogQL stream selector with optional line filters.
+func ParseSelector(s string) (*Selector, error) {
+	expr, err := logql.ParseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := expr.Filter()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "error parsing pipeline", err)
+	}
+	return &Selector{
+		matchers: expr.Matchers(),
+		filter:   filter,
+	}, nil
+}
+
+// Matches returns true if the labels match the stream selector and the line
+// matches the line filters.
+func (s *Selector) Matches(lbls model.LabelSet, line string) bool {
+	for _, filter := range s.matchers {
+		if !filter.Matches(string(lbls[model.LabelName(filter.Name)])) {
+			return false
+		}
+	}
+	return s.filter == nil || s.filter([]byte(line))
+}
+
 // validateMatcherConfig validates the MatcherConfig for the matcherStage
-func validateMatcherConfig(cfg *MatchConfig) (logql.Expr, error) {
+func validateMatcherConfig(cfg *MatchConfig) (*Selector, error) {
 	if cfg.Selector == "" {
 		return nil, ErrSelectorRequired
 	}
@@ -53,7 +87,7 @@ func validateMatcherConfig(cfg *MatchConfig) (logql.Expr, error) {
 		return nil, ErrStagesWithDropLine
 	}
 
-	selector, err := logql.ParseExpr(cfg.Selector)
+	selector, err := ParseSelector(cfg.Selector)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", ErrSelectorSyntax, err)
 	}
@@ -82,11 +116,6 @@ func newMatcherStage(logger log.Logger, jobName *string, config MatchConfig, reg
 		}
 	}
 
-	filter, err := selector.Filter()
-	if err != nil {
-		return nil, fmt.Errorf("%v: %w", "error parsing pipeline", err)
-	}
-
 	dropReason := "match_stage"
 	if config.DropReason != "" {
 		dropReason = config.DropReason
@@ -95,10 +124,9 @@ func newMatcherStage(logger log.Logger, jobName *string, config MatchConfig, reg
 	return &matcherStage{
 		dropReason: dropReason,
 		dropCount:  getDropCountMetric(registerer),
-		matchers:   selector.Matchers(),
+		selector:   selector,
 		stage:      pl,
 		action:     config.Action,
-		filter:     filter,
 	}, nil
 }
 
@@ -123,8 +151,7 @@ func getDropCountMetric(registerer prometheus.Registerer) *prometheus.CounterVec
 type matcherStage struct {
 	dropReason string
 	dropCount  *prometheus.CounterVec
-	matchers   []*labels.Matcher
-	filter     logql.Filter
+	selector   *Selector
 	stage      Stage
 	action     string
 }
@@ -179,16 +206,7 @@ func (m *matcherStage) runDrop(in chan Entry) chan Entry {
 }
 
 func (m *matcherStage) processLogQL(e Entry) (Entry, bool) {
-	for _, filter := range m.matchers {
-		if !filter.Matches(string(e.Labels[model.LabelName(filter.Name)])) {
-			return e, false
-		}
-	}
-
-	if m.filter == nil || m.filter([]byte(e.Line)) {
-		return e, true
-	}
-	return e, false
+	return e, m.selector.Matches(e.Labels, e.Line)
 }
 
 // Name implements Stage
@@ -19,11 +19,13 @@ import (
 type StageConfig struct {
 	CRIConfig             *CRIConfig             `alloy:"cri,block,optional"`
 	DecolorizeConfig      *DecolorizeConfig      `alloy:"decolorize,block,optional"`
+	DedupConfig           *DedupConfig           `alloy:"dedup,block,optional"`
 	DockerConfig          *DockerConfig          `alloy:"docker,block,optional"`
 	DropConfig            *DropConfig            `alloy:"drop,block,optional"`
 	EventLogMessageConfig *EventLogMessageConfig `alloy:"eventlogmessage,block,optional"`
 	GeoIPConfig           *GeoIPConfig           `alloy:"geoip,block,optional"`
 	JSONConfig            *JSONConfig            `alloy:"json,block,optional"`
+	JSONSplitConfig       *JSONSplitConfig       `alloy:"json_split,block,optional"`
 	LabelAllowConfig      *LabelAllowConfig      `alloy:"label_keep,block,optional"`
 	LabelDropConfig       *LabelDropConfig       `alloy:"label_drop,block,optional"`
 	LabelsConfig          *LabelsConfig          `alloy:"labels,block,optional"`
@@ -1,21 +1,38 @@
 package stages
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
+	"reflect"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/uber/jaeger-client-go/utils"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
 )
 
 const (
 	ErrSamplingStageInvalidRate = "sampling stage failed to parse rate,Sampling Rate must be between 0.0 and 1.0, received %f"
+	ErrSamplingStageEmptySource = "sampling stage source must not be empty"
 )
 const maxRandomNumber = ^(uint64(1) << 63) // i.e. 0x7fffffffffffffff
 
+// The hash-based sampling mode uses the same constants as the hash_seed mode
+// of otelcol.processor.probabilistic_sampler, so that both components make
+// the same decision for a given trace ID and seed.
+const (
+	numHashBuckets        = 0x4000
+	bitMaskHashBuckets    = numHashBuckets - 1
+	percentageScaleFactor = numHashBuckets / 100.0
+)
+
 var (
 	defaultSamplingpReason = "sampling_stage"
 )
@@ -24,6 +41,8 @@ var (
 type SamplingConfig struct {
 	DropReason   string  `alloy:"drop_counter_reason,attr,optional"`
 	SamplingRate float64 `alloy:"rate,attr"`
+	Source       *string `alloy:"source,attr,optional"`
+	HashSeed     uint32  `alloy:"hash_seed,attr,optional"`
 }
 
 func (s *SamplingConfig) SetToDefault() {
@@ -34,6 +53,9 @@ func (s *SamplingConfig) Validate() error {
 	if s.SamplingRate < 0.0 || s.SamplingRate > 1.0 {
 		return fmt.Errorf(ErrSamplingStageInvalidRate, s.SamplingRate)
 	}
+	if s.Source != nil && *s.Source == "" {
+		return errors.New(ErrSamplingStageEmptySource)
+	}
 	return nil
 }
 
@@ -51,6 +73,9 @@ func newSamplingStage(logger log.Logger, cfg SamplingConfig, registerer promethe
 		dropCount:        getDropCountMetric(registerer),
 		samplingBoundary: samplingBoundary,
 		source:           source,
+		// The multiplication is carried out in 32-bit precision and rounded
+		// towards zero, like otelcol.processor.probabilistic_sampler does.
+		hashThreshold: uint32(float32(samplingRate*100) * percentageScaleFactor),
 	}
 }
 
@@ -60,6 +85,7 @@ type samplingStage struct {
 	dropCount        *prometheus.CounterVec
 	samplingBoundary uint64
 	source           rand.Source
+	hashThreshold    uint32
 }
 
 func (m *samplingStage) Run(in chan Entry) chan Entry {
@@ -68,7 +94,7 @@ func (m *samplingStage) Run(in chan Entry) chan Entry {
 		defer close(out)
 		counter := m.dropCount.WithLabelValues(m.cfg.DropReason)
 		for e := range in {
-			if m.isSampled() {
+			if m.shouldSample(e) {
 				out <- e
 				continue
 			}
@@ -78,6 +104,53 @@ func (m *samplingStage) Run(in chan Entry) chan Entry {
 	return out
 }
 
+// shouldSample makes a consistent decision based on the hash of the source
+// value if one is configured and present in the extracted map, and a random
+// decision otherwise.
+func (m *samplingStage) shouldSample(e Entry) bool {
+	if m.cfg.Source == nil {
+		return m.isSampled()
+	}
+
+	v, ok := e.Extracted[*m.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "source does not exist in the set of extracted values, sampling randomly", "source", *m.cfg.Source)
+		}
+		return m.isSampled()
+	}
+	s, err := getString(v)
+	if err != nil {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "failed to convert source value to string, sampling randomly", "source", *m.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+		}
+		return m.isSampled()
+	}
+	return m.isSampledHash(s)
+}
+
+// isSampledHash reports whether the value falls under the sampling rate. A
+// value which is a hex encoded 16 byte trace ID is hashed in its binary form,
+// which is how otelcol.processor.probabilistic_sampler hashes trace IDs.
+func (m *samplingStage) isSampledHash(value string) bool {
+	b := []byte(value)
+	if len(value) == 32 {
+		if id, err := hex.DecodeString(value); err == nil {
+			b = id
+		}
+	}
+	return computeHash(b, m.cfg.HashSeed)&bitMaskHashBuckets < m.hashThreshold
+}
+
+// computeHash hashes the seed followed by b using FNV-1a.
+func computeHash(b []byte, seed uint32) uint32 {
+	hash := fnv.New32a()
+	// hash.Write never returns an error, see hash/fnv/fnv.go.
+	_, _ = hash.Write(binary.LittleEndian.AppendUint32(nil, seed))
+	_, _ = hash.Write(b)
+	return hash.Sum32()
+}
+
 // code from jaeger project.
 // github.com/uber/jaeger-client-go@v2.30.0+incompatible/sampler.go:144
 // func (s *ProbabilisticSampler) IsSampled(id TraceID, operation string) (bool, []Tag)
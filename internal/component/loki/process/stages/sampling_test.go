@@ -1,6 +1,7 @@
 package stages
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -38,6 +39,75 @@ func TestSamplingPipeline(t *testing.T) {
 	assert.LessOrEqual(t, len(out), 70)
 }
 
+var testSamplingTraceIDAlloy = `
+stage.json {
+  expressions = { "trace_id" = "" }
+}
+
+stage.sampling {
+  rate      = 0.5
+  source    = "trace_id"
+  hash_seed = 123
+}
+`
+
+func TestSamplingPipeline_TraceID(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testSamplingTraceIDAlloy), &plName, registry, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	// The expected decisions are the ones otelcol.processor.probabilistic_sampler
+	// makes for the same trace IDs with sampling_percentage = 50 and hash_seed = 123.
+	traceIDs := map[string]bool{
+		"4bf92f3577b34da6a3ce929d0e0e4736": true,
+		"a1b2c3d4e5f60718293a4b5c6d7e8f90": true,
+		"0af7651916cd43dd8448eb211c80319c": false,
+		"5b8efff798038103d269b633813fc60c": false,
+		"00000000000000000000000000000001": false,
+	}
+
+	for traceID, sampled := range traceIDs {
+		entries := make([]Entry, 0)
+		for i := 0; i < 10; i++ {
+			entries = append(entries, newEntry(nil, nil, fmt.Sprintf(`{"trace_id":"%s","i":%d}`, traceID, i), time.Now()))
+		}
+		out := processEntries(pl, entries...)
+		if sampled {
+			assert.Len(t, out, 10, traceID)
+		} else {
+			assert.Empty(t, out, traceID)
+		}
+	}
+}
+
+func TestSamplingStage_isSampledHash(t *testing.T) {
+	source := "id"
+	tests := []struct {
+		name    string
+		rate    float64
+		value   string
+		sampled bool
+	}{
+		{name: "trace ID under threshold", rate: 0.5, value: "4bf92f3577b34da6a3ce929d0e0e4736", sampled: true},
+		{name: "trace ID over threshold", rate: 0.5, value: "0af7651916cd43dd8448eb211c80319c", sampled: false},
+		{name: "upper case trace ID", rate: 0.5, value: "4BF92F3577B34DA6A3CE929D0E0E4736", sampled: true},
+		{name: "non trace ID value", rate: 0.5, value: "req-1", sampled: false},
+		{name: "rate 0 never samples", rate: 0, value: "4bf92f3577b34da6a3ce929d0e0e4736", sampled: false},
+		{name: "rate 1 always samples", rate: 1, value: "ffffffffffffffffffffffffffffffff", sampled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newSamplingStage(util_log.Logger, SamplingConfig{
+				DropReason:   defaultSamplingpReason,
+				SamplingRate: tt.rate,
+				Source:       &source,
+				HashSeed:     123,
+			}, prometheus.NewRegistry()).(*samplingStage)
+			assert.Equal(t, tt.sampled, st.isSampledHash(tt.value))
+		})
+	}
+}
+
 func Test_validateSamplingConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -51,6 +121,14 @@ func Test_validateSamplingConfig(t *testing.T) {
 			},
 			wantErr: fmt.Errorf(ErrSamplingStageInvalidRate, 12.0),
 		},
+		{
+			name: "Empty source",
+			config: &SamplingConfig{
+				SamplingRate: 0.5,
+				Source:       new(string),
+			},
+			wantErr: errors.New(ErrSamplingStageEmptySource),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
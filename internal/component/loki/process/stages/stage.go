@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"slices"
 	"time"
 
 	"github.com/go-kit/log"
@@ -18,12 +19,14 @@ import (
 const (
 	StageTypeCRI        = "cri"
 	StageTypeDecolorize = "decolorize"
+	StageTypeDedup      = "dedup"
 	StageTypeDocker     = "docker"
 	StageTypeDrop       = "drop"
 	//TODO(thampiotr): Add support for eventlogmessage stage
 	StageTypeEventLogMessage    = "eventlogmessage"
 	StageTypeGeoIP              = "geoip"
 	StageTypeJSON               = "json"
+	StageTypeJSONSplit          = "json_split"
 	StageTypeLabel              = "labels"
 	StageTypeLabelAllow         = "labelallow"
 	StageTypeLabelDrop          = "labeldrop"
@@ -86,6 +89,28 @@ func (entry *Entry) copy() *Entry {
 	return n
 }
 
+// clone returns a deep copy of the entry with its line replaced by the given
+// one. It is used by stages that fan a single entry out into several, so that
+// downstream stages can freely mutate the labels, structured metadata and
+// extracted map of each copy.
+func (entry *Entry) clone(line string) Entry {
+	extracted := make(map[string]interface{}, len(entry.Extracted))
+	for k, v := range entry.Extracted {
+		extracted[k] = v
+	}
+
+	out := Entry{
+		Extracted: extracted,
+		Entry: loki.Entry{
+			Labels: entry.Labels.Clone(),
+			Entry:  entry.Entry.Entry,
+		},
+	}
+	out.Line = line
+	out.StructuredMetadata = slices.Clone(entry.StructuredMetadata)
+	return out
+}
+
 // stageProcessor Allow to transform a Processor (old synchronous pipeline stage) into an async Stage
 type stageProcessor struct {
 	Processor
@@ -148,6 +173,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.JSONSplitConfig != nil:
+		s, err = newJSONSplitStage(logger, *cfg.JSONSplitConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.LogfmtConfig != nil:
 		s, err = newLogfmtStage(logger, *cfg.LogfmtConfig)
 		if err != nil {
@@ -213,6 +243,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.DedupConfig != nil:
+		s, err = newDedupStage(logger, *cfg.DedupConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.DropConfig != nil:
 		s, err = newDropStage(logger, *cfg.DropConfig, registerer)
 		if err != nil {
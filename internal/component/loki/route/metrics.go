@@ -0,0 +1,39 @@
+package route
+
+import (
+	"github.com/grafana/alloy/internal/util"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	entriesProcessed prometheus_client.Counter
+	entriesWritten   *prometheus_client.CounterVec
+	entriesDropped   prometheus_client.Counter
+}
+
+// newMetrics creates a new set of metrics. If reg is non-nil, the metrics
+// will also be registered.
+func newMetrics(reg prometheus_client.Registerer) *metrics {
+	var m metrics
+
+	m.entriesProcessed = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "loki_route_entries_processed",
+		Help: "Total number of log entries processed",
+	})
+	m.entriesWritten = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "loki_route_entries_written",
+		Help: "Total number of log entries forwarded, by route",
+	}, []string{"route"})
+	m.entriesDropped = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "loki_route_entries_dropped",
+		Help: "Total number of log entries dropped because they didn't match any route",
+	})
+
+	if reg != nil {
+		m.entriesProcessed = util.MustRegisterOrGet(reg, m.entriesProcessed).(prometheus_client.Counter)
+		m.entriesWritten = util.MustRegisterOrGet(reg, m.entriesWritten).(*prometheus_client.CounterVec)
+		m.entriesDropped = util.MustRegisterOrGet(reg, m.entriesDropped).(prometheus_client.Counter)
+	}
+
+	return &m
+}
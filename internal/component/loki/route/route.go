@@ -0,0 +1,272 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/loki/process/stages"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "loki.route",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Routing modes.
+const (
+	ModeFirst = "first"
+	ModeAll   = "all"
+)
+
+// defaultRouteName is the name of the default route in metrics.
+const defaultRouteName = "default"
+
+// Arguments holds values which are used to configure the loki.route
+// component.
+type Arguments struct {
+	// The routes to evaluate, in order.
+	Routes []Route `alloy:"route,block,optional"`
+
+	// Whether entries are forwarded to the first or all matching routes.
+	Mode string `alloy:"mode,attr,optional"`
+
+	// Where the entries which don't match any route should be forwarded to.
+	DefaultForwardTo []loki.LogsReceiver `alloy:"default_forward_to,attr,optional"`
+}
+
+// Route forwards the entries matching a selector to a list of receivers.
+type Route struct {
+	Name      string              `alloy:"name,attr,optional"`
+	Selector  string              `alloy:"selector,attr"`
+	ForwardTo []loki.LogsReceiver `alloy:"forward_to,attr"`
+}
+
+// DefaultArguments provides the default arguments for the loki.route
+// component.
+var DefaultArguments = Arguments{
+	Mode: ModeFirst,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (a *Arguments) Validate() error {
+	switch a.Mode {
+	case ModeFirst, ModeAll:
+	default:
+		return fmt.Errorf("invalid mode %q, must be %q or %q", a.Mode, ModeFirst, ModeAll)
+	}
+
+	names := make(map[string]struct{}, len(a.Routes))
+	for i, r := range a.Routes {
+		name := r.name(i)
+		if name == defaultRouteName {
+			return fmt.Errorf("route name %q is reserved for the default route", defaultRouteName)
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("duplicate route name %q", name)
+		}
+		names[name] = struct{}{}
+
+		if r.Selector == "" {
+			return fmt.Errorf("route %q: %w", name, errors.New("selector must not be empty"))
+		}
+		if _, err := stages.ParseSelector(r.Selector); err != nil {
+			return fmt.Errorf("route %q: invalid selector: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// name returns the name of the route, which defaults to its index.
+func (r Route) name(index int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return strconv.Itoa(index)
+}
+
+// Exports holds values which are exported by the loki.route component.
+type Exports struct {
+	Receiver loki.LogsReceiver `alloy:"receiver,attr"`
+}
+
+// compiledRoute is a route with its parsed selector.
+type compiledRoute struct {
+	name      string
+	selector  *stages.Selector
+	forwardTo []loki.LogsReceiver
+}
+
+// Component implements the loki.route component.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+
+	mut              sync.RWMutex
+	routes           []compiledRoute
+	mode             string
+	defaultForwardTo []loki.LogsReceiver
+
+	receiver loki.LogsReceiver
+
+	debugDataPublisher livedebugging.DebugDataPublisher
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
+)
+
+// New creates a new loki.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:               o,
+		metrics:            newMetrics(o.Registerer),
+		receiver:           loki.NewLogsReceiver(),
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+	}
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	componentID := livedebugging.ComponentID(c.opts.ID)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver.Chan():
+			c.metrics.entriesProcessed.Inc()
+
+			routes, receivers := c.route(entry)
+			c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+				componentID,
+				livedebugging.LokiLog,
+				uint64(len(receivers)),
+				func() string {
+					return fmt.Sprintf("entry: %s, labels: %s => routes: %v", entry.Line, entry.Labels.String(), routes)
+				},
+			))
+
+			if len(routes) == 0 {
+				level.Debug(c.opts.Logger).Log("msg", "dropping entry not matching any route", "labels", entry.Labels.String())
+				c.metrics.entriesDropped.Inc()
+				continue
+			}
+			for _, r := range routes {
+				c.metrics.entriesWritten.WithLabelValues(r).Inc()
+			}
+
+			for _, receiver := range receivers {
+				select {
+				case <-ctx.Done():
+					return nil
+				case receiver.Chan() <- entry:
+				}
+			}
+		}
+	}
+}
+
+// route returns the names of the routes matching the entry and the receivers
+// to forward it to. A receiver is only returned once, even if it's part of
+// several matching routes.
+func (c *Component) route(entry loki.Entry) ([]string, []loki.LogsReceiver) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var (
+		names     []string
+		receivers []loki.LogsReceiver
+	)
+	for _, r := range c.routes {
+		if !r.selector.Matches(entry.Labels, entry.Line) {
+			continue
+		}
+		names = append(names, r.name)
+		receivers = appendUnique(receivers, r.forwardTo)
+		if c.mode == ModeFirst {
+			break
+		}
+	}
+
+	if len(names) == 0 && len(c.defaultForwardTo) > 0 {
+		names = append(names, defaultRouteName)
+		receivers = appendUnique(receivers, c.defaultForwardTo)
+	}
+	return names, receivers
+}
+
+func appendUnique(receivers []loki.LogsReceiver, add []loki.LogsReceiver) []loki.LogsReceiver {
+outer:
+	for _, a := range add {
+		for _, r := range receivers {
+			if r == a {
+				continue outer
+			}
+		}
+		receivers = append(receivers, a)
+	}
+	return receivers
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	routes := make([]compiledRoute, 0, len(newArgs.Routes))
+	for i, r := range newArgs.Routes {
+		selector, err := stages.ParseSelector(r.Selector)
+		if err != nil {
+			return fmt.Errorf("route %q: invalid selector: %w", r.name(i), err)
+		}
+		routes = append(routes, compiledRoute{
+			name:      r.name(i),
+			selector:  selector,
+			forwardTo: r.ForwardTo,
+		})
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.routes = routes
+	c.mode = newArgs.Mode
+	c.defaultForwardTo = newArgs.DefaultForwardTo
+
+	return nil
+}
+
+// LiveDebugging implements component.LiveDebugging.
+func (c *Component) LiveDebugging() {}
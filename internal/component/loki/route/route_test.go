@@ -0,0 +1,223 @@
+package route
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestRoute(t *testing.T) {
+	errors, api, all, fallback := loki.NewLogsReceiver(), loki.NewLogsReceiver(), loki.NewLogsReceiver(), loki.NewLogsReceiver()
+
+	tests := map[string]struct {
+		mode     string
+		fallback bool
+		labels   model.LabelSet
+		line     string
+		expected []loki.LogsReceiver
+	}{
+		"first matching route": {
+			mode:     ModeFirst,
+			labels:   model.LabelSet{"app": "api"},
+			line:     "level=error msg=failed",
+			expected: []loki.LogsReceiver{errors},
+		},
+		"all matching routes": {
+			mode:     ModeAll,
+			labels:   model.LabelSet{"app": "api"},
+			line:     "level=error msg=failed",
+			expected: []loki.LogsReceiver{errors, api, all},
+		},
+		"line filter not matching": {
+			mode:     ModeFirst,
+			labels:   model.LabelSet{"app": "api"},
+			line:     "level=info msg=ok",
+			expected: []loki.LogsReceiver{api},
+		},
+		"default route": {
+			mode:     ModeFirst,
+			fallback: true,
+			labels:   model.LabelSet{"app": "web"},
+			line:     "level=info msg=ok",
+			expected: []loki.LogsReceiver{fallback},
+		},
+		"no matching route": {
+			mode:     ModeAll,
+			labels:   model.LabelSet{"app": "web"},
+			line:     "level=info msg=ok",
+			expected: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			args := Arguments{
+				Mode: tc.mode,
+				Routes: []Route{
+					{Name: "errors", Selector: `{app=~".+"} |= "level=error"`, ForwardTo: []loki.LogsReceiver{errors}},
+					{Name: "api", Selector: `{app="api"}`, ForwardTo: []loki.LogsReceiver{api}},
+					// The receiver of the errors route is only sent the entry once.
+					{Selector: `{app="api"}`, ForwardTo: []loki.LogsReceiver{errors, all}},
+				},
+			}
+			if tc.fallback {
+				args.DefaultForwardTo = []loki.LogsReceiver{fallback}
+			}
+			require.NoError(t, args.Validate())
+
+			c, err := New(testOptions(t), args)
+			require.NoError(t, err)
+
+			_, receivers := c.route(loki.Entry{
+				Labels: tc.labels,
+				Entry:  logproto.Entry{Timestamp: time.Now(), Line: tc.line},
+			})
+			require.Equal(t, tc.expected, receivers)
+		})
+	}
+}
+
+func TestRoute_Run(t *testing.T) {
+	matched, fallback := loki.NewLogsReceiver(), loki.NewLogsReceiver()
+	opts := testOptions(t)
+
+	c, err := New(opts, Arguments{
+		Mode: ModeFirst,
+		Routes: []Route{
+			{Name: "errors", Selector: `{app="api"} |= "error"`, ForwardTo: []loki.LogsReceiver{matched}},
+		},
+		DefaultForwardTo: []loki.LogsReceiver{fallback},
+	})
+	require.NoError(t, err)
+	go c.Run(t.Context())
+
+	send := func(line string) {
+		select {
+		case c.receiver.Chan() <- loki.Entry{
+			Labels: model.LabelSet{"app": "api"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+		}:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed sending log entry")
+		}
+	}
+	receive := func(receiver loki.LogsReceiver, line string) {
+		select {
+		case e := <-receiver.Chan():
+			require.Equal(t, line, e.Line)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for log entry")
+		}
+	}
+
+	send("an error")
+	receive(matched, "an error")
+	send("all good")
+	receive(fallback, "all good")
+
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.entriesProcessed))
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.entriesWritten.WithLabelValues("errors")))
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.entriesWritten.WithLabelValues(defaultRouteName)))
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"valid": {
+			config: `
+				route {
+					selector   = "{app=\"api\"} |= \"error\""
+					forward_to = []
+				}
+				route {
+					name       = "web"
+					selector   = "{app=\"web\"}"
+					forward_to = []
+				}
+				mode = "all"
+			`,
+		},
+		"invalid mode": {
+			config: `mode = "some"`,
+			err:    `invalid mode "some"`,
+		},
+		"invalid selector": {
+			config: `
+				route {
+					selector   = "app=api"
+					forward_to = []
+				}
+			`,
+			err: `route "0": invalid selector`,
+		},
+		"duplicate name": {
+			config: `
+				route {
+					name       = "api"
+					selector   = "{app=\"api\"}"
+					forward_to = []
+				}
+				route {
+					name       = "api"
+					selector   = "{app=\"web\"}"
+					forward_to = []
+				}
+			`,
+			err: `duplicate route name "api"`,
+		},
+		"reserved name": {
+			config: `
+				route {
+					name       = "default"
+					selector   = "{app=\"api\"}"
+					forward_to = []
+				}
+			`,
+			err: `route name "default" is reserved`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte(tc.config), &args)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func testOptions(t *testing.T) component.Options {
+	return component.Options{
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     prometheus.NewRegistry(),
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+	}
+}
+
+func getServiceData(name string) (interface{}, error) {
+	switch name {
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/sha1"
 	"embed"
 	"fmt"
+	"math"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +35,9 @@ type Rule struct {
 	name        string
 	regex       *regexp.Regexp
 	secretGroup int
+	keywords    []string // Lowercase keywords, one of which must be in the text for the rule to apply
+	entropy     float64  // Minimum Shannon entropy of the secret
+	auditOnly   bool
 	allowlist   []AllowRule
 }
 
@@ -55,6 +60,7 @@ func init() {
 // - loki_secretfilter_secrets_redacted_by_rule_total: Number of secrets redacted, partitioned by rule name.
 // - loki_secretfilter_secrets_redacted_by_origin: Number of secrets redacted, partitioned by origin label value.
 // - loki_secretfilter_secrets_allowlisted_total: Number of secrets that matched a rule but were in an allowlist, partitioned by source.
+// - loki_secretfilter_secrets_audited_total: Number of secrets detected but not redacted because of the audit mode, partitioned by rule name.
 
 // Arguments holds values which are used to configure the secretfilter
 // component.
@@ -67,6 +73,25 @@ type Arguments struct {
 	AllowList      []string            `alloy:"allowlist,attr,optional"`       // List of regexes to allowlist (on top of what's in the Gitleaks config)
 	PartialMask    uint                `alloy:"partial_mask,attr,optional"`    // Show the first N characters of the secret (default: 0)
 	OriginLabel    string              `alloy:"origin_label,attr,optional"`    // The label name to use for tracking metrics by origin (if empty, no origin metrics are collected)
+	Rules          []RuleConfig        `alloy:"rule,block,optional"`           // Custom rules, used on top of the rules of the Gitleaks config
+
+	ScanStructuredMetadata bool     `alloy:"scan_structured_metadata,attr,optional"` // Also scan the values of the structured metadata
+	ScanLabels             []string `alloy:"scan_labels,attr,optional"`              // Names of the labels whose values are also scanned
+	AuditOnly              bool     `alloy:"audit_only,attr,optional"`               // Only count the secrets found by all rules without redacting them
+	AuditLabel             string   `alloy:"audit_label,attr,optional"`              // Label listing the rules which found secrets that weren't redacted because of the audit mode
+}
+
+// RuleConfig is a secret detection rule defined in the Alloy configuration.
+// The fields have the same meaning as in the Gitleaks config. Keywords and
+// entropy are only supported for rules defined in the Alloy configuration.
+type RuleConfig struct {
+	ID          string   `alloy:"id,attr"`
+	Regex       string   `alloy:"regex,attr"`
+	SecretGroup int      `alloy:"secret_group,attr,optional"`
+	Keywords    []string `alloy:"keywords,attr,optional"`
+	Entropy     float64  `alloy:"entropy,attr,optional"`
+	AllowList   []string `alloy:"allowlist,attr,optional"`
+	AuditOnly   bool     `alloy:"audit_only,attr,optional"` // Only count the secrets found by this rule without redacting them
 }
 
 // Exports holds the values exported by the loki.secretfilter component.
@@ -82,6 +107,49 @@ func (args *Arguments) SetToDefault() {
 	*args = DefaultArguments
 }
 
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	ids := make(map[string]struct{}, len(args.Rules))
+	for _, r := range args.Rules {
+		if r.ID == "" {
+			return fmt.Errorf("rule id must not be empty")
+		}
+		if _, ok := ids[r.ID]; ok {
+			return fmt.Errorf("duplicate rule id %q", r.ID)
+		}
+		ids[r.ID] = struct{}{}
+
+		if r.Regex == "" {
+			return fmt.Errorf("rule %q: regex must not be empty", r.ID)
+		}
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return fmt.Errorf("rule %q: invalid regex: %w", r.ID, err)
+		}
+		if r.SecretGroup < 0 || r.SecretGroup > re.NumSubexp() {
+			return fmt.Errorf("rule %q: secret_group %d doesn't exist in the regex", r.ID, r.SecretGroup)
+		}
+		if r.Entropy < 0 {
+			return fmt.Errorf("rule %q: entropy must not be negative", r.ID)
+		}
+		for _, a := range r.AllowList {
+			if _, err := regexp.Compile(a); err != nil {
+				return fmt.Errorf("rule %q: invalid allowlist regex: %w", r.ID, err)
+			}
+		}
+	}
+
+	for _, l := range args.ScanLabels {
+		if !model.LabelName(l).IsValid() {
+			return fmt.Errorf("invalid label name %q in scan_labels", l)
+		}
+	}
+	if args.AuditLabel != "" && !model.LabelName(args.AuditLabel).IsValidLegacy() {
+		return fmt.Errorf("invalid audit_label %q", args.AuditLabel)
+	}
+	return nil
+}
+
 var (
 	_ component.Component     = (*Component)(nil)
 	_ component.LiveDebugging = (*Component)(nil)
@@ -142,6 +210,9 @@ type metrics struct {
 	// Number of secrets that matched but were in allowlist
 	secretsAllowlistedTotal *prometheus.CounterVec
 
+	// Number of secrets that matched but weren't redacted because of the audit mode
+	secretsAuditedTotal *prometheus.CounterVec
+
 	// Summary of time taken for redaction log processing
 	processingDuration prometheus.Summary
 }
@@ -176,6 +247,12 @@ func newMetrics(reg prometheus.Registerer, originLabel string) *metrics {
 		Help:      "Number of secrets that matched a rule but were in an allowlist, partitioned by source.",
 	}, []string{"source"})
 
+	m.secretsAuditedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "loki_secretfilter",
+		Name:      "secrets_audited_total",
+		Help:      "Number of secrets detected but not redacted because of the audit mode, partitioned by rule name.",
+	}, []string{"rule"})
+
 	m.processingDuration = prometheus.NewSummary(prometheus.SummaryOpts{
 		Subsystem: "loki_secretfilter",
 		Name:      "processing_duration_seconds",
@@ -194,6 +271,7 @@ func newMetrics(reg prometheus.Registerer, originLabel string) *metrics {
 			m.secretsRedactedByOrigin = util.MustRegisterOrGet(reg, m.secretsRedactedByOrigin).(*prometheus.CounterVec)
 		}
 		m.secretsAllowlistedTotal = util.MustRegisterOrGet(reg, m.secretsAllowlistedTotal).(*prometheus.CounterVec)
+		m.secretsAuditedTotal = util.MustRegisterOrGet(reg, m.secretsAuditedTotal).(*prometheus.CounterVec)
 		m.processingDuration = util.MustRegisterOrGet(reg, m.processingDuration).(prometheus.Summary)
 	}
 
@@ -267,7 +345,74 @@ func (c *Component) processEntry(entry loki.Entry) loki.Entry {
 		c.metrics.processingDuration.Observe(time.Since(start).Seconds())
 	}()
 
+	s := scanState{labels: entry.Labels, audited: make(map[string]struct{})}
+	entry.Line = c.redactSecrets(entry.Line, &s)
+
+	if c.args.ScanStructuredMetadata && len(entry.StructuredMetadata) > 0 {
+		// The structured metadata may be shared with other components, so it's copied before being redacted.
+		entry.StructuredMetadata = slices.Clone(entry.StructuredMetadata)
+		for i := range entry.StructuredMetadata {
+			entry.StructuredMetadata[i].Value = c.redactSecrets(entry.StructuredMetadata[i].Value, &s)
+		}
+	}
+
+	var labels model.LabelSet
+	for _, name := range c.args.ScanLabels {
+		value, ok := entry.Labels[model.LabelName(name)]
+		if !ok {
+			continue
+		}
+		redacted := c.redactSecrets(string(value), &s)
+		if redacted == string(value) {
+			continue
+		}
+		// Same as for the structured metadata, the labels are copied before being modified.
+		if labels == nil {
+			labels = entry.Labels.Clone()
+		}
+		labels[model.LabelName(name)] = model.LabelValue(redacted)
+	}
+
+	if c.args.AuditLabel != "" && len(s.audited) > 0 {
+		if labels == nil {
+			labels = entry.Labels.Clone()
+		}
+		rules := make([]string, 0, len(s.audited))
+		for rule := range s.audited {
+			rules = append(rules, rule)
+		}
+		slices.Sort(rules)
+		labels[model.LabelName(c.args.AuditLabel)] = model.LabelValue(strings.Join(rules, ","))
+	}
+
+	if labels != nil {
+		entry.Labels = labels
+	}
+	return entry
+}
+
+// scanState holds the state shared by the scans of the different parts of a log entry.
+type scanState struct {
+	// Labels of the log entry as received, used for the origin metrics
+	labels model.LabelSet
+	// Names of the rules which found secrets that weren't redacted because of the audit mode
+	audited map[string]struct{}
+}
+
+// redactSecrets applies all the rules to the text and returns it with the secrets found redacted.
+func (c *Component) redactSecrets(text string, s *scanState) string {
+	// Lowercase text used to look for keywords, computed when needed
+	var lowerText string
 	for _, r := range c.Rules {
+		if len(r.keywords) > 0 {
+			if lowerText == "" {
+				lowerText = strings.ToLower(text)
+			}
+			if !containsAny(lowerText, r.keywords) {
+				continue
+			}
+		}
+
 		// To find the secret within the text captured by the regex (and avoid being too greedy), we can use the 'secretGroup' field in the gitleaks.toml file.
 		// But it's rare for regexes to have this field set, so we can use a simple heuristic in other cases.
 		//
@@ -277,7 +422,7 @@ func (c *Component) processEntry(entry loki.Entry) loki.Entry {
 		//
 		// For the first case, we can replace the entire match with the redaction string.
 		// For the second case, we can replace the first submatch with the redaction string (to avoid redacting something else than the secret such as delimiters).
-		for _, occ := range r.regex.FindAllStringSubmatch(entry.Line, -1) {
+		for _, occ := range r.regex.FindAllStringSubmatch(text, -1) {
 			// By default, the secret is the full match group
 			secret := occ[0]
 
@@ -321,8 +466,22 @@ func (c *Component) processEntry(entry loki.Entry) loki.Entry {
 				continue
 			}
 
-			// Redact the secret (redactLine replaces ALL instances of the secret in the line)
-			entry.Line = c.redactLine(entry.Line, secret, r.name)
+			// Skip secrets which are not random enough
+			if r.entropy > 0 && shannonEntropy(secret) < r.entropy {
+				level.Debug(c.opts.Logger).Log("msg", "secret below entropy threshold", "rule", r.name)
+				continue
+			}
+
+			// In audit mode, only record the secret
+			if r.auditOnly || c.args.AuditOnly {
+				c.metrics.secretsAuditedTotal.WithLabelValues(r.name).Inc()
+				s.audited[r.name] = struct{}{}
+				continue
+			}
+
+			// Redact the secret (redactLine replaces ALL instances of the secret in the text)
+			text = c.redactLine(text, secret, r.name)
+			lowerText = ""
 
 			// Record metrics for the redacted secret
 			c.metrics.secretsRedactedTotal.Inc()
@@ -330,15 +489,41 @@ func (c *Component) processEntry(entry loki.Entry) loki.Entry {
 
 			// Record metrics for origin label
 			// Only track if the origin label is specified and the label exists in the log entry
-			if c.args.OriginLabel != "" && len(entry.Labels) > 0 {
-				if value, ok := entry.Labels[model.LabelName(c.args.OriginLabel)]; ok {
+			if c.args.OriginLabel != "" && len(s.labels) > 0 {
+				if value, ok := s.labels[model.LabelName(c.args.OriginLabel)]; ok {
 					c.metrics.secretsRedactedByOrigin.WithLabelValues(string(value)).Inc()
 				}
 			}
 		}
 	}
 
-	return entry
+	return text
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy returns the Shannon entropy of the string, in bits per character, like Gitleaks does.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	var n float64
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
 }
 
 func (c *Component) redactLine(line string, secret string, ruleName string) string {
@@ -406,6 +591,9 @@ func (c *Component) Update(args component.Arguments) error {
 
 	var ruleGenericApiKey *Rule = nil
 
+	// Reset the rules
+	c.Rules = nil
+
 	// Compile regexes
 	for _, rule := range gitleaksCfg.Rules {
 		// If the rule regex is empty, skip this rule
@@ -426,61 +614,41 @@ func (c *Component) Update(args component.Arguments) error {
 				continue
 			}
 		}
-		re, err := regexp.Compile(rule.Regex)
-		if err != nil {
-			level.Error(c.opts.Logger).Log("msg", "error compiling regex", "error", err)
-			return err
-		}
-		// If the rule regex matches the empty string, skip this rule
-		if re.Match([]byte("")) {
-			level.Warn(c.opts.Logger).Log("msg", "excluded rule due to matching the empty string", "rule", rule.ID)
-			continue
-		}
-		// If the rule regex matches the redaction string, skip this rule
-		redactionString := "<REDACTED-SECRET:" + rule.ID + ">"
-		if c.args.RedactWith != "" {
-			redactionString = c.args.RedactWith
-			redactionString = strings.ReplaceAll(redactionString, "$SECRET_NAME", rule.ID)
-		}
-		if re.Match([]byte(redactionString)) {
-			level.Warn(c.opts.Logger).Log("msg", "excluded rule due to matching the redaction string", "rule", rule.ID)
-			continue
-		}
 
-		// Compile rule-specific allowlist regexes
-		var allowlist []AllowRule
-		for _, r := range rule.Allowlist.Regexes {
-			re, err := regexp.Compile(r)
-			if err != nil {
-				level.Error(c.opts.Logger).Log("msg", "error compiling allowlist regex", "error", err)
-				return err
-			}
-			allowlist = append(allowlist, AllowRule{Regex: re, Source: fmt.Sprintf("rule %s", rule.ID)})
+		ruleCfg := RuleConfig{
+			ID:          rule.ID,
+			Regex:       rule.Regex,
+			SecretGroup: rule.SecretGroup,
+			AllowList:   rule.Allowlist.Regexes,
 		}
 		for _, currAllowList := range rule.Allowlists {
-			for _, r := range currAllowList.Regexes {
-				re, err := regexp.Compile(r)
-				if err != nil {
-					level.Error(c.opts.Logger).Log("msg", "error compiling allowlist regex", "error", err)
-					return err
-				}
-				allowlist = append(allowlist, AllowRule{Regex: re, Source: fmt.Sprintf("rule %s", rule.ID)})
-			}
+			ruleCfg.AllowList = append(ruleCfg.AllowList, currAllowList.Regexes...)
 		}
-
-		newRule := Rule{
-			name:        rule.ID,
-			regex:       re,
-			secretGroup: rule.SecretGroup,
-			allowlist:   allowlist,
+		newRule, err := c.compileRule(ruleCfg)
+		if err != nil {
+			return err
+		}
+		if newRule == nil {
+			continue
 		}
 
 		// We treat the generic API key rule separately as we want to add it in last position
 		// to the list of rules (so that is has the lowest priority)
 		if strings.ToLower(rule.ID) == "generic-api-key" {
-			ruleGenericApiKey = &newRule
+			ruleGenericApiKey = newRule
 		} else {
-			c.Rules = append(c.Rules, newRule)
+			c.Rules = append(c.Rules, *newRule)
+		}
+	}
+
+	// Add the rules from the Alloy config, which aren't filtered by type
+	for _, rule := range c.args.Rules {
+		newRule, err := c.compileRule(rule)
+		if err != nil {
+			return err
+		}
+		if newRule != nil {
+			c.Rules = append(c.Rules, *newRule)
 		}
 	}
 
@@ -516,4 +684,56 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// compileRule compiles the regexes of a rule. It returns nil if the rule
+// must be excluded.
+func (c *Component) compileRule(rule RuleConfig) (*Rule, error) {
+	re, err := regexp.Compile(rule.Regex)
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "error compiling regex", "error", err)
+		return nil, err
+	}
+	// If the rule regex matches the empty string, skip this rule
+	if re.Match([]byte("")) {
+		level.Warn(c.opts.Logger).Log("msg", "excluded rule due to matching the empty string", "rule", rule.ID)
+		return nil, nil
+	}
+	// If the rule regex matches the redaction string, skip this rule
+	redactionString := "<REDACTED-SECRET:" + rule.ID + ">"
+	if c.args.RedactWith != "" {
+		redactionString = c.args.RedactWith
+		redactionString = strings.ReplaceAll(redactionString, "$SECRET_NAME", rule.ID)
+	}
+	if re.Match([]byte(redactionString)) {
+		level.Warn(c.opts.Logger).Log("msg", "excluded rule due to matching the redaction string", "rule", rule.ID)
+		return nil, nil
+	}
+
+	// Compile rule-specific allowlist regexes
+	var allowlist []AllowRule
+	for _, r := range rule.AllowList {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			level.Error(c.opts.Logger).Log("msg", "error compiling allowlist regex", "error", err)
+			return nil, err
+		}
+		allowlist = append(allowlist, AllowRule{Regex: re, Source: fmt.Sprintf("rule %s", rule.ID)})
+	}
+
+	// Keywords are matched case-insensitively, like in Gitleaks
+	keywords := make([]string, 0, len(rule.Keywords))
+	for _, k := range rule.Keywords {
+		keywords = append(keywords, strings.ToLower(k))
+	}
+
+	return &Rule{
+		name:        rule.ID,
+		regex:       re,
+		secretGroup: rule.SecretGroup,
+		keywords:    keywords,
+		entropy:     rule.Entropy,
+		auditOnly:   rule.AuditOnly,
+		allowlist:   allowlist,
+	}, nil
+}
+
 func (c *Component) LiveDebugging() {}
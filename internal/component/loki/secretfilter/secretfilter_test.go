@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/loki/pkg/push"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/jaswdr/faker/v2"
 	"github.com/prometheus/client_golang/prometheus"
@@ -1328,3 +1329,208 @@ func TestArgumentsUpdate(t *testing.T) {
 		})
 	}
 }
+
+func newTestComponent(t *testing.T, config string) (*Component, *prometheus.Registry) {
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(config), &args))
+
+	registry := prometheus.NewRegistry()
+	c, err := New(component.Options{
+		Logger:         util.TestLogger(t),
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+		Registerer:     registry,
+	}, args)
+	require.NoError(t, err)
+	return c, registry
+}
+
+func TestCustomRules(t *testing.T) {
+	c, _ := newTestComponent(t, `
+		forward_to = []
+		types      = ["none"]
+
+		rule {
+			id           = "internal-token"
+			regex        = "token=(itk_[a-zA-Z0-9]{10})"
+			secret_group = 1
+			keywords     = ["ITK_"]
+			allowlist    = ["itk_test[0-9]+"]
+		}
+
+		rule {
+			id      = "random-password"
+			regex   = "password=(\\S+)"
+			entropy = 3
+		}
+	`)
+	require.Len(t, c.Rules, 2)
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{
+			line:     "login with token=itk_a1b2c3d4e5",
+			expected: "login with token=<REDACTED-SECRET:internal-token>",
+		},
+		{
+			line:     "login with token=itk_test123456",
+			expected: "login with token=itk_test123456",
+		},
+		{
+			line:     "login with password=xK9#mQ2$vL7!",
+			expected: "login with password=<REDACTED-SECRET:random-password>",
+		},
+		{
+			// The entropy of the password is too low for it to be a secret.
+			line:     "login with password=aaaaaaaa",
+			expected: "login with password=aaaaaaaa",
+		},
+	}
+	for _, tc := range tests {
+		entry := c.processEntry(loki.Entry{Entry: logproto.Entry{Timestamp: time.Now(), Line: tc.line}})
+		require.Equal(t, tc.expected, entry.Line)
+	}
+}
+
+func TestScanStructuredMetadataAndLabels(t *testing.T) {
+	c, _ := newTestComponent(t, `
+		forward_to               = []
+		types                    = ["grafana"]
+		scan_structured_metadata = true
+		scan_labels              = ["token", "missing"]
+	`)
+
+	secret := fakeSecrets["grafana-api-key"].value
+	labels := model.LabelSet{"job": "test-job", "token": model.LabelValue(secret)}
+	metadata := push.LabelsAdapter{{Name: "trace_id", Value: "abc"}, {Name: "auth", Value: "key " + secret}}
+	entry := c.processEntry(loki.Entry{
+		Labels: labels,
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "no secret", StructuredMetadata: metadata},
+	})
+
+	require.Equal(t, "no secret", entry.Line)
+	require.Equal(t, model.LabelSet{"job": "test-job", "token": "<REDACTED-SECRET:grafana-api-key>"}, entry.Labels)
+	require.Equal(t, push.LabelsAdapter{{Name: "trace_id", Value: "abc"}, {Name: "auth", Value: "key <REDACTED-SECRET:grafana-api-key>"}}, entry.StructuredMetadata)
+	require.Equal(t, 2.0, testutil.ToFloat64(c.metrics.secretsRedactedTotal))
+
+	// The labels and structured metadata of the received entry must not be modified.
+	require.Equal(t, model.LabelValue(secret), labels["token"])
+	require.Equal(t, "key "+secret, metadata[1].Value)
+}
+
+func TestAuditMode(t *testing.T) {
+	secret := fakeSecrets["grafana-api-key"].value
+
+	t.Run("component", func(t *testing.T) {
+		c, _ := newTestComponent(t, `
+			forward_to  = []
+			types       = ["grafana"]
+			audit_only  = true
+			audit_label = "secrets_found"
+		`)
+
+		line := "a secret " + secret
+		entry := c.processEntry(loki.Entry{
+			Labels: model.LabelSet{"job": "test-job"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+		})
+		require.Equal(t, line, entry.Line)
+		require.Equal(t, model.LabelSet{"job": "test-job", "secrets_found": "grafana-api-key"}, entry.Labels)
+		require.Equal(t, 0.0, testutil.ToFloat64(c.metrics.secretsRedactedTotal))
+		require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.secretsAuditedTotal.WithLabelValues("grafana-api-key")))
+	})
+
+	t.Run("rule", func(t *testing.T) {
+		c, _ := newTestComponent(t, `
+			forward_to  = []
+			types       = ["grafana"]
+			audit_label = "secrets_found"
+
+			rule {
+				id         = "new-rule"
+				regex      = "secret=(\\S+)"
+				audit_only = true
+			}
+		`)
+
+		entry := c.processEntry(loki.Entry{
+			Labels: model.LabelSet{"job": "test-job"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: "secret=hunter2 " + secret},
+		})
+		// Only the rule in audit mode doesn't redact the secret it finds.
+		require.Equal(t, "secret=hunter2 <REDACTED-SECRET:grafana-api-key>", entry.Line)
+		require.Equal(t, model.LabelSet{"job": "test-job", "secrets_found": "new-rule"}, entry.Labels)
+		require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.secretsRedactedTotal))
+		require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.secretsAuditedTotal.WithLabelValues("new-rule")))
+	})
+}
+
+func TestArgumentsValidate(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"valid rule": {
+			config: `
+				rule {
+					id    = "my-rule"
+					regex = "secret=(\\S+)"
+				}`,
+		},
+		"missing id": {
+			config: `
+				rule {
+					id    = ""
+					regex = "secret"
+				}`,
+			err: "rule id must not be empty",
+		},
+		"duplicate id": {
+			config: `
+				rule {
+					id    = "my-rule"
+					regex = "secret"
+				}
+				rule {
+					id    = "my-rule"
+					regex = "password"
+				}`,
+			err: `duplicate rule id "my-rule"`,
+		},
+		"invalid regex": {
+			config: `
+				rule {
+					id    = "my-rule"
+					regex = "(secret"
+				}`,
+			err: `rule "my-rule": invalid regex`,
+		},
+		"invalid secret group": {
+			config: `
+				rule {
+					id           = "my-rule"
+					regex        = "secret=(\\S+)"
+					secret_group = 2
+				}`,
+			err: `rule "my-rule": secret_group 2 doesn't exist in the regex`,
+		},
+		"invalid audit label": {
+			config: `audit_label = "secrets found"`,
+			err:    `invalid audit_label "secrets found"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte("forward_to = []\n"+tc.config), &args)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -2,10 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
 
+	"github.com/alecthomas/units"
+
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/common/loki"
 	fnet "github.com/grafana/alloy/internal/component/common/net"
@@ -34,6 +37,51 @@ type Arguments struct {
 	Labels               map[string]string   `alloy:"labels,attr,optional"`
 	RelabelRules         relabel.Rules       `alloy:"relabel_rules,attr,optional"`
 	UseIncomingTimestamp bool                `alloy:"use_incoming_timestamp,attr,optional"`
+	Limits               LimitsConfig        `alloy:"limits,block,optional"`
+}
+
+// LimitsConfig configures the limits enforced on push requests. A limit set
+// to 0 is disabled.
+type LimitsConfig struct {
+	IngestionRateLimit      units.Base2Bytes `alloy:"ingestion_rate_limit,attr,optional"`
+	IngestionRateLimitBurst units.Base2Bytes `alloy:"ingestion_rate_limit_burst,attr,optional"`
+	PerStreamRateLimit      units.Base2Bytes `alloy:"per_stream_rate_limit,attr,optional"`
+	PerStreamRateLimitBurst units.Base2Bytes `alloy:"per_stream_rate_limit_burst,attr,optional"`
+	MaxLineSize             units.Base2Bytes `alloy:"max_line_size,attr,optional"`
+	MaxLineSizeTruncate     bool             `alloy:"max_line_size_truncate,attr,optional"`
+	MaxStreamsPerPush       int              `alloy:"max_streams_per_push,attr,optional"`
+}
+
+// Validate implements syntax.Validator.
+func (l *LimitsConfig) Validate() error {
+	if l.IngestionRateLimit < 0 || l.IngestionRateLimitBurst < 0 || l.PerStreamRateLimit < 0 || l.PerStreamRateLimitBurst < 0 {
+		return errors.New("rate limits and burst sizes must not be negative")
+	}
+	if l.IngestionRateLimitBurst > 0 && l.IngestionRateLimit == 0 {
+		return errors.New("ingestion_rate_limit_burst requires ingestion_rate_limit to be set")
+	}
+	if l.PerStreamRateLimitBurst > 0 && l.PerStreamRateLimit == 0 {
+		return errors.New("per_stream_rate_limit_burst requires per_stream_rate_limit to be set")
+	}
+	if l.MaxLineSize < 0 {
+		return errors.New("max_line_size must not be negative")
+	}
+	if l.MaxStreamsPerPush < 0 {
+		return errors.New("max_streams_per_push must not be negative")
+	}
+	return nil
+}
+
+func (l *LimitsConfig) limits() lokipush.Limits {
+	return lokipush.Limits{
+		IngestionRateLimit:      float64(l.IngestionRateLimit),
+		IngestionRateLimitBurst: int(l.IngestionRateLimitBurst),
+		PerStreamRateLimit:      float64(l.PerStreamRateLimit),
+		PerStreamRateLimitBurst: int(l.PerStreamRateLimitBurst),
+		MaxLineSize:             int(l.MaxLineSize),
+		MaxLineSizeTruncate:     l.MaxLineSizeTruncate,
+		MaxStreamsPerPush:       l.MaxStreamsPerPush,
+	}
 }
 
 // SetToDefault implements syntax.Defaulter.
@@ -155,6 +203,7 @@ func (c *Component) Update(args component.Arguments) error {
 	c.server.SetLabels(newArgs.labelSet())
 	c.server.SetRelabelRules(newArgs.RelabelRules)
 	c.server.SetKeepTimestamp(newArgs.UseIncomingTimestamp)
+	c.server.SetLimits(newArgs.Limits.limits())
 
 	return nil
 }
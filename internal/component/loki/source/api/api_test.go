@@ -23,7 +23,9 @@ import (
 	"github.com/grafana/alloy/internal/component/common/loki/client/fake"
 	"github.com/grafana/alloy/internal/component/common/net"
 	"github.com/grafana/alloy/internal/component/common/relabel"
+	"github.com/grafana/alloy/internal/component/loki/source/api/internal/lokipush"
 	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
 )
 
 func TestLokiSourceAPI_Simple(t *testing.T) {
@@ -323,6 +325,36 @@ func TestDefaultServerConfig(t *testing.T) {
 	comp.stop()
 }
 
+func TestLimitsConfig(t *testing.T) {
+	var args Arguments
+	err := syntax.Unmarshal([]byte(`
+		forward_to = []
+		limits {
+			ingestion_rate_limit        = "4MiB"
+			per_stream_rate_limit       = "1MiB"
+			per_stream_rate_limit_burst = "2MiB"
+			max_line_size               = "256KiB"
+			max_streams_per_push        = 100
+		}
+	`), &args)
+	require.NoError(t, err)
+	require.Equal(t, lokipush.Limits{
+		IngestionRateLimit:      4 << 20,
+		PerStreamRateLimit:      1 << 20,
+		PerStreamRateLimitBurst: 2 << 20,
+		MaxLineSize:             256 << 10,
+		MaxStreamsPerPush:       100,
+	}, args.Limits.limits())
+
+	err = syntax.Unmarshal([]byte(`
+		forward_to = []
+		limits {
+			ingestion_rate_limit_burst = "4MiB"
+		}
+	`), &args)
+	require.ErrorContains(t, err, "ingestion_rate_limit_burst requires ingestion_rate_limit to be set")
+}
+
 func startTestComponent(
 	t *testing.T,
 	opts component.Options,
@@ -0,0 +1,305 @@
+package lokipush
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// Reasons used in the discarded entries metrics. They match the reasons
+// used by Loki's distributor where there is an equivalent.
+const (
+	reasonRateLimited          = "rate_limited"
+	reasonPerStreamRateLimited = "per_stream_rate_limited"
+	reasonLineTooLong          = "line_too_long"
+	reasonTooManyStreams       = "too_many_streams"
+	reasonPushTooLarge         = "push_too_large"
+)
+
+// limiterCleanupInterval is how often rate limiters which haven't been used
+// recently are removed.
+const limiterCleanupInterval = time.Minute
+
+// Limits configures the limits enforced on push requests. A limit set to 0
+// is disabled.
+type Limits struct {
+	// IngestionRateLimit is the number of bytes per second accepted per
+	// tenant, and IngestionRateLimitBurst the number of bytes a tenant can
+	// push at once. The burst defaults to the rate limit.
+	IngestionRateLimit      float64
+	IngestionRateLimitBurst int
+	// PerStreamRateLimit is the number of bytes per second accepted per
+	// stream, and PerStreamRateLimitBurst the number of bytes which can be
+	// pushed to a stream at once. The burst defaults to the rate limit.
+	PerStreamRateLimit      float64
+	PerStreamRateLimitBurst int
+	// MaxLineSize is the maximum size of a log line in bytes. Longer lines
+	// are discarded, or truncated if MaxLineSizeTruncate is set.
+	MaxLineSize         int
+	MaxLineSizeTruncate bool
+	// MaxStreamsPerPush is the maximum number of streams in a push request.
+	MaxStreamsPerPush int
+}
+
+// limitError is returned when a push request is rejected because of a
+// limit. It carries the HTTP status code to respond with.
+type limitError struct {
+	status     int
+	reason     string
+	retryAfter time.Duration
+	msg        string
+}
+
+func (e *limitError) Error() string {
+	return e.msg
+}
+
+// writeHTTP writes the error as response, setting the Retry-After header if
+// the request can be retried.
+func (e *limitError) writeHTTP(w http.ResponseWriter) {
+	if e.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.retryAfter.Seconds()))))
+	}
+	http.Error(w, e.msg, e.status)
+}
+
+// limiter enforces Limits on push requests. Rate limits are tracked per
+// tenant and per stream, where streams are identified by their labels as
+// received, before relabeling. Requests without a tenant share the same
+// limits.
+type limiter struct {
+	limits Limits
+
+	discardedEntries *prometheus.CounterVec
+	discardedBytes   *prometheus.CounterVec
+
+	mut         sync.Mutex
+	tenants     map[string]*rateLimiter
+	streams     map[streamKey]*rateLimiter
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+type streamKey struct {
+	tenant string
+	labels string
+}
+
+type rateLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
+}
+
+func newLimiter(limits Limits, discardedEntries, discardedBytes *prometheus.CounterVec) *limiter {
+	return &limiter{
+		limits:           limits,
+		discardedEntries: discardedEntries,
+		discardedBytes:   discardedBytes,
+		tenants:          make(map[string]*rateLimiter),
+		streams:          make(map[streamKey]*rateLimiter),
+		lastCleanup:      time.Now(),
+		now:              time.Now,
+	}
+}
+
+// enabled returns whether a limit applying to the lines of push requests is
+// set. MaxStreamsPerPush isn't taken into account.
+func (l *limiter) enabled() bool {
+	return l.limits.IngestionRateLimit > 0 || l.limits.PerStreamRateLimit > 0 || l.limits.MaxLineSize > 0
+}
+
+// enforce enforces the limits on the streams of a push request. Lines which
+// are too long are truncated or removed from the streams in place, and
+// reported with the returned validation error. If the request must be
+// rejected as a whole, a *limitError is returned instead and no rate limit
+// is consumed.
+func (l *limiter) enforce(tenant string, streams []logproto.Stream) (validationErr error, rejectErr *limitError) {
+	if max := l.limits.MaxStreamsPerPush; max > 0 && len(streams) > max {
+		l.discard(tenant, reasonTooManyStreams, streams...)
+		return nil, &limitError{
+			status: http.StatusBadRequest,
+			reason: reasonTooManyStreams,
+			msg:    fmt.Sprintf("push request contains %d streams, which exceeds the limit of %d streams per push", len(streams), max),
+		}
+	}
+
+	validationErr = l.enforceMaxLineSize(tenant, streams)
+
+	if rejectErr = l.reserve(tenant, streams); rejectErr != nil {
+		l.discard(tenant, rejectErr.reason, streams...)
+		return nil, rejectErr
+	}
+	return validationErr, nil
+}
+
+// enforceMaxLineSize truncates or removes the lines which are longer than
+// the maximum line size.
+func (l *limiter) enforceMaxLineSize(tenant string, streams []logproto.Stream) error {
+	max := l.limits.MaxLineSize
+	if max <= 0 {
+		return nil
+	}
+
+	var lastErr error
+	for i := range streams {
+		kept := streams[i].Entries[:0]
+		for _, e := range streams[i].Entries {
+			if len(e.Line) <= max {
+				kept = append(kept, e)
+				continue
+			}
+			if l.limits.MaxLineSizeTruncate {
+				e.Line = e.Line[:max]
+				kept = append(kept, e)
+				continue
+			}
+			l.discard(tenant, reasonLineTooLong, logproto.Stream{Entries: []logproto.Entry{e}})
+			lastErr = fmt.Errorf("max line size (%d bytes) exceeded while adding (%d bytes) line for stream %s", max, len(e.Line), streams[i].Labels)
+		}
+		streams[i].Entries = kept
+	}
+	return lastErr
+}
+
+// reserve consumes the rate limits of the tenant and of every stream of the
+// request. If any of them is exceeded, nothing is consumed and an error is
+// returned.
+func (l *limiter) reserve(tenant string, streams []logproto.Stream) *limitError {
+	if l.limits.IngestionRateLimit <= 0 && l.limits.PerStreamRateLimit <= 0 {
+		return nil
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	var reservations []*rate.Reservation
+	cancel := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+
+	if l.limits.IngestionRateLimit > 0 {
+		size := 0
+		for _, s := range streams {
+			size += entriesSize(s.Entries)
+		}
+
+		lim := getRateLimiter(l.tenants, tenant, now, l.limits.IngestionRateLimit, l.limits.IngestionRateLimitBurst)
+		r := lim.ReserveN(now, size)
+		if !r.OK() {
+			return &limitError{
+				status: http.StatusRequestEntityTooLarge,
+				reason: reasonPushTooLarge,
+				msg:    fmt.Sprintf("push request of %d bytes exceeds the ingestion burst size of %d bytes for tenant %q, reduce the batch size of the client", size, lim.Burst(), tenant),
+			}
+		}
+		reservations = append(reservations, r)
+		if delay := r.DelayFrom(now); delay > 0 {
+			cancel()
+			return &limitError{
+				status:     http.StatusTooManyRequests,
+				reason:     reasonRateLimited,
+				retryAfter: delay,
+				msg:        fmt.Sprintf("ingestion rate limit exceeded for tenant %q (limit: %d bytes/sec) while attempting to ingest %d bytes, reduce log volume or increase the limit", tenant, int(l.limits.IngestionRateLimit), size),
+			}
+		}
+	}
+
+	if l.limits.PerStreamRateLimit > 0 {
+		for _, s := range streams {
+			size := entriesSize(s.Entries)
+			lim := getRateLimiter(l.streams, streamKey{tenant: tenant, labels: s.Labels}, now, l.limits.PerStreamRateLimit, l.limits.PerStreamRateLimitBurst)
+			r := lim.ReserveN(now, size)
+			if !r.OK() {
+				cancel()
+				return &limitError{
+					status: http.StatusRequestEntityTooLarge,
+					reason: reasonPushTooLarge,
+					msg:    fmt.Sprintf("push request of %d bytes for stream %s exceeds the per stream burst size of %d bytes, reduce the batch size of the client", size, s.Labels, lim.Burst()),
+				}
+			}
+			reservations = append(reservations, r)
+			if delay := r.DelayFrom(now); delay > 0 {
+				cancel()
+				return &limitError{
+					status:     http.StatusTooManyRequests,
+					reason:     reasonPerStreamRateLimited,
+					retryAfter: delay,
+					msg:        fmt.Sprintf("per stream rate limit exceeded (limit: %d bytes/sec) while attempting to ingest %d bytes for stream %s, reduce log volume or increase the limit", int(l.limits.PerStreamRateLimit), size, s.Labels),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// cleanup removes the rate limiters which weren't used for long enough to
+// be full again, as they behave like new ones.
+func (l *limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < limiterCleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for k, lim := range l.tenants {
+		if now.Sub(lim.lastUsed) > fillDuration(lim.Limiter) {
+			delete(l.tenants, k)
+		}
+	}
+	for k, lim := range l.streams {
+		if now.Sub(lim.lastUsed) > fillDuration(lim.Limiter) {
+			delete(l.streams, k)
+		}
+	}
+}
+
+func getRateLimiter[K comparable](limiters map[K]*rateLimiter, key K, now time.Time, limit float64, burst int) *rateLimiter {
+	lim, ok := limiters[key]
+	if !ok {
+		if burst <= 0 {
+			burst = int(limit)
+		}
+		lim = &rateLimiter{Limiter: rate.NewLimiter(rate.Limit(limit), burst)}
+		limiters[key] = lim
+	}
+	lim.lastUsed = now
+	return lim
+}
+
+// fillDuration returns the time it takes for an empty rate limiter to be
+// full.
+func fillDuration(lim *rate.Limiter) time.Duration {
+	return time.Duration(float64(lim.Burst()) / float64(lim.Limit()) * float64(time.Second))
+}
+
+func (l *limiter) discard(tenant, reason string, streams ...logproto.Stream) {
+	for _, s := range streams {
+		l.discardedEntries.WithLabelValues(tenant, reason).Add(float64(len(s.Entries)))
+		l.discardedBytes.WithLabelValues(tenant, reason).Add(float64(entriesSize(s.Entries)))
+	}
+}
+
+// entriesSize returns the size of the entries, counting the log lines and
+// structured metadata like Loki does.
+func entriesSize(entries []logproto.Entry) int {
+	size := 0
+	for _, e := range entries {
+		size += len(e.Line)
+		for _, m := range e.StructuredMetadata {
+			size += len(m.Name) + len(m.Value)
+		}
+	}
+	return size
+}
@@ -0,0 +1,230 @@
+package lokipush
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(limits Limits) *limiter {
+	return newLimiter(limits,
+		prometheus.NewCounterVec(prometheus.CounterOpts{Name: "discarded_entries"}, []string{"tenant", "reason"}),
+		prometheus.NewCounterVec(prometheus.CounterOpts{Name: "discarded_bytes"}, []string{"tenant", "reason"}),
+	)
+}
+
+func testStream(labels string, lines ...string) logproto.Stream {
+	s := logproto.Stream{Labels: labels}
+	for _, l := range lines {
+		s.Entries = append(s.Entries, logproto.Entry{Timestamp: time.Unix(0, 0), Line: l})
+	}
+	return s
+}
+
+func TestLimiter_IngestionRateLimit(t *testing.T) {
+	l := newTestLimiter(Limits{IngestionRateLimit: 10, IngestionRateLimitBurst: 20})
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	_, err := l.enforce("tenant1", []logproto.Stream{testStream(`{app="a"}`, "0123456789", "0123456789")})
+	require.Nil(t, err)
+
+	// The burst is used up, so the next push must wait for 1s.
+	_, err = l.enforce("tenant1", []logproto.Stream{testStream(`{app="a"}`, "0123456789")})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, err.status)
+	assert.Equal(t, reasonRateLimited, err.reason)
+	assert.Equal(t, time.Second, err.retryAfter)
+	assert.Equal(t, 1.0, testutil.ToFloat64(l.discardedEntries.WithLabelValues("tenant1", reasonRateLimited)))
+	assert.Equal(t, 10.0, testutil.ToFloat64(l.discardedBytes.WithLabelValues("tenant1", reasonRateLimited)))
+
+	// Other tenants have their own limits.
+	_, err = l.enforce("tenant2", []logproto.Stream{testStream(`{app="a"}`, "0123456789")})
+	require.Nil(t, err)
+
+	now = now.Add(time.Second)
+	_, err = l.enforce("tenant1", []logproto.Stream{testStream(`{app="a"}`, "0123456789")})
+	require.Nil(t, err)
+}
+
+func TestLimiter_PerStreamRateLimit(t *testing.T) {
+	l := newTestLimiter(Limits{IngestionRateLimit: 100, PerStreamRateLimit: 10})
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	_, err := l.enforce("", []logproto.Stream{
+		testStream(`{app="a"}`, "0123456789"),
+		testStream(`{app="b"}`, "0123456789"),
+	})
+	require.Nil(t, err)
+
+	_, err = l.enforce("", []logproto.Stream{
+		testStream(`{app="c"}`, "0123456789"),
+		testStream(`{app="a"}`, "01234"),
+	})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, err.status)
+	assert.Equal(t, reasonPerStreamRateLimited, err.reason)
+	assert.Equal(t, 500*time.Millisecond, err.retryAfter)
+
+	// The rejected request must not have consumed any rate limit, so the
+	// tenant and the new stream still have their full burst.
+	_, err = l.enforce("", []logproto.Stream{testStream(`{app="c"}`, "0123456789")})
+	require.Nil(t, err)
+	assert.Equal(t, 70.0, l.tenants[""].TokensAt(now))
+}
+
+func TestLimiter_PushLargerThanBurst(t *testing.T) {
+	l := newTestLimiter(Limits{IngestionRateLimit: 10})
+
+	_, err := l.enforce("", []logproto.Stream{testStream(`{app="a"}`, "this line is longer than ten bytes")})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.status)
+	assert.Equal(t, reasonPushTooLarge, err.reason)
+	assert.Zero(t, err.retryAfter)
+}
+
+func TestLimiter_MaxLineSize(t *testing.T) {
+	t.Run("discard", func(t *testing.T) {
+		l := newTestLimiter(Limits{MaxLineSize: 5})
+		streams := []logproto.Stream{testStream(`{app="a"}`, "short", "too long", "ok")}
+
+		validationErr, err := l.enforce("", streams)
+		require.Nil(t, err)
+		require.ErrorContains(t, validationErr, "max line size (5 bytes) exceeded")
+		require.Len(t, streams[0].Entries, 2)
+		assert.Equal(t, "short", streams[0].Entries[0].Line)
+		assert.Equal(t, "ok", streams[0].Entries[1].Line)
+		assert.Equal(t, 1.0, testutil.ToFloat64(l.discardedEntries.WithLabelValues("", reasonLineTooLong)))
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		l := newTestLimiter(Limits{MaxLineSize: 5, MaxLineSizeTruncate: true})
+		streams := []logproto.Stream{testStream(`{app="a"}`, "short", "too long")}
+
+		validationErr, err := l.enforce("", streams)
+		require.Nil(t, err)
+		require.NoError(t, validationErr)
+		require.Len(t, streams[0].Entries, 2)
+		assert.Equal(t, "too l", streams[0].Entries[1].Line)
+	})
+}
+
+func TestLimiter_MaxStreamsPerPush(t *testing.T) {
+	l := newTestLimiter(Limits{MaxStreamsPerPush: 1})
+
+	_, err := l.enforce("", []logproto.Stream{testStream(`{app="a"}`, "a")})
+	require.Nil(t, err)
+
+	_, err = l.enforce("", []logproto.Stream{testStream(`{app="a"}`, "a"), testStream(`{app="b"}`, "b")})
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.status)
+	assert.Equal(t, reasonTooManyStreams, err.reason)
+}
+
+func TestLimiter_Cleanup(t *testing.T) {
+	l := newTestLimiter(Limits{IngestionRateLimit: 10, PerStreamRateLimit: 10})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	_, err := l.enforce("tenant1", []logproto.Stream{testStream(`{app="a"}`, "a")})
+	require.Nil(t, err)
+	require.Len(t, l.tenants, 1)
+	require.Len(t, l.streams, 1)
+
+	now = now.Add(limiterCleanupInterval + time.Second)
+	_, err = l.enforce("tenant2", []logproto.Stream{testStream(`{app="b"}`, "b")})
+	require.Nil(t, err)
+	assert.Len(t, l.tenants, 1)
+	assert.Contains(t, l.tenants, "tenant2")
+	assert.Len(t, l.streams, 1)
+}
+
+func TestPushRateLimited(t *testing.T) {
+	pt, port, eh := createPushServer(t, log.NewNopLogger())
+	defer pt.Shutdown()
+	pt.SetLimits(Limits{IngestionRateLimit: 1, IngestionRateLimitBurst: 10})
+
+	push := func(line string) *http.Response {
+		req := logproto.PushRequest{Streams: []logproto.Stream{testStream(`{app="a"}`, line)}}
+		buf, err := req.Marshal()
+		require.NoError(t, err)
+
+		httpReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s:%d/loki/api/v1/push", localhost, port), bytes.NewReader(snappy.Encode(nil, buf)))
+		require.NoError(t, err)
+		httpReq.Header.Set("Content-Type", "application/x-protobuf")
+		httpReq.Header.Set("X-Scope-OrgID", "tenant1")
+		resp, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := push("0123456789")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = push("0123456789")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	// Refilling 10 bytes at 1 byte per second takes up to 10 seconds.
+	assert.Contains(t, []string{"9", "10"}, resp.Header.Get("Retry-After"))
+
+	require.Eventually(t, func() bool { return len(eh.Received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, eh.Received(), 1)
+}
+
+func TestPlaintextPushMaxLineSize(t *testing.T) {
+	pt, port, eh := createPushServer(t, log.NewNopLogger())
+	defer pt.Shutdown()
+	pt.SetLimits(Limits{MaxLineSize: 5})
+
+	body := strings.NewReader("short\nthis line is too long\nok\n")
+	resp, err := http.Post(fmt.Sprintf("http://%s:%d/loki/api/v1/raw", localhost, port), "text/plain", body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	require.Eventually(t, func() bool { return len(eh.Received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "short", eh.Received()[0].Line)
+	assert.Equal(t, "ok", eh.Received()[1].Line)
+}
+
+func TestPlaintextPushStreamedWithoutLimits(t *testing.T) {
+	pt, port, eh := createPushServer(t, log.NewNopLogger())
+	defer pt.Shutdown()
+
+	// Without limits, lines are forwarded before the whole body is read.
+	body, bodyWriter := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := http.Post(fmt.Sprintf("http://%s:%d/loki/api/v1/raw", localhost, port), "text/plain", body)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		}
+	}()
+
+	_, err := bodyWriter.Write([]byte("first\n"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(eh.Received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "first", eh.Received()[0].Line)
+
+	_, err = bodyWriter.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, bodyWriter.Close())
+	<-done
+	require.Len(t, eh.Received(), 2)
+}
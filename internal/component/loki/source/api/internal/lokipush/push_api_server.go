@@ -35,10 +35,14 @@ type PushAPIServer struct {
 	server       *fnet.TargetServer
 	handler      loki.EntryHandler
 
+	discardedEntries *prometheus.CounterVec
+	discardedBytes   *prometheus.CounterVec
+
 	rwMutex       sync.RWMutex
 	labels        model.LabelSet
 	relabelRules  []*relabel.Config
 	keepTimestamp bool
+	limiter       *limiter
 }
 
 func NewPushAPIServer(logger log.Logger,
@@ -51,12 +55,22 @@ func NewPushAPIServer(logger log.Logger,
 		logger:       logger,
 		serverConfig: serverConfig,
 		handler:      handler,
+		discardedEntries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_discarded_entries_total",
+			Help: "Total number of log entries discarded because of limits.",
+		}, []string{"tenant", "reason"}),
+		discardedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_discarded_bytes_total",
+			Help: "Total number of bytes of log entries discarded because of limits.",
+		}, []string{"tenant", "reason"}),
 	}
+	s.limiter = newLimiter(Limits{}, s.discardedEntries, s.discardedBytes)
 
 	srv, err := fnet.NewTargetServer(logger, "loki_source_api", registerer, serverConfig)
 	if err != nil {
 		return nil, err
 	}
+	registerer.MustRegister(s.discardedEntries, s.discardedBytes)
 
 	s.server = srv
 	return s, nil
@@ -140,6 +154,22 @@ func (s *PushAPIServer) SetRelabelRules(rules frelabel.Rules) {
 	s.relabelRules = frelabel.ComponentToPromRelabelConfigs(rules)
 }
 
+// SetLimits sets the limits enforced on push requests. The state of the rate
+// limiters is reset if the limits changed.
+func (s *PushAPIServer) SetLimits(limits Limits) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	if s.limiter.limits != limits {
+		s.limiter = newLimiter(limits, s.discardedEntries, s.discardedBytes)
+	}
+}
+
+func (s *PushAPIServer) getLimiter() *limiter {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.limiter
+}
+
 func (s *PushAPIServer) getRelabelRules() []*relabel.Config {
 	s.rwMutex.RLock()
 	defer s.rwMutex.RUnlock()
@@ -171,12 +201,19 @@ func (s *PushAPIServer) handleLoki(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	validationErr, limitErr := s.getLimiter().enforce(tenantID, req.Streams)
+	if limitErr != nil {
+		level.Warn(s.logger).Log("msg", "push request rejected because of limits", "tenant", tenantID, "err", limitErr)
+		limitErr.writeHTTP(w)
+		return
+	}
+
 	// Take snapshot of current configs and apply consistently for the entire request.
 	addLabels := s.getLabels()
 	relabelRules := s.getRelabelRules()
 	keepTimestamp := s.getKeepTimestamp()
 
-	var lastErr error
+	lastErr := validationErr
 	for _, stream := range req.Streams {
 		ls, err := promql_parser.ParseMetric(stream.Labels)
 		if err != nil {
@@ -243,10 +280,18 @@ func (s *PushAPIServer) handleLoki(w http.ResponseWriter, r *http.Request) {
 // NOTE: This code is copied from Promtail (https://github.com/grafana/loki/commit/47e2c5884f443667e64764f3fc3948f8f11abbb8) with changes kept to the minimum.
 // Only the HTTP handler functions are copied to allow for Alloy-specific server configuration and lifecycle management.
 func (s *PushAPIServer) handlePlaintext(w http.ResponseWriter, r *http.Request) {
+	tenantID, _ := tenant.TenantID(r.Context())
 	entries := s.handler.Chan()
 	defer r.Body.Close()
 	body := bufio.NewReader(r.Body)
 	addLabels := s.getLabels()
+	limiter := s.getLimiter()
+
+	// When limits are enabled, the lines are read first so that the limits
+	// can be enforced on the whole request, as a single stream. Otherwise
+	// they are forwarded as they are read.
+	buffered := limiter.enabled()
+	stream := logproto.Stream{}
 	for {
 		line, err := body.ReadString('\n')
 		if err != nil && err != io.EOF {
@@ -261,18 +306,49 @@ func (s *PushAPIServer) handlePlaintext(w http.ResponseWriter, r *http.Request)
 			}
 			continue
 		}
-		entries <- loki.Entry{
-			Labels: addLabels,
-			Entry: logproto.Entry{
-				Timestamp: time.Now(),
-				Line:      line,
-			},
+		entry := logproto.Entry{
+			Timestamp: time.Now(),
+			Line:      line,
+		}
+		if buffered {
+			stream.Entries = append(stream.Entries, entry)
+		} else {
+			entries <- loki.Entry{
+				Labels: addLabels,
+				Entry:  entry,
+			}
 		}
 		if err == io.EOF {
 			break
 		}
 	}
 
+	if !buffered {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	streams := []logproto.Stream{stream}
+	validationErr, limitErr := limiter.enforce(tenantID, streams)
+	if limitErr != nil {
+		level.Warn(s.logger).Log("msg", "push request rejected because of limits", "tenant", tenantID, "err", limitErr)
+		limitErr.writeHTTP(w)
+		return
+	}
+
+	for _, e := range streams[0].Entries {
+		entries <- loki.Entry{
+			Labels: addLabels,
+			Entry:  e,
+		}
+	}
+
+	if validationErr != nil {
+		level.Warn(s.logger).Log("msg", "at least one entry in the push request failed to process", "err", validationErr.Error())
+		http.Error(w, validationErr.Error(), http.StatusBadRequest)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -62,6 +62,10 @@ func (n *noopPositions) Put(path string, labels string, pos int64) {}
 
 func (n *noopPositions) PutString(path string, labels string, pos string) {}
 
+func (n *noopPositions) GetInode(path string, labels string) uint64 { return 0 }
+
+func (n *noopPositions) PutInode(path string, labels string, inode uint64) {}
+
 func (n *noopPositions) Remove(path string, labels string) {}
 
 func (n *noopPositions) Stop() {}
@@ -465,3 +465,60 @@ func checkMsg(t *testing.T, ch loki.LogsReceiver, msg string, timeout time.Durat
 		require.FailNow(t, "failed waiting for log line")
 	}
 }
+
+func TestFileRotatedWhileReading(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("go.opencensus.io/stats/view.(*worker).start"))
+
+	ctx, cancel := context.WithCancel(componenttest.TestContext(t))
+	defer cancel()
+
+	// Create file to log to.
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "loki.source.file")
+	require.NoError(t, err)
+
+	ch1 := loki.NewLogsReceiver()
+
+	go func() {
+		err := ctrl.Run(ctx, Arguments{
+			Targets: []discovery.Target{discovery.NewTargetFromMap(map[string]string{
+				"__path__": path,
+			})},
+			ForwardTo: []loki.LogsReceiver{ch1},
+			FileWatch: FileWatch{
+				MinPollFrequency: 25 * time.Millisecond,
+				MaxPollFrequency: 25 * time.Millisecond,
+			},
+		})
+		require.NoError(t, err)
+	}()
+
+	ctrl.WaitRunning(time.Minute)
+
+	expectLine := func(expected string) {
+		select {
+		case logEntry := <-ch1.Chan():
+			require.Equal(t, expected, logEntry.Line)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for log line", expected)
+		}
+	}
+
+	_, err = f.Write([]byte("before rotation\n"))
+	require.NoError(t, err)
+	expectLine("before rotation")
+
+	// Rotate the file, and keep writing to the rotated file for a bit like an
+	// application which wasn't told to reopen its log file yet.
+	require.NoError(t, os.Rename(path, path+".1"))
+	_, err = f.Write([]byte("written to rotated file\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("written to new file\n"), 0600))
+
+	expectLine("written to rotated file")
+	expectLine("written to new file")
+}
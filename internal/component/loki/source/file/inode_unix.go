@@ -0,0 +1,16 @@
+//go:build !windows
+
+package file
+
+import (
+	"os"
+	"syscall"
+)
+
+// getInode returns the inode of the file, or 0 if it can't be determined.
+func getInode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
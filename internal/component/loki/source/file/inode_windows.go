@@ -0,0 +1,11 @@
+//go:build windows
+
+package file
+
+import "os"
+
+// getInode returns 0 as inodes are not available on Windows. Rotated files
+// are therefore not tracked across restarts.
+func getInode(_ os.FileInfo) uint64 {
+	return 0
+}
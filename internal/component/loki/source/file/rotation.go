@@ -0,0 +1,166 @@
+package file
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// rotatedFile is a file which the tailed file was rotated to while the tailer
+// was not running, and which still has to be read from offset.
+type rotatedFile struct {
+	path   string
+	inode  uint64
+	offset int64
+}
+
+// findRotatedFile returns the path of the file that the file at path was
+// rotated to since pos was recorded for the file with prevInode. It returns an
+// empty string if the file wasn't rotated or if the rotated file can't be
+// found.
+//
+// Rotated files are looked for next to the file, with a name starting with
+// the name of the file followed by a '.' or a '-', for example app.log.1 or
+// app.log-20250101.gz. A file which was renamed is found by its inode. A file
+// which was compressed after being renamed, or copied before the file was
+// truncated like logrotate's copytruncate option does, has another inode: the
+// most recently modified candidate which has at least pos bytes, once
+// decompressed, is used instead. Uncompressed candidates with another inode
+// are only used if the file was truncated, since a renamed file which can't
+// be found by its inode was either compressed or deleted.
+func findRotatedFile(path string, fi os.FileInfo, prevInode uint64, pos int64) string {
+	inode := getInode(fi)
+	if pos == 0 || prevInode == 0 || inode == 0 {
+		return ""
+	}
+
+	renamed := prevInode != inode
+	truncated := !renamed && fi.Size() < pos
+	if !renamed && !truncated {
+		return ""
+	}
+
+	candidates := rotationCandidates(path, inode)
+
+	if renamed {
+		for _, c := range candidates {
+			if getInode(c.fi) == prevInode {
+				return c.path
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		if _, compressed := compressionFormatFromPath(c.path); !compressed && !truncated {
+			continue
+		}
+		if hasContentSize(c, pos) {
+			return c.path
+		}
+	}
+	return ""
+}
+
+// findRenamedFile returns the info of the file with the given inode among the
+// rotated versions of the file at path, if it was renamed to one of them.
+func findRenamedFile(path string, inode uint64) (os.FileInfo, bool) {
+	if inode == 0 {
+		return nil, false
+	}
+	for _, c := range rotationCandidates(path, 0) {
+		if getInode(c.fi) == inode {
+			return c.fi, true
+		}
+	}
+	return nil, false
+}
+
+type rotationCandidate struct {
+	path string
+	fi   os.FileInfo
+}
+
+// rotationCandidates returns the siblings of the file at path which may be
+// rotated versions of it, most recently modified first. Files with the given
+// inode are ignored, as they are the file at path itself.
+func rotationCandidates(path string, inode uint64) []rotationCandidate {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+
+	var candidates []rotationCandidate
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || len(name) <= len(base) || !strings.HasPrefix(name, base) {
+			continue
+		}
+		if sep := name[len(base)]; sep != '.' && sep != '-' {
+			continue
+		}
+
+		fi, err := e.Info()
+		if err != nil || (inode != 0 && getInode(fi) == inode) {
+			continue
+		}
+		candidates = append(candidates, rotationCandidate{path: filepath.Join(dir, name), fi: fi})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].fi.ModTime().After(candidates[j].fi.ModTime())
+	})
+	return candidates
+}
+
+// hasContentSize reports whether the candidate has at least size bytes of
+// content, once decompressed if it's compressed. A rotated file which is
+// smaller than the recorded position can't be the file it was recorded for.
+func hasContentSize(c rotationCandidate, size int64) bool {
+	if _, compressed := compressionFormatFromPath(c.path); !compressed {
+		return c.fi.Size() >= size
+	}
+
+	f, r, err := openRotatedFile(c.path, log.NewNopLogger())
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	_, err = io.CopyN(io.Discard, r, size)
+	return err == nil
+}
+
+// openRotatedFile opens the rotated file at path and returns a reader of its
+// content, which is decompressed if the file has the extension of one of the
+// supported compression formats.
+func openRotatedFile(path string, logger log.Logger) (*os.File, io.Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format, compressed := compressionFormatFromPath(path)
+	if !compressed {
+		return f, f, nil
+	}
+	r, err := mountReader(f, logger, format)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, r, nil
+}
+
+// compressionFormatFromPath returns the compression format of the file at
+// path based on its extension.
+func compressionFormatFromPath(path string) (CompressionFormat, bool) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if _, ok := supportedCompressedFormats()[ext]; !ok {
+		return "", false
+	}
+	return CompressionFormat(ext), true
+}
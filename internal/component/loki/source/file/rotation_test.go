@@ -0,0 +1,270 @@
+//go:build !windows
+
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/tail/watch"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/common/loki/positions"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func TestTailerReadsFileRotatedWhileStopped(t *testing.T) {
+	tests := map[string]struct {
+		rotate   func(t *testing.T, path string)
+		expected []string
+	}{
+		"renamed": {
+			rotate: func(t *testing.T, path string) {
+				require.NoError(t, os.Rename(path, path+".1"))
+			},
+			expected: []string{"third", "fourth"},
+		},
+		"renamed and compressed": {
+			// Like logrotate, the new file is created before the rotated
+			// file is compressed.
+			expected: []string{"third", "fourth"},
+			rotate: func(t *testing.T, path string) {
+				require.NoError(t, os.Rename(path, path+".1"))
+				require.NoError(t, os.WriteFile(path, nil, 0600))
+				content, err := os.ReadFile(path + ".1")
+				require.NoError(t, err)
+				f, err := os.Create(path + ".1.gz")
+				require.NoError(t, err)
+				w := gzip.NewWriter(f)
+				_, err = w.Write(content)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+				require.NoError(t, f.Close())
+				require.NoError(t, os.Remove(path+".1"))
+			},
+		},
+		"copytruncate": {
+			expected: []string{"third", "fourth"},
+			rotate: func(t *testing.T, path string) {
+				content, err := os.ReadFile(path)
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(path+"-20250101", content, 0600))
+				require.NoError(t, os.Truncate(path, 0))
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("go.opencensus.io/stats/view.(*worker).start"))
+			l := util.TestLogger(t)
+			ch := loki.NewLogsReceiver()
+			tempDir := t.TempDir()
+			path := filepath.Join(tempDir, "app.log")
+
+			require.NoError(t, os.WriteFile(path, []byte("first\nsecond\nthird\n"), 0600))
+			fi, err := os.Stat(path)
+			require.NoError(t, err)
+
+			positionsFile, err := positions.New(l, positions.Config{
+				SyncPeriod:    50 * time.Millisecond,
+				PositionsFile: filepath.Join(tempDir, "positions.yaml"),
+			})
+			require.NoError(t, err)
+			labels := model.LabelSet{"foo": "bar"}
+
+			// Simulate a previous run which read the first two lines only.
+			positionsFile.Put(path, labels.String(), 13)
+			positionsFile.PutInode(path, labels.String(), getInode(fi))
+
+			tc.rotate(t, path)
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+			require.NoError(t, err)
+			_, err = f.WriteString("fourth\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			tailer, err := newTailer(
+				newMetrics(nil),
+				l,
+				ch,
+				positionsFile,
+				path,
+				labels,
+				"",
+				watch.PollingFileWatcherOptions{
+					MinPollFrequency: 25 * time.Millisecond,
+					MaxPollFrequency: 25 * time.Millisecond,
+				},
+				false,
+				func() bool { return true },
+			)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				tailer.Run(ctx)
+				close(done)
+			}()
+
+			for _, expected := range tc.expected {
+				select {
+				case logEntry := <-ch.Chan():
+					require.Equal(t, expected, logEntry.Line)
+				case <-time.After(5 * time.Second):
+					require.FailNow(t, "failed waiting for log line", expected)
+				}
+			}
+
+			cancel()
+			<-done
+
+			fi, err = os.Stat(path)
+			require.NoError(t, err)
+			pos, err := positionsFile.Get(path, labels.String())
+			require.NoError(t, err)
+			require.Equal(t, int64(7), pos)
+			require.Equal(t, getInode(fi), positionsFile.GetInode(path, labels.String()))
+
+			positionsFile.Stop()
+		})
+	}
+}
+
+func TestFindRotatedFile(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "app.log")
+
+	require.NoError(t, os.WriteFile(path, []byte("old content\n"), 0600))
+	old, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte("new\n"), 0600))
+	// Files which don't look like rotated versions of app.log are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app.logger"), []byte("unrelated content\n"), 0600))
+	// An older rotated file.
+	require.NoError(t, os.WriteFile(path+".2", []byte("other content\n"), 0600))
+	require.NoError(t, os.Chtimes(path+".2", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// Not rotated.
+	require.Empty(t, findRotatedFile(path, fi, getInode(fi), 2))
+	// No position or unknown inode.
+	require.Empty(t, findRotatedFile(path, fi, getInode(old), 0))
+	require.Empty(t, findRotatedFile(path, fi, 0, 4))
+	// Renamed.
+	require.Equal(t, path+".1", findRotatedFile(path, fi, getInode(old), 4))
+	// Truncated, the copy must contain the position.
+	require.Equal(t, path+".1", findRotatedFile(path, fi, getInode(fi), 10))
+	require.Empty(t, findRotatedFile(path, fi, getInode(fi), 100))
+
+	// Compressed after being renamed, the decompressed content must contain
+	// the position.
+	writeGzip(t, path+".1.gz", "old content\n")
+	require.NoError(t, os.Remove(path+".1"))
+	require.Equal(t, path+".1.gz", findRotatedFile(path, fi, getInode(old), 4))
+	require.Empty(t, findRotatedFile(path, fi, getInode(old), 100))
+
+	// Uncompressed files with another inode aren't used for renamed files.
+	require.NoError(t, os.Remove(path+".1.gz"))
+	require.Empty(t, findRotatedFile(path, fi, getInode(old), 4))
+}
+
+func TestTailerRotatedWhileRunning(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("go.opencensus.io/stats/view.(*worker).start"))
+	l := util.TestLogger(t)
+	ch := loki.NewLogsReceiver()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "app.log")
+
+	require.NoError(t, os.WriteFile(path, []byte("skipped\n"), 0600))
+
+	positionsFile, err := positions.New(l, positions.Config{
+		SyncPeriod:    50 * time.Millisecond,
+		PositionsFile: filepath.Join(tempDir, "positions.yaml"),
+	})
+	require.NoError(t, err)
+	defer positionsFile.Stop()
+
+	tailer, err := newTailer(
+		newMetrics(nil),
+		l,
+		ch,
+		positionsFile,
+		path,
+		model.LabelSet{"foo": "bar"},
+		"",
+		watch.PollingFileWatcherOptions{
+			MinPollFrequency: 25 * time.Millisecond,
+			MaxPollFrequency: 25 * time.Millisecond,
+		},
+		true,
+		func() bool { return false },
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		(&runnerReader{reader: tailer}).Run(ctx)
+		close(done)
+	}()
+
+	receive := func(expected string) {
+		select {
+		case logEntry := <-ch.Chan():
+			require.Equal(t, expected, logEntry.Line)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for log line", expected)
+		}
+	}
+
+	require.Eventually(t, tailer.IsRunning, 5*time.Second, 10*time.Millisecond)
+	appendLines(t, path, "first\n")
+	receive("first")
+
+	// Lines written to the rotated file after the rename and to the new file
+	// before the tailer restarts aren't lost, even with tail_from_end.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte("third\nfourth\n"), 0600))
+	_, err = f.WriteString("second\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	for _, expected := range []string{"second", "third", "fourth"} {
+		receive(expected)
+	}
+
+	cancel()
+	<-done
+}
+
+func appendLines(t *testing.T, path string, lines string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(lines)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func writeGzip(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+}
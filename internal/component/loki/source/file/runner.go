@@ -10,6 +10,10 @@ import (
 
 var _ runner.Task = (*runnerTask)(nil)
 
+// minSuccessfulRun is how long a reader must have run for the backoff to be
+// reset when it stops.
+const minSuccessfulRun = 1 * time.Second
+
 type runnerTask struct {
 	reader     reader
 	path       string
@@ -51,7 +55,14 @@ func (r *runnerReader) Run(ctx context.Context) {
 	)
 
 	for {
+		start := time.Now()
 		r.reader.Run(ctx)
+		// A reader which ran for a while stopped because its file was rotated
+		// rather than because it failed to start, so the next one is started
+		// without accumulating delays over rotations.
+		if time.Since(start) >= minSuccessfulRun {
+			backoff.Reset()
+		}
 		backoff.Wait()
 		if !backoff.Ongoing() {
 			break
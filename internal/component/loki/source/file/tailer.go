@@ -4,11 +4,13 @@ package file
 // tailer implements the reader interface by using the github.com/grafana/tail package to tail files.
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,6 +52,16 @@ type tailer struct {
 
 	tail    *tail.Tail
 	decoder *encoding.Decoder
+
+	// inode is the inode of the file being tailed.
+	inode uint64
+	// rotated is set while the remainder of a file which was rotated while
+	// the tailer wasn't running is being read. It is protected by
+	// posAndSizeMtx.
+	rotated *rotatedFile
+	// offset is the offset in the tailed file of the end of the last line read
+	// by readLines. It's only used by stop once readLines exited.
+	offset int64
 }
 
 func newTailer(metrics *metrics, logger log.Logger, receiver loki.LogsReceiver, positions positions.Positions, path string,
@@ -156,7 +168,7 @@ func (t *tailer) Run(ctx context.Context) {
 	t.metrics.filesActive.Add(1.)
 
 	done := make(chan struct{})
-	ctx, cancel := context.WithCancel(ctx)
+	tailCtx, cancel := context.WithCancel(ctx)
 	go func() {
 		// readLines closes done on exit
 		t.readLines(handler, done)
@@ -166,8 +178,9 @@ func (t *tailer) Run(ctx context.Context) {
 	t.running.Store(true)
 	defer t.running.Store(false)
 
-	<-ctx.Done()
-	t.stop(done)
+	<-tailCtx.Done()
+	// If ctx isn't done, the tail stopped by itself, for example because the file was rotated.
+	t.stop(done, ctx.Err() == nil)
 }
 
 func (t *tailer) initRun() (loki.EntryHandler, error) {
@@ -181,17 +194,35 @@ func (t *tailer) initRun() (loki.EntryHandler, error) {
 		return nil, fmt.Errorf("failed to get file position: %w", err)
 	}
 
-	// NOTE: The code assumes that if a position is available and that the file is bigger than the position, then
-	// the tail should start from the position. This may not be always desired in situation where the file was rotated
-	// with a file that has the same name but different content and a bigger size that the previous one. This problem would
-	// mostly show up on Windows because on Unix systems, the readlines function is not exited on file rotation.
-	// If this ever becomes a problem, we may want to consider saving and comparing file creation timestamps.
-	if fi.Size() < pos {
+	t.inode = getInode(fi)
+	t.rotated = nil
+	prevInode := t.positions.GetInode(t.path, t.labelsStr)
+
+	// If the file was rotated since it was last read, the remainder of the rotated file is read before the new file,
+	// which is then read from its start. tail_from_end doesn't apply to files replacing a file which was read.
+	rotated := false
+	if rotatedPath := findRotatedFile(t.path, fi, prevInode, pos); rotatedPath != "" {
+		level.Info(t.logger).Log("msg", "file was rotated since it was last read, will read the rest of the rotated file first", "path", t.path, "rotated_path", rotatedPath)
+		t.rotated = &rotatedFile{path: rotatedPath, inode: prevInode, offset: pos}
+		rotated = true
+		pos = 0
+	} else if pos > 0 && prevInode != 0 && t.inode != 0 && prevInode != t.inode {
+		// The position was recorded for another file which can't be found anymore, for example because it was
+		// deleted. The rest of it is skipped and the new file is read from its start.
+		level.Warn(t.logger).Log("msg", "file was rotated since it was last read but the rotated file wasn't found, the rest of it won't be read", "path", t.path)
+		t.positions.Remove(t.path, t.labelsStr)
+		rotated = true
+		pos = 0
+	} else if fi.Size() < pos {
+		// NOTE: The code assumes that if a position is available and that the file is bigger than the position, then
+		// the tail should start from the position. This may not be always desired in situation where the file was rotated
+		// with a file that has the same name but different content and a bigger size that the previous one, and where
+		// the inode of the file isn't known, for example on Windows.
 		t.positions.Remove(t.path, t.labelsStr)
 	}
 
 	// If no cached position is found and the tailFromEnd option is enabled.
-	if pos == 0 && t.tailFromEnd {
+	if pos == 0 && t.tailFromEnd && !rotated {
 		pos, err = getLastLinePosition(t.path)
 		if err != nil {
 			level.Error(t.logger).Log("msg", "failed to get a position from the end of the file, default to start of file", err)
@@ -201,10 +232,13 @@ func (t *tailer) initRun() (loki.EntryHandler, error) {
 		}
 	}
 
+	// The file isn't reopened when it is moved or deleted: the tail reads the rest of it and exits, and the runner
+	// starts a new tailer for the file which replaced it. This way positions are always recorded for the inode of the
+	// file which is being read.
 	tail, err := tail.TailFile(t.path, tail.Config{
 		Follow:    true,
 		Poll:      true,
-		ReOpen:    true,
+		ReOpen:    false,
 		MustExist: true,
 		Location: &tail.SeekInfo{
 			Offset: pos,
@@ -219,6 +253,7 @@ func (t *tailer) initRun() (loki.EntryHandler, error) {
 	}
 
 	t.tail = tail
+	t.offset = pos
 
 	labelsMiddleware := t.labels.Merge(model.LabelSet{filenameLabel: model.LabelValue(t.path)})
 	handler := loki.AddLabelsMiddleware(labelsMiddleware).Wrap(loki.NewEntryHandler(t.receiver.Chan(), func() {}))
@@ -285,6 +320,8 @@ func (t *tailer) readLines(handler loki.EntryHandler, done chan struct{}) {
 	}()
 
 	entries := handler.Chan()
+	t.readRotated(entries)
+
 	for {
 		line, ok := <-t.tail.Lines
 		if !ok {
@@ -298,35 +335,128 @@ func (t *tailer) readLines(handler loki.EntryHandler, done chan struct{}) {
 			continue
 		}
 
-		var text string
-		if t.decoder != nil {
-			var err error
-			text, err = t.convertToUTF8(line.Text)
-			if err != nil {
-				level.Debug(t.logger).Log("msg", "failed to convert encoding", "error", err)
-				t.metrics.encodingFailures.WithLabelValues(t.path).Inc()
-				text = fmt.Sprintf("the requested encoding conversion for this line failed in Alloy: %s", err.Error())
-			}
-		} else {
-			text = line.Text
-		}
-
 		t.metrics.readLines.WithLabelValues(t.path).Inc()
 		entries <- loki.Entry{
 			Labels: model.LabelSet{},
 			Entry: logproto.Entry{
 				Timestamp: line.Time,
-				Line:      text,
+				Line:      t.lineText(line.Text),
 			},
 		}
+		// The tail only removes the trailing '\n' of lines.
+		t.offset += int64(len(line.Text)) + 1
 	}
 }
 
+// readRotated reads the remainder of the file the tailed file was rotated to,
+// if any, starting from the position recorded before the rotation. Rotated
+// files which were compressed are decompressed. It returns early if the tail
+// is stopped, in which case the position in the rotated file is kept so that
+// reading it is resumed on the next run. Otherwise, the rotated file is done
+// with once it was read to its end or once it fails to be read, and the
+// position of the tailed file is recorded from then on.
+func (t *tailer) readRotated(entries chan<- loki.Entry) {
+	t.posAndSizeMtx.Lock()
+	rotated := t.rotated
+	t.posAndSizeMtx.Unlock()
+	if rotated == nil {
+		return
+	}
+
+	if !t.readRotatedFile(rotated, entries) {
+		return
+	}
+
+	t.posAndSizeMtx.Lock()
+	t.rotated = nil
+	t.posAndSizeMtx.Unlock()
+}
+
+// readRotatedFile reads the rotated file from its offset. It returns false if
+// it was interrupted because the tail is stopped.
+func (t *tailer) readRotatedFile(rotated *rotatedFile, entries chan<- loki.Entry) bool {
+	f, r, err := openRotatedFile(rotated.path, t.logger)
+	if err != nil {
+		level.Error(t.logger).Log("msg", "failed to open rotated file", "path", t.path, "rotated_path", rotated.path, "error", err)
+		return true
+	}
+	defer f.Close()
+
+	// Compressed files can't be seeked, so their content is skipped up to the offset instead.
+	if _, compressed := compressionFormatFromPath(rotated.path); compressed {
+		_, err = io.CopyN(io.Discard, r, rotated.offset)
+	} else {
+		_, err = f.Seek(rotated.offset, io.SeekStart)
+	}
+	if err != nil {
+		level.Warn(t.logger).Log("msg", "failed to seek to position in rotated file", "path", t.path, "rotated_path", rotated.path, "offset", rotated.offset, "error", err)
+		return true
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			t.metrics.readLines.WithLabelValues(t.path).Inc()
+			entry := loki.Entry{
+				Labels: model.LabelSet{},
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      t.lineText(strings.TrimSuffix(line, "\n")),
+				},
+			}
+			select {
+			case entries <- entry:
+			case <-t.tail.Dying():
+				return false
+			}
+
+			t.posAndSizeMtx.Lock()
+			rotated.offset += int64(len(line))
+			t.posAndSizeMtx.Unlock()
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				level.Error(t.logger).Log("msg", "error reading rotated file", "path", t.path, "rotated_path", rotated.path, "error", err)
+			}
+			break
+		}
+	}
+
+	level.Info(t.logger).Log("msg", "finished reading rotated file", "path", t.path, "rotated_path", rotated.path)
+	return true
+}
+
+// lineText returns the text of a line converted to UTF8 if an encoding is
+// configured.
+func (t *tailer) lineText(text string) string {
+	if t.decoder == nil {
+		return text
+	}
+
+	res, err := t.convertToUTF8(text)
+	if err != nil {
+		level.Debug(t.logger).Log("msg", "failed to convert encoding", "error", err)
+		t.metrics.encodingFailures.WithLabelValues(t.path).Inc()
+		return fmt.Sprintf("the requested encoding conversion for this line failed in Alloy: %s", err.Error())
+	}
+	return res
+}
+
 func (t *tailer) markPositionAndSize() error {
 	// Lock this update because it can be called in two different goroutines
 	t.posAndSizeMtx.Lock()
 	defer t.posAndSizeMtx.Unlock()
 
+	// Until a rotated file was read completely, its position is recorded so that reading it is resumed after a
+	// restart.
+	if t.rotated != nil {
+		t.positions.Put(t.path, t.labelsStr, t.rotated.offset)
+		t.positions.PutInode(t.path, t.labelsStr, t.rotated.inode)
+		return nil
+	}
+
 	size, err := t.tail.Size()
 	if err != nil {
 		// If the file no longer exists, no need to save position information
@@ -346,11 +476,16 @@ func (t *tailer) markPositionAndSize() error {
 	t.metrics.totalBytes.WithLabelValues(t.path).Set(float64(size))
 	t.metrics.readBytes.WithLabelValues(t.path).Set(float64(pos))
 	t.positions.Put(t.path, t.labelsStr, pos)
+	if t.inode != 0 {
+		t.positions.PutInode(t.path, t.labelsStr, t.inode)
+	}
 
 	return nil
 }
 
-func (t *tailer) stop(done chan struct{}) {
+// stop stops the tail and waits for readLines to exit. tailEnded is true if
+// the tail stopped by itself rather than because the tailer is stopped.
+func (t *tailer) stop(done chan struct{}, tailEnded bool) {
 	// Save the current position before shutting down tailer to ensure that if the file is tailed again
 	// it start where it left off.
 	err := t.markPositionAndSize()
@@ -377,6 +512,14 @@ func (t *tailer) stop(done chan struct{}) {
 
 	level.Info(t.logger).Log("msg", "stopped tailing file", "path", t.path)
 
+	// The tail stops by itself once it read a file which was moved or deleted, so the runner starts a new tailer
+	// for the file replacing it. The position is kept so that the new tailer reads the rest of the rotated file
+	// and the new file from its start, instead of skipping lines with tail_from_end.
+	if tailEnded {
+		t.markEndedPosition()
+		return
+	}
+
 	// If the component is not stopping, then it means that the target for this component is gone and that
 	// we should clear the entry from the positions file.
 	if !t.componentStopping() {
@@ -384,6 +527,23 @@ func (t *tailer) stop(done chan struct{}) {
 	}
 }
 
+// markEndedPosition records the offset of the last line read from the file,
+// once the tail stopped by itself, if the file was renamed. The offset
+// recorded by markPositionAndSize is kept otherwise.
+func (t *tailer) markEndedPosition() {
+	t.posAndSizeMtx.Lock()
+	defer t.posAndSizeMtx.Unlock()
+
+	if t.rotated != nil {
+		return
+	}
+	if _, renamed := findRenamedFile(t.path, t.inode); !renamed {
+		return
+	}
+	t.positions.Put(t.path, t.labelsStr, t.offset)
+	t.positions.PutInode(t.path, t.labelsStr, t.inode)
+}
+
 func (t *tailer) IsRunning() bool {
 	return t.running.Load()
 }
@@ -0,0 +1,227 @@
+package otlppush
+
+import (
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/prometheus"
+	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+)
+
+const (
+	attrServiceName    = "service.name"
+	unknownServiceName = "unknown_service"
+)
+
+// DefaultResourceAttributeLabels are the resource attributes which are
+// converted to labels by default. It matches the resource attributes Loki
+// indexes by default when it receives OTLP logs.
+var DefaultResourceAttributeLabels = []string{
+	"service.name",
+	"service.namespace",
+	"service.instance.id",
+	"deployment.environment",
+	"cloud.region",
+	"cloud.availability_zone",
+	"k8s.cluster.name",
+	"k8s.namespace.name",
+	"k8s.pod.name",
+	"k8s.container.name",
+	"container.name",
+	"k8s.replicaset.name",
+	"k8s.deployment.name",
+	"k8s.statefulset.name",
+	"k8s.daemonset.name",
+	"k8s.cronjob.name",
+	"k8s.job.name",
+}
+
+// ConvertConfig controls which attributes of OTLP logs are converted to
+// labels. All other attributes are converted to structured metadata.
+type ConvertConfig struct {
+	ResourceAttributeLabels []string
+	LogAttributeLabels      []string
+}
+
+// converter converts OTLP logs to Loki entries the same way Loki converts
+// the OTLP logs it receives, except that the attributes converted to labels
+// are configurable.
+type converter struct {
+	resourceAttributeLabels map[string]struct{}
+	logAttributeLabels      map[string]struct{}
+}
+
+func newConverter(cfg ConvertConfig) *converter {
+	return &converter{
+		resourceAttributeLabels: toSet(cfg.ResourceAttributeLabels),
+		logAttributeLabels:      toSet(cfg.LogAttributeLabels),
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// convert converts every log record of ld to a Loki entry.
+func (c *converter) convert(ld plog.Logs) []loki.Entry {
+	entries := make([]loki.Entry, 0, ld.LogRecordCount())
+
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		resAttrs := rls.At(i).Resource().Attributes()
+
+		// Like Loki, make sure that every stream has at least the service_name label.
+		resourceLabels := model.LabelSet{}
+		if v, ok := resAttrs.Get(attrServiceName); !ok || v.AsString() == "" {
+			resourceLabels[model.LabelName(prometheus.NormalizeLabel(attrServiceName))] = unknownServiceName
+		}
+
+		var resourceMetadata push.LabelsAdapter
+		resAttrs.Range(func(k string, v pcommon.Value) bool {
+			if _, ok := c.resourceAttributeLabels[k]; ok {
+				for _, l := range attributeToLabels(k, v, "") {
+					resourceLabels[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+				}
+				return true
+			}
+			resourceMetadata = append(resourceMetadata, attributeToLabels(k, v, "")...)
+			return true
+		})
+
+		sls := rls.At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			scopeMetadata := scopeToStructuredMetadata(sls.At(j).Scope())
+
+			logs := sls.At(j).LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				entry := c.convertLogRecord(logs.At(k), resourceLabels)
+				entry.StructuredMetadata = append(entry.StructuredMetadata, resourceMetadata...)
+				entry.StructuredMetadata = append(entry.StructuredMetadata, scopeMetadata...)
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries
+}
+
+// convertLogRecord converts a log record to a Loki entry. The log attributes
+// and fields, except the body, are converted to labels or structured
+// metadata.
+func (c *converter) convertLogRecord(lr plog.LogRecord, resourceLabels model.LabelSet) loki.Entry {
+	lbls := resourceLabels.Clone()
+
+	var metadata push.LabelsAdapter
+	lr.Attributes().Range(func(k string, v pcommon.Value) bool {
+		if _, ok := c.logAttributeLabels[k]; ok {
+			for _, l := range attributeToLabels(k, v, "") {
+				lbls[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+			}
+			return true
+		}
+		metadata = append(metadata, attributeToLabels(k, v, "")...)
+		return true
+	})
+
+	// If the timestamp isn't set, the observed timestamp is already used as
+	// the timestamp of the entry.
+	if lr.Timestamp() != 0 && lr.ObservedTimestamp() != 0 {
+		metadata = append(metadata, push.LabelAdapter{Name: "observed_timestamp", Value: strconv.FormatInt(lr.ObservedTimestamp().AsTime().UnixNano(), 10)})
+	}
+	if severityNum := lr.SeverityNumber(); severityNum != plog.SeverityNumberUnspecified {
+		metadata = append(metadata, push.LabelAdapter{Name: "severity_number", Value: strconv.Itoa(int(severityNum))})
+	}
+	if severityText := lr.SeverityText(); severityText != "" {
+		metadata = append(metadata, push.LabelAdapter{Name: "severity_text", Value: severityText})
+	}
+	if droppedAttributesCount := lr.DroppedAttributesCount(); droppedAttributesCount != 0 {
+		metadata = append(metadata, push.LabelAdapter{Name: "dropped_attributes_count", Value: strconv.FormatUint(uint64(droppedAttributesCount), 10)})
+	}
+	if flags := lr.Flags(); flags != 0 {
+		metadata = append(metadata, push.LabelAdapter{Name: "flags", Value: strconv.FormatUint(uint64(flags), 10)})
+	}
+	if traceID := lr.TraceID(); !traceID.IsEmpty() {
+		metadata = append(metadata, push.LabelAdapter{Name: "trace_id", Value: hex.EncodeToString(traceID[:])})
+	}
+	if spanID := lr.SpanID(); !spanID.IsEmpty() {
+		metadata = append(metadata, push.LabelAdapter{Name: "span_id", Value: hex.EncodeToString(spanID[:])})
+	}
+
+	return loki.Entry{
+		Labels: lbls,
+		Entry: logproto.Entry{
+			Timestamp:          timestampFromLogRecord(lr),
+			Line:               lr.Body().AsString(),
+			StructuredMetadata: metadata,
+		},
+	}
+}
+
+// scopeToStructuredMetadata converts the attributes and fields of an
+// instrumentation scope to structured metadata.
+func scopeToStructuredMetadata(scope pcommon.InstrumentationScope) push.LabelsAdapter {
+	metadata := attributesToLabels(scope.Attributes())
+	if name := scope.Name(); name != "" {
+		metadata = append(metadata, push.LabelAdapter{Name: "scope_name", Value: name})
+	}
+	if version := scope.Version(); version != "" {
+		metadata = append(metadata, push.LabelAdapter{Name: "scope_version", Value: version})
+	}
+	if droppedAttributesCount := scope.DroppedAttributesCount(); droppedAttributesCount != 0 {
+		metadata = append(metadata, push.LabelAdapter{Name: "scope_dropped_attributes_count", Value: strconv.FormatUint(uint64(droppedAttributesCount), 10)})
+	}
+	return metadata
+}
+
+func attributesToLabels(attrs pcommon.Map) push.LabelsAdapter {
+	lbls := make(push.LabelsAdapter, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		lbls = append(lbls, attributeToLabels(k, v, "")...)
+		return true
+	})
+	return lbls
+}
+
+// attributeToLabels converts an attribute to one or more labels with a
+// Prometheus compatible name. Map attributes are flattened, using the
+// names of their parents as prefix.
+func attributeToLabels(k string, v pcommon.Value, prefix string) push.LabelsAdapter {
+	name := k
+	if prefix != "" {
+		name = prefix + "_" + k
+	}
+	name = prometheus.NormalizeLabel(name)
+
+	if v.Type() != pcommon.ValueTypeMap {
+		return push.LabelsAdapter{{Name: name, Value: v.AsString()}}
+	}
+
+	mv := v.Map()
+	lbls := make(push.LabelsAdapter, 0, mv.Len())
+	mv.Range(func(k string, v pcommon.Value) bool {
+		lbls = append(lbls, attributeToLabels(k, v, name)...)
+		return true
+	})
+	return lbls
+}
+
+func timestampFromLogRecord(lr plog.LogRecord) time.Time {
+	if lr.Timestamp() != 0 {
+		return lr.Timestamp().AsTime()
+	}
+	if lr.ObservedTimestamp() != 0 {
+		return lr.ObservedTimestamp().AsTime()
+	}
+	return time.Now()
+}
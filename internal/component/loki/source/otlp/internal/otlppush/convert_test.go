@@ -0,0 +1,101 @@
+package otlppush
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestConvert(t *testing.T) {
+	ts := time.Unix(0, 1700000000000000000).UTC()
+	observed := ts.Add(time.Second)
+
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	rl.Resource().Attributes().PutStr("k8s.namespace.name", "shop")
+	rl.Resource().Attributes().PutStr("host.name", "node-1")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("logger")
+	sl.Scope().SetVersion("v1")
+	sl.Scope().Attributes().PutStr("scope.attr", "value")
+
+	lr := sl.LogRecords().AppendEmpty()
+	lr.Body().SetStr("payment processed")
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(observed))
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.SetSeverityText("INFO")
+	lr.SetTraceID([16]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10})
+	lr.SetSpanID([8]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+	lr.Attributes().PutStr("level", "info")
+	lr.Attributes().PutStr("user.id", "42")
+	nested := lr.Attributes().PutEmptyMap("http")
+	nested.PutInt("status", 200)
+
+	c := newConverter(ConvertConfig{
+		ResourceAttributeLabels: DefaultResourceAttributeLabels,
+		LogAttributeLabels:      []string{"level"},
+	})
+	entries := c.convert(ld)
+	require.Len(t, entries, 1)
+
+	e := entries[0]
+	assert.Equal(t, "payment processed", e.Line)
+	assert.Equal(t, ts, e.Timestamp)
+	assert.Equal(t, model.LabelSet{
+		"service_name":       "checkout",
+		"k8s_namespace_name": "shop",
+		"level":              "info",
+	}, e.Labels)
+	assert.ElementsMatch(t, push.LabelsAdapter{
+		{Name: "user_id", Value: "42"},
+		{Name: "http_status", Value: "200"},
+		{Name: "observed_timestamp", Value: "1700000001000000000"},
+		{Name: "severity_number", Value: "9"},
+		{Name: "severity_text", Value: "INFO"},
+		{Name: "trace_id", Value: "0102030405060708090a0b0c0d0e0f10"},
+		{Name: "span_id", Value: "0102030405060708"},
+		{Name: "host_name", Value: "node-1"},
+		{Name: "scope_attr", Value: "value"},
+		{Name: "scope_name", Value: "logger"},
+		{Name: "scope_version", Value: "v1"},
+	}, e.StructuredMetadata)
+}
+
+func TestConvert_DefaultsServiceName(t *testing.T) {
+	ld := plog.NewLogs()
+	lrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	lrs.AppendEmpty().Body().SetStr("first")
+	lrs.AppendEmpty().Body().SetStr("second")
+
+	entries := newConverter(ConvertConfig{}).convert(ld)
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		assert.Equal(t, model.LabelSet{"service_name": "unknown_service"}, e.Labels)
+		assert.Empty(t, e.StructuredMetadata)
+	}
+	assert.Equal(t, "first", entries[0].Line)
+	assert.Equal(t, "second", entries[1].Line)
+}
+
+func TestConvert_ObservedTimestamp(t *testing.T) {
+	observed := time.Unix(1700000000, 0).UTC()
+
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(observed))
+
+	entries := newConverter(ConvertConfig{}).convert(ld)
+	require.Len(t, entries, 1)
+	assert.Equal(t, observed, entries[0].Timestamp)
+	// The observed timestamp is only kept as structured metadata if it
+	// differs from the entry timestamp.
+	assert.Empty(t, entries[0].StructuredMetadata)
+}
@@ -0,0 +1,309 @@
+package otlppush
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/common/loki/client"
+	fnet "github.com/grafana/alloy/internal/component/common/net"
+	frelabel "github.com/grafana/alloy/internal/component/common/relabel"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+const (
+	pbContentType   = "application/x-protobuf"
+	jsonContentType = "application/json"
+
+	// DefaultMaxRequestBodySize is the default maximum size of the body of an
+	// OTLP/HTTP request, which matches the default maximum size of the
+	// messages received by the gRPC server.
+	DefaultMaxRequestBodySize = 4 << 20
+)
+
+// PushServer is a server which accepts OTLP logs over HTTP and gRPC and
+// forwards them as Loki entries to a handler.
+type PushServer struct {
+	logger       log.Logger
+	serverConfig *fnet.ServerConfig
+	server       *fnet.TargetServer
+	handler      loki.EntryHandler
+
+	rwMutex            sync.RWMutex
+	labels             model.LabelSet
+	relabelRules       []*relabel.Config
+	keepTimestamp      bool
+	converter          *converter
+	maxRequestBodySize int64
+}
+
+func NewPushServer(logger log.Logger,
+	serverConfig *fnet.ServerConfig,
+	handler loki.EntryHandler,
+	registerer prometheus.Registerer,
+) (*PushServer, error) {
+
+	s := &PushServer{
+		logger:       logger,
+		serverConfig: serverConfig,
+		handler:      handler,
+		converter:    newConverter(ConvertConfig{}),
+
+		maxRequestBodySize: DefaultMaxRequestBodySize,
+	}
+
+	srv, err := fnet.NewTargetServer(logger, "loki_source_otlp", registerer, serverConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	s.server = srv
+	return s, nil
+}
+
+func (s *PushServer) Run() error {
+	level.Info(s.logger).Log("msg", "starting OTLP push server")
+
+	return s.server.MountAndRunWithGRPC(func(router *mux.Router) {
+		router.Path("/v1/logs").Methods("POST").Handler(http.HandlerFunc(s.handleHTTP))
+		router.Path("/ready").Methods("GET").Handler(http.HandlerFunc(s.ready))
+	}, func(server *grpc.Server) {
+		plogotlp.RegisterGRPCServer(server, &grpcServer{s: s})
+	})
+}
+
+func (s *PushServer) ServerConfig() fnet.ServerConfig {
+	return *s.serverConfig
+}
+
+// HTTPListenAddr returns the listen address of the HTTP server.
+func (s *PushServer) HTTPListenAddr() string {
+	return s.server.HTTPListenAddr()
+}
+
+// GRPCListenAddr returns the listen address of the gRPC server.
+func (s *PushServer) GRPCListenAddr() string {
+	return s.server.GRPCListenAddr()
+}
+
+func (s *PushServer) Shutdown() {
+	level.Info(s.logger).Log("msg", "stopping OTLP push server")
+	s.server.StopAndShutdown()
+}
+
+func (s *PushServer) SetLabels(labels model.LabelSet) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.labels = labels
+}
+
+func (s *PushServer) SetKeepTimestamp(keepTimestamp bool) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.keepTimestamp = keepTimestamp
+}
+
+func (s *PushServer) SetRelabelRules(rules frelabel.Rules) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.relabelRules = frelabel.ComponentToPromRelabelConfigs(rules)
+}
+
+func (s *PushServer) SetConvertConfig(cfg ConvertConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.converter = newConverter(cfg)
+}
+
+// SetMaxRequestBodySize sets the maximum size of the body of an OTLP/HTTP
+// request, once decompressed.
+func (s *PushServer) SetMaxRequestBodySize(size int64) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.maxRequestBodySize = size
+}
+
+func (s *PushServer) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	_, ctx, _ := user.ExtractOrgIDFromHTTPRequest(r)
+
+	contentType := r.Header.Get("Content-Type")
+	if i := strings.IndexByte(contentType, ';'); i != -1 {
+		contentType = contentType[:i]
+	}
+
+	s.rwMutex.RLock()
+	maxBodySize := s.maxRequestBodySize
+	s.rwMutex.RUnlock()
+
+	req, err := readHTTPRequest(w, r, contentType, maxBodySize)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to parse incoming OTLP request", "err", err.Error())
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.handleLogs(ctx, req.Logs()); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	var body []byte
+	resp := plogotlp.NewExportResponse()
+	if contentType == jsonContentType {
+		body, err = resp.MarshalJSON()
+	} else {
+		body, err = resp.MarshalProto()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		level.Warn(s.logger).Log("msg", "failed to write OTLP response", "err", err)
+	}
+}
+
+// ready responds to requests checking that the server is reachable.
+func (s *PushServer) ready(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ready")); err != nil {
+		level.Error(s.logger).Log("msg", "failed to respond to ready endpoint", "err", err)
+	}
+}
+
+// readHTTPRequest reads an OTLP logs export request encoded as protobuf or
+// JSON, optionally compressed with gzip. It returns an [http.MaxBytesError]
+// if the body is larger than maxBodySize, either as sent or once
+// decompressed.
+func readHTTPRequest(w http.ResponseWriter, r *http.Request, contentType string, maxBodySize int64) (plogotlp.ExportRequest, error) {
+	req := plogotlp.NewExportRequest()
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxBodySize)
+	defer r.Body.Close()
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return req, err
+		}
+		defer gr.Close()
+		// Read one more byte than allowed to tell a body of exactly
+		// maxBodySize bytes from a larger one.
+		body = io.LimitReader(gr, maxBodySize+1)
+	}
+
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return req, err
+	}
+	if int64(len(buf)) > maxBodySize {
+		return req, &http.MaxBytesError{Limit: maxBodySize}
+	}
+
+	switch contentType {
+	case pbContentType:
+		err = req.UnmarshalProto(buf)
+	case jsonContentType:
+		err = req.UnmarshalJSON(buf)
+	default:
+		err = fmt.Errorf("content type %q is not supported", contentType)
+	}
+	return req, err
+}
+
+type grpcServer struct {
+	plogotlp.UnimplementedGRPCServer
+	s *PushServer
+}
+
+// Export implements plogotlp.GRPCServer.
+func (g *grpcServer) Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	_, ctx, _ = user.ExtractFromGRPCRequest(ctx)
+	if err := g.s.handleLogs(ctx, req.Logs()); err != nil {
+		return plogotlp.NewExportResponse(), err
+	}
+	return plogotlp.NewExportResponse(), nil
+}
+
+// handleLogs converts the logs to Loki entries and sends them to the
+// handler. It returns an error if the request is canceled before all entries
+// were sent.
+func (s *PushServer) handleLogs(ctx context.Context, ld plog.Logs) error {
+	tenantID, _ := user.ExtractOrgID(ctx)
+
+	// Take snapshot of current configs and apply consistently for the entire request.
+	s.rwMutex.RLock()
+	addLabels := s.labels.Clone()
+	relabelRules := s.relabelRules
+	keepTimestamp := s.keepTimestamp
+	converter := s.converter
+	s.rwMutex.RUnlock()
+
+	for _, e := range converter.convert(ld) {
+		lb := labels.NewBuilder(labels.EmptyLabels())
+		for k, v := range e.Labels {
+			lb.Set(string(k), string(v))
+		}
+		// Add configured labels
+		for k, v := range addLabels {
+			lb.Set(string(k), string(v))
+		}
+
+		// Apply relabeling
+		processed, keep := relabel.Process(lb.Labels(), relabelRules...)
+		if !keep || processed.IsEmpty() {
+			continue
+		}
+
+		// Convert to model.LabelSet
+		filtered := model.LabelSet{}
+		processed.Range(func(l labels.Label) {
+			if strings.HasPrefix(l.Name, "__") {
+				return
+			}
+			filtered[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		})
+
+		// Add tenant ID to the filtered labels if it is set
+		if tenantID != "" {
+			filtered[model.LabelName(client.ReservedLabelTenantID)] = model.LabelValue(tenantID)
+		}
+
+		e.Labels = filtered
+		if !keepTimestamp {
+			e.Timestamp = time.Now()
+		}
+
+		select {
+		case s.handler.Chan() <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/alecthomas/units"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	fnet "github.com/grafana/alloy/internal/component/common/net"
+	"github.com/grafana/alloy/internal/component/common/relabel"
+	"github.com/grafana/alloy/internal/component/loki/source/otlp/internal/otlppush"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "loki.source.otlp",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+const (
+	// DefaultHTTPPort is the default port of the OTLP/HTTP receiver.
+	DefaultHTTPPort = 4318
+	// DefaultGRPCPort is the default port of the OTLP/gRPC receiver.
+	DefaultGRPCPort = 4317
+)
+
+type Arguments struct {
+	Server                  *fnet.ServerConfig  `alloy:",squash"`
+	ForwardTo               []loki.LogsReceiver `alloy:"forward_to,attr"`
+	Labels                  map[string]string   `alloy:"labels,attr,optional"`
+	ResourceAttributeLabels []string            `alloy:"resource_attribute_labels,attr,optional"`
+	LogAttributeLabels      []string            `alloy:"log_attribute_labels,attr,optional"`
+	RelabelRules            relabel.Rules       `alloy:"relabel_rules,attr,optional"`
+	UseIncomingTimestamp    bool                `alloy:"use_incoming_timestamp,attr,optional"`
+	// The maximum size of the body of an OTLP/HTTP request, once decompressed.
+	MaxRequestBodySize units.Base2Bytes `alloy:"max_request_body_size,attr,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = Arguments{
+		Server:                  defaultServerConfig(),
+		ResourceAttributeLabels: append([]string(nil), otlppush.DefaultResourceAttributeLabels...),
+		UseIncomingTimestamp:    true,
+		MaxRequestBodySize:      otlppush.DefaultMaxRequestBodySize,
+	}
+}
+
+// Validate implements syntax.Validator.
+func (a *Arguments) Validate() error {
+	if a.MaxRequestBodySize <= 0 {
+		return fmt.Errorf("max_request_body_size must be greater than 0")
+	}
+	return nil
+}
+
+// defaultServerConfig returns the default server config with the standard
+// OTLP ports.
+func defaultServerConfig() *fnet.ServerConfig {
+	cfg := fnet.DefaultServerConfig()
+	cfg.HTTP.ListenPort = DefaultHTTPPort
+	cfg.GRPC.ListenPort = DefaultGRPCPort
+	return cfg
+}
+
+func (a *Arguments) labelSet() model.LabelSet {
+	labelSet := make(model.LabelSet, len(a.Labels))
+	for k, v := range a.Labels {
+		labelSet[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return labelSet
+}
+
+func (a *Arguments) convertConfig() otlppush.ConvertConfig {
+	return otlppush.ConvertConfig{
+		ResourceAttributeLabels: a.ResourceAttributeLabels,
+		LogAttributeLabels:      a.LogAttributeLabels,
+	}
+}
+
+type Component struct {
+	opts               component.Options
+	entriesChan        chan loki.Entry
+	uncheckedCollector *util.UncheckedCollector
+
+	serverMut sync.Mutex
+	server    *otlppush.PushServer
+
+	// Use separate receivers mutex to avoid a deadlock when Update drains the
+	// current server while Run is forwarding entries.
+	receiversMut sync.RWMutex
+	receivers    []loki.LogsReceiver
+}
+
+func New(opts component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:               opts,
+		entriesChan:        make(chan loki.Entry),
+		receivers:          args.ForwardTo,
+		uncheckedCollector: util.NewUncheckedCollector(nil),
+	}
+	opts.Registerer.MustRegister(c.uncheckedCollector)
+	err := c.Update(args)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Component) Run(ctx context.Context) (err error) {
+	defer c.stop()
+
+	for {
+		select {
+		case entry := <-c.entriesChan:
+			c.receiversMut.RLock()
+			receivers := c.receivers
+			c.receiversMut.RUnlock()
+
+			for _, receiver := range receivers {
+				select {
+				case receiver.Chan() <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Component) Update(args component.Arguments) error {
+	newArgs, ok := args.(Arguments)
+	if !ok {
+		return fmt.Errorf("invalid type of arguments: %T", args)
+	}
+
+	// if no server config provided, we'll use defaults
+	if newArgs.Server == nil {
+		newArgs.Server = defaultServerConfig()
+	}
+	if newArgs.Server.HTTP == nil {
+		newArgs.Server.HTTP = defaultServerConfig().HTTP
+	}
+	if newArgs.Server.GRPC == nil {
+		newArgs.Server.GRPC = defaultServerConfig().GRPC
+	}
+
+	c.receiversMut.Lock()
+	c.receivers = newArgs.ForwardTo
+	c.receiversMut.Unlock()
+
+	c.serverMut.Lock()
+	defer c.serverMut.Unlock()
+	serverNeedsRestarting := c.server == nil || !reflect.DeepEqual(c.server.ServerConfig(), *newArgs.Server)
+	if serverNeedsRestarting {
+		if c.server != nil {
+			c.server.Shutdown()
+		}
+
+		// [server.Server] registers new metrics every time it is created. To
+		// avoid issues with re-registering metrics with the same name, we create a
+		// new registry for the server every time we create one, and pass it to an
+		// unchecked collector to bypass uniqueness checking.
+		serverRegistry := prometheus.NewRegistry()
+		c.uncheckedCollector.SetCollector(serverRegistry)
+
+		var err error
+		c.server, err = otlppush.NewPushServer(c.opts.Logger, newArgs.Server, loki.NewEntryHandler(c.entriesChan, func() {}), serverRegistry)
+		if err != nil {
+			return fmt.Errorf("failed to create embedded server: %v", err)
+		}
+		err = c.server.Run()
+		if err != nil {
+			return fmt.Errorf("failed to run embedded server: %v", err)
+		}
+	}
+
+	c.server.SetLabels(newArgs.labelSet())
+	c.server.SetRelabelRules(newArgs.RelabelRules)
+	c.server.SetKeepTimestamp(newArgs.UseIncomingTimestamp)
+	c.server.SetConvertConfig(newArgs.convertConfig())
+	c.server.SetMaxRequestBodySize(int64(newArgs.MaxRequestBodySize))
+
+	return nil
+}
+
+func (c *Component) stop() {
+	c.serverMut.Lock()
+	defer c.serverMut.Unlock()
+	if c.server != nil {
+		c.server.Shutdown()
+		c.server = nil
+	}
+}
@@ -0,0 +1,355 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/regexp"
+	"github.com/phayes/freeport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/common/loki/client"
+	"github.com/grafana/alloy/internal/component/common/loki/client/fake"
+	"github.com/grafana/alloy/internal/component/common/relabel"
+	"github.com/grafana/alloy/internal/component/loki/source/otlp/internal/otlppush"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestLokiSourceOTLP_HTTP(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		gzip        bool
+	}{
+		"protobuf":      {contentType: "application/x-protobuf"},
+		"json":          {contentType: "application/json"},
+		"gzip protobuf": {contentType: "application/x-protobuf", gzip: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+
+			receiver := fake.NewClient(func() {})
+			defer receiver.Stop()
+
+			args := testArgsWith(t, func(a *Arguments) {
+				a.ForwardTo = []loki.LogsReceiver{receiver.LogsReceiver()}
+			})
+			_, shutdown := startTestComponent(t, defaultOptions(t), args, ctx)
+			defer shutdown()
+
+			req := plogotlp.NewExportRequestFromLogs(testLogs())
+			var (
+				body []byte
+				err  error
+			)
+			if tc.contentType == "application/json" {
+				body, err = req.MarshalJSON()
+			} else {
+				body, err = req.MarshalProto()
+			}
+			require.NoError(t, err)
+
+			if tc.gzip {
+				var buf bytes.Buffer
+				gw := gzip.NewWriter(&buf)
+				_, err = gw.Write(body)
+				require.NoError(t, err)
+				require.NoError(t, gw.Close())
+				body = buf.Bytes()
+			}
+
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s:%d/v1/logs", args.Server.HTTP.ListenAddress, args.Server.HTTP.ListenPort), bytes.NewReader(body))
+			require.NoError(t, err)
+			httpReq.Header.Set("Content-Type", tc.contentType)
+			httpReq.Header.Set("X-Scope-OrgID", "tenant1")
+			if tc.gzip {
+				httpReq.Header.Set("Content-Encoding", "gzip")
+			}
+
+			resp, err := http.DefaultClient.Do(httpReq)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, tc.contentType, resp.Header.Get("Content-Type"))
+
+			assertReceived(t, receiver, "tenant1")
+		})
+	}
+}
+
+func TestLokiSourceOTLP_HTTPEndpoints(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	args := testArgs(t)
+	_, shutdown := startTestComponent(t, defaultOptions(t), args, ctx)
+	defer shutdown()
+
+	url := fmt.Sprintf("http://%s:%d/v1/logs", args.Server.HTTP.ListenAddress, args.Server.HTTP.ListenPort)
+
+	resp, err := http.Post(url, "application/x-protobuf", bytes.NewReader([]byte("not protobuf")))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Post(url, "text/plain", bytes.NewReader([]byte("hello")))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(fmt.Sprintf("http://%s:%d/ready", args.Server.HTTP.ListenAddress, args.Server.HTTP.ListenPort))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestLokiSourceOTLP_HTTPMaxRequestBodySize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	body, err := plogotlp.NewExportRequestFromLogs(testLogs()).MarshalProto()
+	require.NoError(t, err)
+
+	args := testArgsWith(t, func(a *Arguments) {
+		a.MaxRequestBodySize = units.Base2Bytes(len(body) - 1)
+	})
+	_, shutdown := startTestComponent(t, defaultOptions(t), args, ctx)
+	defer shutdown()
+
+	url := fmt.Sprintf("http://%s:%d/v1/logs", args.Server.HTTP.ListenAddress, args.Server.HTTP.ListenPort)
+
+	resp, err := http.Post(url, "application/x-protobuf", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// The limit also applies to the decompressed body, which is larger than
+	// the compressed one.
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err = gw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	require.Less(t, buf.Len(), len(body))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	require.NoError(t, err)
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("Content-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestLokiSourceOTLP_GRPC(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	receiver := fake.NewClient(func() {})
+	defer receiver.Stop()
+
+	args := testArgsWith(t, func(a *Arguments) {
+		a.ForwardTo = []loki.LogsReceiver{receiver.LogsReceiver()}
+	})
+	_, shutdown := startTestComponent(t, defaultOptions(t), args, ctx)
+	defer shutdown()
+
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("%s:%d", args.Server.GRPC.ListenAddress, args.Server.GRPC.ListenPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	grpcCtx := metadata.AppendToOutgoingContext(ctx, "X-Scope-OrgID", "tenant1")
+	_, err = plogotlp.NewGRPCClient(conn).Export(grpcCtx, plogotlp.NewExportRequestFromLogs(testLogs()))
+	require.NoError(t, err)
+
+	assertReceived(t, receiver, "tenant1")
+}
+
+func TestLokiSourceOTLP_UseIncomingTimestamp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	receiver := fake.NewClient(func() {})
+	defer receiver.Stop()
+
+	args := testArgsWith(t, func(a *Arguments) {
+		a.ForwardTo = []loki.LogsReceiver{receiver.LogsReceiver()}
+		a.UseIncomingTimestamp = false
+	})
+	_, shutdown := startTestComponent(t, defaultOptions(t), args, ctx)
+	defer shutdown()
+
+	body, err := plogotlp.NewExportRequestFromLogs(testLogs()).MarshalProto()
+	require.NoError(t, err)
+	resp, err := http.Post(fmt.Sprintf("http://%s:%d/v1/logs", args.Server.HTTP.ListenAddress, args.Server.HTTP.ListenPort), "application/x-protobuf", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Eventually(t, func() bool { return len(receiver.Received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.WithinDuration(t, time.Now(), receiver.Received()[0].Timestamp, 5*time.Second)
+}
+
+func TestArguments_Defaults(t *testing.T) {
+	var args Arguments
+	err := syntax.Unmarshal([]byte(`forward_to = []`), &args)
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultHTTPPort, args.Server.HTTP.ListenPort)
+	assert.Equal(t, DefaultGRPCPort, args.Server.GRPC.ListenPort)
+	assert.Contains(t, args.ResourceAttributeLabels, "service.name")
+	assert.Empty(t, args.LogAttributeLabels)
+	assert.True(t, args.UseIncomingTimestamp)
+	assert.Equal(t, units.Base2Bytes(4*units.MiB), args.MaxRequestBodySize)
+}
+
+// testLogs returns two log records from the same resource. The second one
+// is dropped by the relabel rules of testArgs.
+func testLogs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	rl.Resource().Attributes().PutStr("host.name", "node-1")
+	lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+
+	lr := lrs.AppendEmpty()
+	lr.Body().SetStr("hello world!")
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	lr.Attributes().PutStr("level", "info")
+	lr.Attributes().PutStr("tag", "keep")
+
+	lr = lrs.AppendEmpty()
+	lr.Body().SetStr("dropped")
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	lr.Attributes().PutStr("level", "info")
+	lr.Attributes().PutStr("tag", "ignore")
+
+	return ld
+}
+
+func assertReceived(t *testing.T, receiver *fake.Client, tenant string) {
+	require.Eventually(
+		t,
+		func() bool { return len(receiver.Received()) == 1 },
+		5*time.Second,
+		10*time.Millisecond,
+		"did not receive the forwarded message within the timeout",
+	)
+	// Make sure that the dropped entry doesn't arrive late.
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, receiver.Received(), 1)
+
+	received := receiver.Received()[0]
+	assert.Equal(t, "hello world!", received.Line)
+	assert.Equal(t, int64(1700000000), received.Timestamp.Unix())
+	assert.Equal(t, model.LabelSet{
+		"service_name":               "checkout",
+		"level":                      "info",
+		"tag":                        "keep",
+		"foo":                        "bar",
+		client.ReservedLabelTenantID: model.LabelValue(tenant),
+	}, received.Labels)
+	assert.Equal(t, "host_name", received.StructuredMetadata[0].Name)
+	assert.Equal(t, "node-1", received.StructuredMetadata[0].Value)
+}
+
+func startTestComponent(
+	t *testing.T,
+	opts component.Options,
+	args Arguments,
+	ctx context.Context,
+) (component.Component, func()) {
+
+	comp, err := New(opts, args)
+	require.NoError(t, err)
+	go func() {
+		err := comp.Run(ctx)
+		require.NoError(t, err)
+	}()
+
+	return comp, func() {
+		// in order to cleanly shutdown, we want to make sure the server is running first.
+		waitForServerToBeReady(t, comp)
+		comp.stop()
+	}
+}
+
+func waitForServerToBeReady(t *testing.T, comp *Component) {
+	require.Eventuallyf(t, func() bool {
+		resp, err := http.Get(fmt.Sprintf(
+			"http://%v:%d/wrong/url",
+			comp.server.ServerConfig().HTTP.ListenAddress,
+			comp.server.ServerConfig().HTTP.ListenPort,
+		))
+		return err == nil && resp.StatusCode == 404
+	}, 5*time.Second, 20*time.Millisecond, "server failed to start before timeout")
+}
+
+func defaultOptions(t *testing.T) component.Options {
+	return component.Options{
+		ID:         "loki.source.otlp.test",
+		Logger:     util.TestAlloyLogger(t),
+		Registerer: prometheus.NewRegistry(),
+	}
+}
+
+func testArgsWith(t *testing.T, mutator func(arguments *Arguments)) Arguments {
+	a := testArgs(t)
+	mutator(&a)
+	return a
+}
+
+func testArgs(t *testing.T) Arguments {
+	server := defaultServerConfig()
+	server.HTTP.ListenAddress = "127.0.0.1"
+	server.HTTP.ListenPort = getFreePort(t)
+	server.GRPC.ListenAddress = "127.0.0.1"
+	server.GRPC.ListenPort = getFreePort(t)
+
+	return Arguments{
+		Server:                  server,
+		ForwardTo:               []loki.LogsReceiver{loki.NewLogsReceiver()},
+		Labels:                  map[string]string{"foo": "bar"},
+		ResourceAttributeLabels: []string{"service.name"},
+		LogAttributeLabels:      []string{"level", "tag"},
+		RelabelRules: relabel.Rules{
+			{
+				SourceLabels: []string{"tag"},
+				Regex:        relabel.Regexp{Regexp: regexp.MustCompile("ignore")},
+				Action:       relabel.Drop,
+			},
+		},
+		UseIncomingTimestamp: true,
+		MaxRequestBodySize:   otlppush.DefaultMaxRequestBodySize,
+	}
+}
+
+func getFreePort(t *testing.T) int {
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+	return port
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/alecthomas/units"
+
 	"github.com/grafana/alloy/internal/alloyseed"
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/common/loki"
@@ -40,23 +42,26 @@ type Arguments struct {
 // WalArguments holds the settings for configuring the Write-Ahead Log (WAL) used
 // by the underlying remote write client.
 type WalArguments struct {
-	Enabled          bool          `alloy:"enabled,attr,optional"`
-	MaxSegmentAge    time.Duration `alloy:"max_segment_age,attr,optional"`
-	MinReadFrequency time.Duration `alloy:"min_read_frequency,attr,optional"`
-	MaxReadFrequency time.Duration `alloy:"max_read_frequency,attr,optional"`
-	DrainTimeout     time.Duration `alloy:"drain_timeout,attr,optional"`
+	Enabled          bool             `alloy:"enabled,attr,optional"`
+	MaxSegmentAge    time.Duration    `alloy:"max_segment_age,attr,optional"`
+	MaxSize          units.Base2Bytes `alloy:"max_size,attr,optional"`
+	MinReadFrequency time.Duration    `alloy:"min_read_frequency,attr,optional"`
+	MaxReadFrequency time.Duration    `alloy:"max_read_frequency,attr,optional"`
+	DrainTimeout     time.Duration    `alloy:"drain_timeout,attr,optional"`
 }
 
 func (wa *WalArguments) Validate() error {
 	if wa.MinReadFrequency >= wa.MaxReadFrequency {
 		return fmt.Errorf("WAL min read frequency should be lower than max read frequency")
 	}
+	if wa.MaxSize < 0 {
+		return fmt.Errorf("WAL max size must be greater than or equal to 0")
+	}
 	return nil
 }
 
 func (wa *WalArguments) SetToDefault() {
-	// todo(thepalbi): Once we are in a good state: replay implemented, and a better cleanup mechanism
-	// make WAL enabled the default
+	// todo(thepalbi): Once we are in a good state with a better cleanup mechanism, make WAL enabled the default
 	*wa = WalArguments{
 		Enabled:          false,
 		MaxSegmentAge:    wal.DefaultMaxSegmentAge,
@@ -175,10 +180,14 @@ func (c *Component) Update(args component.Arguments) error {
 	walCfg := wal.Config{
 		Enabled:       newArgs.WAL.Enabled,
 		MaxSegmentAge: newArgs.WAL.MaxSegmentAge,
+		MaxSize:       int64(newArgs.WAL.MaxSize),
 		WatchConfig: wal.WatchConfig{
 			MinReadFrequency: newArgs.WAL.MinReadFrequency,
 			MaxReadFrequency: newArgs.WAL.MaxReadFrequency,
 			DrainTimeout:     newArgs.WAL.DrainTimeout,
+			// entries are only sent once they are written to the WAL, so any entry left in it on startup might
+			// not have been delivered yet
+			ReplayUnmarked: true,
 		},
 	}
 
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/grafana/loki/pkg/push"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	loki_util "github.com/grafana/loki/v3/pkg/util"
@@ -109,6 +110,20 @@ func TestUnmarshallWalAttrributes(t *testing.T) {
 				DrainTimeout:     time.Minute * 5,
 			},
 		},
+		"wal enabled with max size": {
+			raw: `
+			enabled = true
+			max_size = "1GiB"
+			`,
+			expected: WalArguments{
+				Enabled:          true,
+				MaxSegmentAge:    wal.DefaultMaxSegmentAge,
+				MaxSize:          units.GiB,
+				MinReadFrequency: wal.DefaultWatchConfig.MinReadFrequency,
+				MaxReadFrequency: wal.DefaultWatchConfig.MaxReadFrequency,
+				DrainTimeout:     wal.DefaultWatchConfig.DrainTimeout,
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			cfg := WalArguments{}
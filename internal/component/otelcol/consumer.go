@@ -1,9 +1,17 @@
 package otelcol
 
 import (
+	"reflect"
+
 	otelconsumer "go.opentelemetry.io/collector/consumer"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
+func init() {
+	argument.RegisterCapsuleType("otelcol.Consumer", reflect.TypeFor[Consumer]())
+}
+
 // Consumer is a combined OpenTelemetry Collector consumer which can consume
 // any telemetry signal.
 type Consumer interface {
@@ -0,0 +1,330 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/prometheus"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+const name = "prometheus.aggregate"
+
+func init() {
+	component.Register(component.Registration{
+		Name:      name,
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Supported aggregation operations.
+const (
+	OperationSum   = "sum"
+	OperationCount = "count"
+	OperationMin   = "min"
+	OperationMax   = "max"
+	OperationAvg   = "avg"
+)
+
+// Arguments holds values which are used to configure the prometheus.aggregate
+// component.
+type Arguments struct {
+	// Where the aggregated metrics should be forwarded to.
+	ForwardTo []storage.Appendable `alloy:"forward_to,attr"`
+
+	// How often the aggregated series are forwarded.
+	Interval time.Duration `alloy:"interval,attr,optional"`
+
+	// The labels to keep or to remove from the aggregated series. At most one
+	// of them can be set.
+	By      []string `alloy:"by,attr,optional"`
+	Without []string `alloy:"without,attr,optional"`
+
+	// The aggregation operation.
+	Operation string `alloy:"operation,attr,optional"`
+
+	// How long the latest sample of an input series is aggregated for when
+	// the series doesn't send new samples.
+	StaleTimeout time.Duration `alloy:"stale_timeout,attr,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (arg *Arguments) SetToDefault() {
+	*arg = Arguments{
+		Interval:     time.Minute,
+		Operation:    OperationSum,
+		StaleTimeout: 5 * time.Minute,
+	}
+}
+
+// Validate implements syntax.Validator.
+func (arg *Arguments) Validate() error {
+	if arg.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	if arg.StaleTimeout <= 0 {
+		return fmt.Errorf("stale_timeout must be greater than 0")
+	}
+	if len(arg.By) > 0 && len(arg.Without) > 0 {
+		return fmt.Errorf("only one of by and without can be set")
+	}
+	if slices.Contains(arg.Without, labels.MetricName) {
+		return fmt.Errorf("the %s label can't be removed from aggregated series", labels.MetricName)
+	}
+	switch arg.Operation {
+	case OperationSum, OperationCount, OperationMin, OperationMax, OperationAvg:
+	default:
+		return fmt.Errorf("unknown operation %q, must be one of %q, %q, %q, %q or %q", arg.Operation, OperationSum, OperationCount, OperationMin, OperationMax, OperationAvg)
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the prometheus.aggregate component.
+type Exports struct {
+	Receiver storage.Appendable `alloy:"receiver,attr"`
+}
+
+// Component implements the prometheus.aggregate component.
+type Component struct {
+	opts     component.Options
+	ls       labelstore.LabelStore
+	fanout   *prometheus.Fanout
+	receiver *prometheus.Interceptor
+	exited   atomic.Bool
+
+	samplesProcessed prometheus_client.Counter
+	samplesDropped   prometheus_client.Counter
+	samplesWritten   prometheus_client.Counter
+	seriesCount      prometheus_client.Gauge
+
+	debugDataPublisher livedebugging.DebugDataPublisher
+
+	mut         sync.RWMutex
+	args        Arguments
+	agg         *aggregator
+	argsUpdated chan struct{}
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
+)
+
+// New creates a new prometheus.aggregate component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := o.GetServiceData(labelstore.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{
+		opts:               o,
+		ls:                 data.(labelstore.LabelStore),
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+		argsUpdated:        make(chan struct{}, 1),
+	}
+	c.samplesProcessed = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "alloy_prometheus_aggregate_samples_processed",
+		Help: "Total number of samples processed",
+	})
+	c.samplesDropped = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "alloy_prometheus_aggregate_samples_dropped",
+		Help: "Total number of samples dropped because they can't be aggregated with the configured operation",
+	})
+	c.samplesWritten = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "alloy_prometheus_aggregate_samples_written",
+		Help: "Total number of aggregated samples written",
+	})
+	c.seriesCount = prometheus_client.NewGauge(prometheus_client.GaugeOpts{
+		Name: "alloy_prometheus_aggregate_series",
+		Help: "Number of aggregated series",
+	})
+
+	for _, metric := range []prometheus_client.Collector{c.samplesProcessed, c.samplesDropped, c.samplesWritten, c.seriesCount} {
+		err = o.Registerer.Register(metric)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer, c.ls)
+	c.receiver = prometheus.NewInterceptor(
+		c.fanout,
+		c.ls,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			c.mut.RLock()
+			defer c.mut.RUnlock()
+
+			c.samplesProcessed.Inc()
+			out := c.agg.appendFloat(ref, l, t, v)
+			c.publishDebugData(l, out)
+			return ref, nil
+		}),
+		prometheus.WithHistogramHook(func(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			c.mut.RLock()
+			defer c.mut.RUnlock()
+
+			c.samplesProcessed.Inc()
+			if c.args.Operation == OperationMin || c.args.Operation == OperationMax {
+				c.samplesDropped.Inc()
+				return ref, nil
+			}
+			if fh == nil {
+				fh = h.ToFloat(nil)
+			}
+			out := c.agg.appendHistogram(ref, l, t, fh)
+			c.publishDebugData(l, out)
+			return ref, nil
+		}),
+		prometheus.WithMetadataHook(func(_ storage.SeriesRef, l labels.Labels, m metadata.Metadata, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			c.mut.RLock()
+			out := c.agg.outputLabels(l)
+			c.mut.RUnlock()
+			return next.UpdateMetadata(0, out, m)
+		}),
+		// Exemplars and created timestamps can't be aggregated.
+		prometheus.WithExemplarHook(func(_ storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar, _ storage.Appender) (storage.SeriesRef, error) {
+			return 0, nil
+		}),
+		prometheus.WithCTZeroSampleHook(func(_ storage.SeriesRef, _ labels.Labels, _, _ int64, _ storage.Appender) (storage.SeriesRef, error) {
+			return 0, nil
+		}),
+	)
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err = c.Update(args); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.exited.Store(true)
+
+	c.mut.RLock()
+	ticker := time.NewTicker(c.args.Interval)
+	c.mut.RUnlock()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.argsUpdated:
+			c.mut.RLock()
+			ticker.Reset(c.args.Interval)
+			c.mut.RUnlock()
+		case now := <-ticker.C:
+			if err := c.flush(ctx, now); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to forward aggregated series", "err", err)
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+	if c.agg == nil {
+		c.agg = newAggregator(newArgs)
+	} else {
+		c.agg.update(newArgs)
+	}
+	if c.args.Interval != newArgs.Interval {
+		select {
+		case c.argsUpdated <- struct{}{}:
+		default:
+		}
+	}
+	c.args = newArgs
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	return nil
+}
+
+// flush forwards the aggregated series of the current window and starts a
+// new one.
+func (c *Component) flush(ctx context.Context, now time.Time) error {
+	c.mut.Lock()
+	out := c.agg.flush(now.UnixMilli())
+	c.seriesCount.Set(float64(c.agg.seriesCount()))
+	c.mut.Unlock()
+
+	app := c.fanout.Appender(ctx)
+	for _, s := range out {
+		var err error
+		if s.h != nil {
+			_, err = app.AppendHistogram(0, s.labels, s.t, nil, s.h)
+		} else {
+			_, err = app.Append(0, s.labels, s.t, s.v)
+		}
+		if err != nil {
+			_ = app.Rollback()
+			return err
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return err
+	}
+	c.samplesWritten.Add(float64(len(out)))
+	return nil
+}
+
+func (c *Component) publishDebugData(in, out labels.Labels) {
+	componentID := livedebugging.ComponentID(c.opts.ID)
+	c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		componentID,
+		livedebugging.PrometheusMetric,
+		1,
+		func() string {
+			return fmt.Sprintf("%s => %s", in.String(), out.String())
+		},
+	))
+}
+
+func (c *Component) LiveDebugging() {}
@@ -0,0 +1,420 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestAggregate(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		expected map[string]float64
+	}{
+		"sum by": {
+			config: `by = ["job"]`,
+			expected: map[string]float64{
+				`{__name__="requests_total", job="api"}`: 6,
+				`{__name__="requests_total", job="web"}`: 4,
+			},
+		},
+		"sum without": {
+			config: `without = ["pod"]`,
+			expected: map[string]float64{
+				`{__name__="requests_total", job="api", status="200"}`: 4,
+				`{__name__="requests_total", job="api", status="500"}`: 2,
+				`{__name__="requests_total", job="web", status="200"}`: 4,
+			},
+		},
+		"count": {
+			config: `
+				operation = "count"
+				by        = ["job"]`,
+			expected: map[string]float64{
+				`{__name__="requests_total", job="api"}`: 3,
+				`{__name__="requests_total", job="web"}`: 1,
+			},
+		},
+		"min": {
+			config: `
+				operation = "min"
+				by        = ["job"]`,
+			expected: map[string]float64{
+				`{__name__="requests_total", job="api"}`: 1,
+				`{__name__="requests_total", job="web"}`: 4,
+			},
+		},
+		"max": {
+			config: `
+				operation = "max"
+				by        = ["job"]`,
+			expected: map[string]float64{
+				`{__name__="requests_total", job="api"}`: 3,
+				`{__name__="requests_total", job="web"}`: 4,
+			},
+		},
+		"avg": {
+			config: `
+				operation = "avg"
+				by        = ["job"]`,
+			expected: map[string]float64{
+				`{__name__="requests_total", job="api"}`: 2,
+				`{__name__="requests_total", job="web"}`: 4,
+			},
+		},
+		"all series": {
+			config: ``,
+			expected: map[string]float64{
+				`{__name__="requests_total"}`: 10,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, app := newTestComponent(t, tc.config)
+
+			in := c.receiver.Appender(t.Context())
+			// Only the latest sample of each input series is aggregated.
+			appendFloat(t, in, 1000, 5, "job", "api", "pod", "a", "status", "200")
+			appendFloat(t, in, 2000, 1, "job", "api", "pod", "a", "status", "200")
+			appendFloat(t, in, 1000, 3, "job", "api", "pod", "b", "status", "200")
+			appendFloat(t, in, 1000, 2, "job", "api", "pod", "a", "status", "500")
+			appendFloat(t, in, 1000, 4, "job", "web", "pod", "c", "status", "200")
+			require.NoError(t, in.Commit())
+
+			require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+			require.Equal(t, tc.expected, app.floatValues(60_000))
+			require.Equal(t, 5.0, testutil.ToFloat64(c.samplesProcessed))
+			require.Equal(t, float64(len(tc.expected)), testutil.ToFloat64(c.samplesWritten))
+		})
+	}
+}
+
+func TestAggregate_Staleness(t *testing.T) {
+	c, app := newTestComponent(t, `
+		by            = ["job"]
+		stale_timeout = "1m"`)
+
+	in := c.receiver.Appender(t.Context())
+	appendFloat(t, in, 1000, 1, "job", "api", "pod", "a")
+	appendFloat(t, in, 1000, 2, "job", "api", "pod", "b")
+	appendFloat(t, in, 1000, 3, "job", "web", "pod", "c")
+	require.NoError(t, in.Commit())
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+
+	// Pod b ended, and the web job didn't send samples for longer than the
+	// stale timeout.
+	in = c.receiver.Appender(t.Context())
+	appendFloat(t, in, 61_000, 5, "job", "api", "pod", "a")
+	appendFloat(t, in, 61_000, math.Float64frombits(value.StaleNaN), "job", "api", "pod", "b")
+	require.NoError(t, in.Commit())
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(120_000)))
+
+	values := app.floatValues(120_000)
+	require.Len(t, values, 2)
+	require.Equal(t, 5.0, values[`{__name__="requests_total", job="api"}`])
+	require.True(t, value.IsStaleNaN(values[`{__name__="requests_total", job="web"}`]))
+	require.Equal(t, 1.0, testutil.ToFloat64(c.seriesCount))
+
+	// The stale series isn't forwarded anymore, and pod a times out.
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(180_000)))
+	values = app.floatValues(180_000)
+	require.Len(t, values, 1)
+	require.True(t, value.IsStaleNaN(values[`{__name__="requests_total", job="api"}`]))
+}
+
+func TestAggregate_SeriesMissingWindow(t *testing.T) {
+	c, app := newTestComponent(t, `by = ["job"]`)
+
+	in := c.receiver.Appender(t.Context())
+	appendFloat(t, in, 1000, 10, "job", "api", "pod", "a")
+	appendFloat(t, in, 1000, 20, "job", "api", "pod", "b")
+	require.NoError(t, in.Commit())
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+	require.Equal(t, 30.0, app.floatValues(60_000)[`{__name__="requests_total", job="api"}`])
+
+	// Pod b misses a window, its latest value is still part of the sum.
+	in = c.receiver.Appender(t.Context())
+	appendFloat(t, in, 61_000, 11, "job", "api", "pod", "a")
+	require.NoError(t, in.Commit())
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(120_000)))
+	require.Equal(t, 31.0, app.floatValues(120_000)[`{__name__="requests_total", job="api"}`])
+
+	in = c.receiver.Appender(t.Context())
+	appendFloat(t, in, 121_000, 12, "job", "api", "pod", "a")
+	appendFloat(t, in, 121_000, 25, "job", "api", "pod", "b")
+	require.NoError(t, in.Commit())
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(180_000)))
+	require.Equal(t, 37.0, app.floatValues(180_000)[`{__name__="requests_total", job="api"}`])
+}
+
+func TestAggregate_ClassicHistogram(t *testing.T) {
+	c, app := newTestComponent(t, `by = ["job"]`)
+
+	in := c.receiver.Appender(t.Context())
+	for _, pod := range []string{"a", "b"} {
+		appendNamedFloat(t, in, "latency_bucket", 1000, 1, "job", "api", "pod", pod, "le", "0.1")
+		appendNamedFloat(t, in, "latency_bucket", 1000, 3, "job", "api", "pod", pod, "le", "+Inf")
+	}
+	require.NoError(t, in.Commit())
+	require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+
+	require.Equal(t, map[string]float64{
+		`{__name__="latency_bucket", job="api", le="0.1"}`:  2,
+		`{__name__="latency_bucket", job="api", le="+Inf"}`: 6,
+	}, app.floatValues(60_000))
+}
+
+func TestAggregate_NativeHistogram(t *testing.T) {
+	h := &histogram.Histogram{
+		Count:           4,
+		Sum:             10,
+		Schema:          0,
+		ZeroThreshold:   0.001,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []int64{1, 2},
+	}
+
+	t.Run("sum", func(t *testing.T) {
+		c, app := newTestComponent(t, `by = ["job"]`)
+
+		in := c.receiver.Appender(t.Context())
+		_, err := in.AppendHistogram(0, labels.FromStrings("__name__", "latency", "job", "api", "pod", "a"), 1000, h, nil)
+		require.NoError(t, err)
+		_, err = in.AppendHistogram(0, labels.FromStrings("__name__", "latency", "job", "api", "pod", "b"), 1000, nil, h.ToFloat(nil))
+		require.NoError(t, err)
+		require.NoError(t, in.Commit())
+		require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+
+		got := app.histogram(`{__name__="latency", job="api"}`)
+		require.NotNil(t, got)
+		require.Equal(t, 8.0, got.Count)
+		require.Equal(t, 20.0, got.Sum)
+		require.Equal(t, []float64{2, 6}, got.PositiveBuckets)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		c, app := newTestComponent(t, `
+			operation = "count"
+			by        = ["job"]`)
+
+		in := c.receiver.Appender(t.Context())
+		_, err := in.AppendHistogram(0, labels.FromStrings("__name__", "latency", "job", "api", "pod", "a"), 1000, h, nil)
+		require.NoError(t, err)
+		require.NoError(t, in.Commit())
+		require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+
+		require.Equal(t, map[string]float64{`{__name__="latency", job="api"}`: 1}, app.floatValues(60_000))
+	})
+
+	t.Run("max", func(t *testing.T) {
+		c, app := newTestComponent(t, `
+			operation = "max"
+			by        = ["job"]`)
+
+		in := c.receiver.Appender(t.Context())
+		_, err := in.AppendHistogram(0, labels.FromStrings("__name__", "latency", "job", "api", "pod", "a"), 1000, h, nil)
+		require.NoError(t, err)
+		require.NoError(t, in.Commit())
+		require.NoError(t, c.flush(t.Context(), time.UnixMilli(60_000)))
+
+		require.Empty(t, app.floatValues(60_000))
+		require.Equal(t, 1.0, testutil.ToFloat64(c.samplesDropped))
+	})
+}
+
+func TestAggregate_Run(t *testing.T) {
+	c, app := newTestComponent(t, `
+		by       = ["job"]
+		interval = "10ms"`)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go c.Run(ctx)
+
+	in := c.receiver.Appender(t.Context())
+	appendFloat(t, in, time.Now().UnixMilli(), 1, "job", "api", "pod", "a")
+	require.NoError(t, in.Commit())
+
+	require.Eventually(t, func() bool {
+		return app.count() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"valid": {
+			config: `
+				by        = ["job"]
+				operation = "avg"
+				interval  = "30s"`,
+		},
+		"by and without": {
+			config: `
+				by      = ["job"]
+				without = ["pod"]`,
+			err: "only one of by and without can be set",
+		},
+		"without metric name": {
+			config: `without = ["__name__"]`,
+			err:    "the __name__ label can't be removed from aggregated series",
+		},
+		"unknown operation": {
+			config: `operation = "median"`,
+			err:    `unknown operation "median"`,
+		},
+		"invalid interval": {
+			config: `interval = "0s"`,
+			err:    "interval must be greater than 0",
+		},
+		"invalid stale timeout": {
+			config: `stale_timeout = "0s"`,
+			err:    "stale_timeout must be greater than 0",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte("forward_to = []\n"+tc.config), &args)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func newTestComponent(t *testing.T, config string) (*Component, *testAppender) {
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte("forward_to = []\n"+config), &args))
+
+	app := &testAppender{}
+	args.ForwardTo = []storage.Appendable{testAppendable{app}}
+
+	c, err := New(component.Options{
+		ID:             "1",
+		Logger:         util.TestAlloyLogger(t),
+		OnStateChange:  func(e component.Exports) {},
+		Registerer:     prom.NewRegistry(),
+		GetServiceData: getServiceData,
+	}, args)
+	require.NoError(t, err)
+	return c, app
+}
+
+func appendFloat(t *testing.T, app storage.Appender, ts int64, v float64, lbls ...string) {
+	appendNamedFloat(t, app, "requests_total", ts, v, lbls...)
+}
+
+func appendNamedFloat(t *testing.T, app storage.Appender, name string, ts int64, v float64, lbls ...string) {
+	_, err := app.Append(0, labels.FromStrings(append([]string{"__name__", name}, lbls...)...), ts, v)
+	require.NoError(t, err)
+}
+
+// testAppender records the samples it receives.
+type testAppender struct {
+	storage.Appender
+
+	mut        sync.Mutex
+	floats     []sample
+	histograms map[string]*histogram.FloatHistogram
+}
+
+type sample struct {
+	labels string
+	t      int64
+	v      float64
+}
+
+// testAppendable returns its testAppender.
+type testAppendable struct {
+	*testAppender
+}
+
+func (a testAppendable) Appender(_ context.Context) storage.Appender {
+	return a.testAppender
+}
+
+func (a *testAppender) Append(_ storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	a.floats = append(a.floats, sample{labels: l.String(), t: t, v: v})
+	return 0, nil
+}
+
+func (a *testAppender) AppendHistogram(_ storage.SeriesRef, l labels.Labels, _ int64, _ *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	if a.histograms == nil {
+		a.histograms = make(map[string]*histogram.FloatHistogram)
+	}
+	a.histograms[l.String()] = fh
+	return 0, nil
+}
+
+func (a *testAppender) Commit() error {
+	return nil
+}
+
+func (a *testAppender) Rollback() error {
+	return nil
+}
+
+// floatValues returns the float samples received with the timestamp t.
+func (a *testAppender) floatValues(t int64) map[string]float64 {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	res := make(map[string]float64)
+	for _, s := range a.floats {
+		if s.t == t {
+			res[s.labels] = s.v
+		}
+	}
+	return res
+}
+
+func (a *testAppender) histogram(lbls string) *histogram.FloatHistogram {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return a.histograms[lbls]
+}
+
+func (a *testAppender) count() int {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return len(a.floats)
+}
+
+func getServiceData(name string) (interface{}, error) {
+	switch name {
+	case labelstore.ServiceName:
+		return labelstore.New(nil, prom.DefaultRegisterer), nil
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
@@ -0,0 +1,278 @@
+package aggregate
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// aggregator aggregates the samples it receives into one sample per
+// aggregated series every window. For each aggregated series, the latest
+// sample of every input series is kept, and the aggregation operation is
+// applied to these samples when the window is flushed.
+//
+// Samples are kept across windows, so that an input series which misses a
+// window is still part of the aggregation and sums of counters don't drop.
+// An input series is only removed once it sends a staleness marker, or when
+// its latest sample is older than the stale timeout.
+//
+// Input series are identified by their global ref ID from the labelstore
+// service.
+type aggregator struct {
+	by           []string
+	without      []string
+	operation    string
+	staleTimeout int64 // In milliseconds.
+
+	mut    sync.Mutex
+	series map[uint64]*aggregatedSeries
+}
+
+// aggregatedSeries is an output series and the latest samples of its input
+// series.
+type aggregatedSeries struct {
+	labels     labels.Labels
+	floats     map[storage.SeriesRef]floatSample
+	histograms map[storage.SeriesRef]histogramSample
+
+	// forwarded and forwardedHistogram report whether a sample was forwarded
+	// for the series in the previous window and whether it was a histogram,
+	// so that a staleness marker can be forwarded once the series disappears.
+	forwarded          bool
+	forwardedHistogram bool
+}
+
+type floatSample struct {
+	t int64
+	v float64
+}
+
+type histogramSample struct {
+	t int64
+	h *histogram.FloatHistogram
+}
+
+// outputSample is an aggregated sample to forward. h is nil for float
+// samples.
+type outputSample struct {
+	labels labels.Labels
+	t      int64
+	v      float64
+	h      *histogram.FloatHistogram
+}
+
+func newAggregator(args Arguments) *aggregator {
+	a := &aggregator{series: make(map[uint64]*aggregatedSeries)}
+	a.update(args)
+	return a
+}
+
+// update changes the aggregation. If the labels of the aggregated series
+// changed, the samples received so far are discarded, so that the series
+// aggregated with the previous configuration are marked as stale at the next
+// flush.
+func (a *aggregator) update(args Arguments) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	a.operation = args.Operation
+	a.staleTimeout = args.StaleTimeout.Milliseconds()
+
+	var by, without []string
+	if len(args.Without) > 0 {
+		without = args.Without
+	} else {
+		// The metric name, and the bucket label of classic histograms, are
+		// always kept.
+		by = append([]string{labels.MetricName, labels.BucketLabel}, args.By...)
+	}
+	if slices.Equal(a.by, by) && slices.Equal(a.without, without) {
+		return
+	}
+	a.by, a.without = by, without
+
+	for _, s := range a.series {
+		clear(s.floats)
+		clear(s.histograms)
+	}
+}
+
+// outputLabels returns the labels of the aggregated series the input series
+// belongs to.
+func (a *aggregator) outputLabels(l labels.Labels) labels.Labels {
+	b := labels.NewBuilder(l)
+	if a.without != nil {
+		b.Del(a.without...)
+	} else {
+		b.Keep(a.by...)
+	}
+	return b.Labels()
+}
+
+func (a *aggregator) getSeries(out labels.Labels) *aggregatedSeries {
+	key := out.Hash()
+	s, ok := a.series[key]
+	if !ok {
+		s = &aggregatedSeries{
+			labels:     out,
+			floats:     make(map[storage.SeriesRef]floatSample),
+			histograms: make(map[storage.SeriesRef]histogramSample),
+		}
+		a.series[key] = s
+	}
+	return s
+}
+
+// appendFloat records a float sample of an input series and returns the
+// labels of the aggregated series.
+func (a *aggregator) appendFloat(ref storage.SeriesRef, l labels.Labels, t int64, v float64) labels.Labels {
+	out := a.outputLabels(l)
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	s := a.getSeries(out)
+	if value.IsStaleNaN(v) {
+		// The input series ended, so it's no longer part of the aggregation.
+		delete(s.floats, ref)
+		return out
+	}
+	if prev, ok := s.floats[ref]; !ok || t >= prev.t {
+		s.floats[ref] = floatSample{t: t, v: v}
+	}
+	return out
+}
+
+// appendHistogram records a native histogram sample of an input series and
+// returns the labels of the aggregated series.
+func (a *aggregator) appendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.FloatHistogram) labels.Labels {
+	out := a.outputLabels(l)
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	s := a.getSeries(out)
+	if value.IsStaleNaN(h.Sum) {
+		delete(s.histograms, ref)
+		return out
+	}
+	if prev, ok := s.histograms[ref]; !ok || t >= prev.t {
+		s.histograms[ref] = histogramSample{t: t, h: h}
+	}
+	return out
+}
+
+// flush returns the aggregated samples of the current window with the
+// timestamp t, and starts a new window. Input samples older than the stale
+// timeout are removed first. Series which have no input samples left are
+// returned with a staleness marker if they were forwarded in the previous
+// window, and removed.
+func (a *aggregator) flush(t int64) []outputSample {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	out := make([]outputSample, 0, len(a.series))
+	for key, s := range a.series {
+		s.evict(t - a.staleTimeout)
+
+		switch {
+		case len(s.histograms) > 0 && a.operation != OperationCount:
+			h, err := aggregateHistograms(a.operation, s.histograms)
+			if err != nil {
+				// Histograms with incompatible schemas can't be merged, so
+				// nothing is forwarded for this window.
+				break
+			}
+			out = append(out, outputSample{labels: s.labels, t: t, h: h})
+			s.forwarded, s.forwardedHistogram = true, true
+		case len(s.floats) > 0 || len(s.histograms) > 0:
+			out = append(out, outputSample{labels: s.labels, t: t, v: aggregateFloats(a.operation, s.floats, len(s.histograms))})
+			s.forwarded, s.forwardedHistogram = true, false
+		case s.forwarded:
+			stale := outputSample{labels: s.labels, t: t, v: math.Float64frombits(value.StaleNaN)}
+			if s.forwardedHistogram {
+				stale.h = &histogram.FloatHistogram{Sum: math.Float64frombits(value.StaleNaN)}
+			}
+			out = append(out, stale)
+			delete(a.series, key)
+		default:
+			delete(a.series, key)
+		}
+	}
+	return out
+}
+
+// evict removes the input samples older than minT.
+func (s *aggregatedSeries) evict(minT int64) {
+	maps.DeleteFunc(s.floats, func(_ storage.SeriesRef, f floatSample) bool {
+		return f.t < minT
+	})
+	maps.DeleteFunc(s.histograms, func(_ storage.SeriesRef, h histogramSample) bool {
+		return h.t < minT
+	})
+}
+
+// seriesCount returns the number of aggregated series.
+func (a *aggregator) seriesCount() int {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return len(a.series)
+}
+
+// aggregateFloats applies the operation to the samples. histogramCount is
+// the number of histogram samples, only used when counting series.
+func aggregateFloats(operation string, samples map[storage.SeriesRef]floatSample, histogramCount int) float64 {
+	if operation == OperationCount {
+		return float64(len(samples) + histogramCount)
+	}
+
+	var (
+		res   float64
+		first = true
+	)
+	for _, s := range samples {
+		switch {
+		case first:
+			res = s.v
+			first = false
+		case operation == OperationMin:
+			res = math.Min(res, s.v)
+		case operation == OperationMax:
+			res = math.Max(res, s.v)
+		default:
+			res += s.v
+		}
+	}
+	if operation == OperationAvg {
+		res /= float64(len(samples))
+	}
+	return res
+}
+
+// aggregateHistograms merges the native histograms, for the sum and avg
+// operations.
+func aggregateHistograms(operation string, samples map[storage.SeriesRef]histogramSample) (*histogram.FloatHistogram, error) {
+	var res *histogram.FloatHistogram
+	for _, s := range samples {
+		if res == nil {
+			res = s.h.Copy()
+			continue
+		}
+		if _, err := res.Add(s.h); err != nil {
+			return nil, err
+		}
+	}
+	if operation == OperationAvg {
+		res.Div(float64(len(samples)))
+	}
+	// The counter reset hint of the input histograms doesn't apply to the
+	// merged histogram.
+	res.CounterResetHint = histogram.UnknownCounterReset
+	return res.Compact(0), nil
+}
@@ -0,0 +1,437 @@
+package cardinalitylimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/prometheus"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+const name = "prometheus.cardinality_limit"
+
+func init() {
+	component.Register(component.Registration{
+		Name:      name,
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Actions applied to the samples of series which exceed a limit.
+const (
+	OverflowActionDrop     = "drop"
+	OverflowActionOverflow = "overflow"
+)
+
+// OverflowLabel is the label set on the series which samples exceeding a
+// limit are written to with the overflow action.
+const OverflowLabel = "__overflow__"
+
+// Arguments holds values which are used to configure the
+// prometheus.cardinality_limit component.
+type Arguments struct {
+	// Where the metrics should be forwarded to.
+	ForwardTo []storage.Appendable `alloy:"forward_to,attr"`
+
+	// The maximum number of active series, and of active series per metric.
+	// A value of 0 disables the limit.
+	MaxSeries          int           `alloy:"max_series,attr,optional"`
+	MaxSeriesPerMetric int           `alloy:"max_series_per_metric,attr,optional"`
+	MetricLimits       []MetricLimit `alloy:"metric_limit,block,optional"`
+
+	// What to do with the samples of series exceeding a limit.
+	OverflowAction string `alloy:"overflow_action,attr,optional"`
+
+	// How long a series remains active without receiving samples.
+	ActiveSeriesTimeout time.Duration `alloy:"active_series_timeout,attr,optional"`
+
+	// The labels to report the cardinality of, and the size of the report.
+	TrackedLabels []string `alloy:"tracked_labels,attr,optional"`
+	TopN          int      `alloy:"top_n,attr,optional"`
+}
+
+// MetricLimit overrides the maximum number of active series of a metric.
+type MetricLimit struct {
+	Name      string `alloy:"name,attr"`
+	MaxSeries int    `alloy:"max_series,attr"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (arg *Arguments) SetToDefault() {
+	*arg = Arguments{
+		OverflowAction:      OverflowActionDrop,
+		ActiveSeriesTimeout: 10 * time.Minute,
+		TopN:                10,
+	}
+}
+
+// Validate implements syntax.Validator.
+func (arg *Arguments) Validate() error {
+	if arg.MaxSeries < 0 {
+		return fmt.Errorf("max_series must not be negative")
+	}
+	if arg.MaxSeriesPerMetric < 0 {
+		return fmt.Errorf("max_series_per_metric must not be negative")
+	}
+	seen := make(map[string]struct{}, len(arg.MetricLimits))
+	for _, l := range arg.MetricLimits {
+		if l.Name == "" {
+			return fmt.Errorf("metric_limit name must not be empty")
+		}
+		if _, ok := seen[l.Name]; ok {
+			return fmt.Errorf("metric_limit for metric %q is defined more than once", l.Name)
+		}
+		seen[l.Name] = struct{}{}
+		if l.MaxSeries < 0 {
+			return fmt.Errorf("max_series of metric_limit %q must not be negative", l.Name)
+		}
+	}
+	switch arg.OverflowAction {
+	case OverflowActionDrop, OverflowActionOverflow:
+	default:
+		return fmt.Errorf("unknown overflow_action %q, must be one of %q or %q", arg.OverflowAction, OverflowActionDrop, OverflowActionOverflow)
+	}
+	if arg.ActiveSeriesTimeout <= 0 {
+		return fmt.Errorf("active_series_timeout must be greater than 0")
+	}
+	if arg.TopN <= 0 {
+		return fmt.Errorf("top_n must be greater than 0")
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the prometheus.cardinality_limit
+// component.
+type Exports struct {
+	Receiver storage.Appendable `alloy:"receiver,attr"`
+}
+
+// DebugInfo is the cardinality report of the component.
+type DebugInfo struct {
+	ActiveSeries int                 `alloy:"active_series,attr"`
+	MaxSeries    int                 `alloy:"max_series,attr,optional"`
+	Metrics      []MetricCardinality `alloy:"metric,block,optional"`
+	Labels       []LabelCardinality  `alloy:"label,block,optional"`
+}
+
+// MetricCardinality reports the number of active series of a metric.
+type MetricCardinality struct {
+	Name      string `alloy:"name,attr"`
+	Series    int    `alloy:"series,attr"`
+	MaxSeries int    `alloy:"max_series,attr,optional"`
+}
+
+// LabelCardinality reports the number of values of a tracked label, and the
+// values with the most active series.
+type LabelCardinality struct {
+	Name      string             `alloy:"name,attr"`
+	Values    int                `alloy:"values,attr"`
+	TopValues []ValueCardinality `alloy:"value,block,optional"`
+}
+
+// ValueCardinality reports the number of active series of a label value.
+type ValueCardinality struct {
+	Value  string `alloy:"value,attr"`
+	Series int    `alloy:"series,attr"`
+}
+
+// Component implements the prometheus.cardinality_limit component.
+type Component struct {
+	opts     component.Options
+	ls       labelstore.LabelStore
+	fanout   *prometheus.Fanout
+	receiver *prometheus.Interceptor
+	exited   atomic.Bool
+	now      func() time.Time
+
+	samplesProcessed prometheus_client.Counter
+	samplesLimited   *prometheus_client.CounterVec
+	activeSeries     prometheus_client.Gauge
+
+	debugDataPublisher livedebugging.DebugDataPublisher
+
+	mut     sync.RWMutex
+	args    Arguments
+	tracker *tracker
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+	_ component.LiveDebugging  = (*Component)(nil)
+)
+
+// New creates a new prometheus.cardinality_limit component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := o.GetServiceData(labelstore.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{
+		opts:               o,
+		ls:                 data.(labelstore.LabelStore),
+		now:                time.Now,
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+	}
+	c.samplesProcessed = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "alloy_prometheus_cardinality_limit_samples_processed",
+		Help: "Total number of samples processed",
+	})
+	c.samplesLimited = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "alloy_prometheus_cardinality_limit_samples_limited",
+		Help: "Total number of samples of series exceeding a limit, dropped or written to an overflow series",
+	}, []string{"limit"})
+	c.activeSeries = prometheus_client.NewGauge(prometheus_client.GaugeOpts{
+		Name: "alloy_prometheus_cardinality_limit_active_series",
+		Help: "Number of active series",
+	})
+
+	for _, metric := range []prometheus_client.Collector{c.samplesProcessed, c.samplesLimited, c.activeSeries} {
+		err = o.Registerer.Register(metric)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer, c.ls)
+	c.receiver = prometheus.NewInterceptor(
+		overflowAppendable{next: c.fanout},
+		c.ls,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			out, limited := c.admit(l, value.IsStaleNaN(v))
+			if out.IsEmpty() {
+				return 0, nil
+			}
+			if limited {
+				next.(*overflowAppender).appendFloat(out, t, v)
+				return 0, nil
+			}
+			return next.Append(ref, out, t, v)
+		}),
+		prometheus.WithHistogramHook(func(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			stale := (h != nil && value.IsStaleNaN(h.Sum)) || (fh != nil && value.IsStaleNaN(fh.Sum))
+			out, limited := c.admit(l, stale)
+			if out.IsEmpty() {
+				return 0, nil
+			}
+			if limited {
+				next.(*overflowAppender).appendHistogram(out, t, h, fh)
+				return 0, nil
+			}
+			return next.AppendHistogram(ref, out, t, h, fh)
+		}),
+		prometheus.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			out, limited := c.lookup(l)
+			if out.IsEmpty() {
+				return 0, nil
+			}
+			if limited {
+				return next.AppendExemplar(0, out, e)
+			}
+			return next.AppendExemplar(ref, out, e)
+		}),
+		prometheus.WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			out, limited := c.lookup(l)
+			if out.IsEmpty() {
+				return 0, nil
+			}
+			if limited {
+				return next.UpdateMetadata(0, out, m)
+			}
+			return next.UpdateMetadata(ref, out, m)
+		}),
+		prometheus.WithCTZeroSampleHook(func(ref storage.SeriesRef, l labels.Labels, t, ct int64, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			// Created timestamps of overflow series would reset the
+			// aggregated series, so they're only forwarded for active series.
+			out, limited := c.lookup(l)
+			if out.IsEmpty() || limited {
+				return 0, nil
+			}
+			return next.AppendCTZeroSample(ref, out, t, ct)
+		}),
+	)
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err = c.Update(args); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.exited.Store(true)
+
+	ticker := time.NewTicker(c.gcInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.gc()
+			ticker.Reset(c.gcInterval())
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+	if c.tracker == nil {
+		c.tracker = newTracker(newArgs)
+	} else {
+		c.tracker.update(newArgs)
+	}
+	c.args = newArgs
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.tracker.report(c.args.TopN)
+}
+
+// admit returns the labels the sample of the series is forwarded with, and
+// whether the series exceeds a limit. Empty labels are returned if the sample
+// is dropped.
+func (c *Component) admit(l labels.Labels, stale bool) (labels.Labels, bool) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	c.samplesProcessed.Inc()
+
+	if stale {
+		// Staleness markers end active series. The staleness markers of series
+		// exceeding a limit aren't forwarded, as the series were never
+		// forwarded.
+		if c.tracker.remove(l) {
+			c.activeSeries.Set(float64(c.tracker.activeSeriesCount()))
+			return l, false
+		}
+		return labels.EmptyLabels(), true
+	}
+
+	admitted, limit := c.tracker.admit(l, c.now())
+	c.activeSeries.Set(float64(c.tracker.activeSeriesCount()))
+	if admitted {
+		return l, false
+	}
+
+	c.samplesLimited.WithLabelValues(limit).Inc()
+	out := c.overflowLabels(l)
+	c.publishDebugData(l, limit, out)
+	return out, true
+}
+
+// lookup returns the labels the exemplars and metadata of the series are
+// forwarded with, and whether the series exceeds a limit. Empty labels are
+// returned if they are dropped.
+func (c *Component) lookup(l labels.Labels) (labels.Labels, bool) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	if c.tracker.active(l) {
+		return l, false
+	}
+	return c.overflowLabels(l), true
+}
+
+// overflowLabels returns the labels of the overflow series of the metric of
+// l, or empty labels if samples exceeding a limit are dropped.
+func (c *Component) overflowLabels(l labels.Labels) labels.Labels {
+	if c.args.OverflowAction != OverflowActionOverflow {
+		return labels.EmptyLabels()
+	}
+	return labels.FromStrings(labels.MetricName, l.Get(labels.MetricName), OverflowLabel, "true")
+}
+
+func (c *Component) gcInterval() time.Duration {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return min(c.args.ActiveSeriesTimeout, time.Minute)
+}
+
+// gc removes the series which didn't receive samples during the active series
+// timeout.
+func (c *Component) gc() {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	c.tracker.gc(c.now().Add(-c.args.ActiveSeriesTimeout))
+	c.activeSeries.Set(float64(c.tracker.activeSeriesCount()))
+}
+
+func (c *Component) publishDebugData(in labels.Labels, limit string, out labels.Labels) {
+	componentID := livedebugging.ComponentID(c.opts.ID)
+	c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		componentID,
+		livedebugging.PrometheusMetric,
+		1,
+		func() string {
+			if out.IsEmpty() {
+				return fmt.Sprintf("%s => dropped (%s limit)", in.String(), limit)
+			}
+			return fmt.Sprintf("%s => %s (%s limit)", in.String(), out.String(), limit)
+		},
+	))
+}
+
+func (c *Component) LiveDebugging() {}
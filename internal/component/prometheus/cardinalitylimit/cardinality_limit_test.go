@@ -0,0 +1,333 @@
+package cardinalitylimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestCardinalityLimit_Drop(t *testing.T) {
+	c, app := newTestComponent(t, `
+		max_series_per_metric = 2
+		metric_limit {
+			name       = "errors_total"
+			max_series = 1
+		}`)
+
+	in := c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "a")
+	appendNamedFloat(t, in, "requests_total", 1000, 2, "pod", "b")
+	appendNamedFloat(t, in, "requests_total", 1000, 3, "pod", "c")
+	appendNamedFloat(t, in, "errors_total", 1000, 4, "pod", "a")
+	appendNamedFloat(t, in, "errors_total", 1000, 5, "pod", "b")
+	// Active series aren't limited.
+	appendNamedFloat(t, in, "requests_total", 2000, 6, "pod", "a")
+	require.NoError(t, in.Commit())
+
+	require.Equal(t, []string{
+		`{__name__="requests_total", pod="a"} 1000 1`,
+		`{__name__="requests_total", pod="b"} 1000 2`,
+		`{__name__="errors_total", pod="a"} 1000 4`,
+		`{__name__="requests_total", pod="a"} 2000 6`,
+	}, app.samples())
+	require.Equal(t, 3.0, testutil.ToFloat64(c.activeSeries))
+	require.Equal(t, 2.0, testutil.ToFloat64(c.samplesLimited.WithLabelValues(limitMetric)))
+}
+
+func TestCardinalityLimit_Overflow(t *testing.T) {
+	c, app := newTestComponent(t, `
+		max_series      = 2
+		overflow_action = "overflow"`)
+
+	in := c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "a")
+	appendNamedFloat(t, in, "requests_total", 1000, 2, "pod", "b")
+	appendNamedFloat(t, in, "requests_total", 1000, 3, "pod", "c")
+	appendNamedFloat(t, in, "errors_total", 1000, 4, "pod", "a")
+	require.NoError(t, in.Commit())
+
+	require.Equal(t, []string{
+		`{__name__="requests_total", pod="a"} 1000 1`,
+		`{__name__="requests_total", pod="b"} 1000 2`,
+		`{__name__="requests_total", __overflow__="true"} 1000 3`,
+		`{__name__="errors_total", __overflow__="true"} 1000 4`,
+	}, app.samples())
+	require.Equal(t, 2.0, testutil.ToFloat64(c.samplesLimited.WithLabelValues(limitGlobal)))
+}
+
+func TestCardinalityLimit_OverflowSameTimestamp(t *testing.T) {
+	c, app := newTestComponent(t, `
+		max_series_per_metric = 1
+		overflow_action       = "overflow"`)
+
+	// The samples of the series exceeding the limit are summed per
+	// timestamp, so that the overflow series has a single sample per
+	// timestamp.
+	in := c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "a")
+	appendNamedFloat(t, in, "requests_total", 1000, 2, "pod", "b")
+	appendNamedFloat(t, in, "requests_total", 1000, 3, "pod", "c")
+	appendNamedFloat(t, in, "requests_total", 2000, 4, "pod", "b")
+	require.NoError(t, in.Commit())
+
+	require.Equal(t, []string{
+		`{__name__="requests_total", pod="a"} 1000 1`,
+		`{__name__="requests_total", __overflow__="true"} 1000 5`,
+		`{__name__="requests_total", __overflow__="true"} 2000 4`,
+	}, app.samples())
+
+	// Samples of rolled back appenders are discarded.
+	in = c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 3000, 5, "pod", "b")
+	require.NoError(t, in.Rollback())
+	require.Len(t, app.samples(), 3)
+}
+
+func TestCardinalityLimit_Staleness(t *testing.T) {
+	c, app := newTestComponent(t, `max_series = 1`)
+
+	in := c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "a")
+	// The staleness marker of a limited series isn't forwarded.
+	appendNamedFloat(t, in, "requests_total", 1000, staleNaN, "pod", "b")
+	// The staleness marker ends the series, so another series can be admitted.
+	appendNamedFloat(t, in, "requests_total", 2000, staleNaN, "pod", "a")
+	appendNamedFloat(t, in, "requests_total", 2000, 2, "pod", "b")
+	require.NoError(t, in.Commit())
+
+	require.Equal(t, []string{
+		`{__name__="requests_total", pod="a"} 1000 1`,
+		`{__name__="requests_total", pod="a"} 2000 NaN`,
+		`{__name__="requests_total", pod="b"} 2000 2`,
+	}, app.samples())
+	require.Equal(t, 1.0, testutil.ToFloat64(c.activeSeries))
+}
+
+func TestCardinalityLimit_ActiveSeriesTimeout(t *testing.T) {
+	c, app := newTestComponent(t, `
+		max_series            = 1
+		active_series_timeout = "5m"`)
+
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+
+	in := c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "a")
+	require.NoError(t, in.Commit())
+
+	// The series is still active.
+	now = now.Add(4 * time.Minute)
+	c.gc()
+	in = c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 2000, 2, "pod", "b")
+	require.NoError(t, in.Commit())
+
+	// The series didn't receive any sample during the timeout.
+	now = now.Add(6 * time.Minute)
+	c.gc()
+	require.Equal(t, 0.0, testutil.ToFloat64(c.activeSeries))
+	in = c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 3000, 3, "pod", "b")
+	require.NoError(t, in.Commit())
+
+	require.Equal(t, []string{
+		`{__name__="requests_total", pod="a"} 1000 1`,
+		`{__name__="requests_total", pod="b"} 3000 3`,
+	}, app.samples())
+}
+
+func TestCardinalityLimit_DebugInfo(t *testing.T) {
+	c, _ := newTestComponent(t, `
+		max_series_per_metric = 3
+		tracked_labels        = ["pod", "namespace"]
+		top_n                 = 2
+		metric_limit {
+			name       = "errors_total"
+			max_series = 10
+		}`)
+
+	in := c.receiver.Appender(t.Context())
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "a")
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "b")
+	appendNamedFloat(t, in, "requests_total", 1000, 1, "pod", "c")
+	appendNamedFloat(t, in, "errors_total", 1000, 1, "pod", "a")
+	appendNamedFloat(t, in, "errors_total", 1000, 1, "pod", "b")
+	appendNamedFloat(t, in, "up", 1000, 1, "pod", "a")
+	require.NoError(t, in.Commit())
+
+	require.Equal(t, DebugInfo{
+		ActiveSeries: 6,
+		Metrics: []MetricCardinality{
+			{Name: "requests_total", Series: 3, MaxSeries: 3},
+			{Name: "errors_total", Series: 2, MaxSeries: 10},
+		},
+		Labels: []LabelCardinality{
+			{
+				Name:   "pod",
+				Values: 3,
+				TopValues: []ValueCardinality{
+					{Value: "a", Series: 3},
+					{Value: "b", Series: 2},
+				},
+			},
+			{Name: "namespace"},
+		},
+	}, c.DebugInfo())
+
+	// Tracking another label reports the cardinality of the active series.
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(`
+		forward_to     = []
+		tracked_labels = ["__name__"]
+		top_n          = 2`), &args))
+	require.NoError(t, c.Update(args))
+	require.Equal(t, []LabelCardinality{{
+		Name:   "__name__",
+		Values: 3,
+		TopValues: []ValueCardinality{
+			{Value: "requests_total", Series: 3},
+			{Value: "errors_total", Series: 2},
+		},
+	}}, c.DebugInfo().(DebugInfo).Labels)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"defaults": {
+			config: ``,
+		},
+		"negative max_series": {
+			config: `max_series = -1`,
+			err:    "max_series must not be negative",
+		},
+		"duplicate metric_limit": {
+			config: `
+				metric_limit {
+					name       = "up"
+					max_series = 1
+				}
+				metric_limit {
+					name       = "up"
+					max_series = 2
+				}`,
+			err: `metric_limit for metric "up" is defined more than once`,
+		},
+		"unknown overflow_action": {
+			config: `overflow_action = "relabel"`,
+			err:    `unknown overflow_action "relabel"`,
+		},
+		"zero active_series_timeout": {
+			config: `active_series_timeout = "0s"`,
+			err:    "active_series_timeout must be greater than 0",
+		},
+		"zero top_n": {
+			config: `top_n = 0`,
+			err:    "top_n must be greater than 0",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte("forward_to = []\n"+tc.config), &args)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+var staleNaN = math.Float64frombits(value.StaleNaN)
+
+func newTestComponent(t *testing.T, config string) (*Component, *testAppender) {
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte("forward_to = []\n"+config), &args))
+
+	app := &testAppender{}
+	args.ForwardTo = []storage.Appendable{testAppendable{app}}
+
+	c, err := New(component.Options{
+		ID:             "1",
+		Logger:         util.TestAlloyLogger(t),
+		OnStateChange:  func(e component.Exports) {},
+		Registerer:     prom.NewRegistry(),
+		GetServiceData: getServiceData,
+	}, args)
+	require.NoError(t, err)
+	return c, app
+}
+
+func appendNamedFloat(t *testing.T, app storage.Appender, name string, ts int64, v float64, lbls ...string) {
+	_, err := app.Append(0, labels.FromStrings(append([]string{"__name__", name}, lbls...)...), ts, v)
+	require.NoError(t, err)
+}
+
+// testAppender records the samples it receives.
+type testAppender struct {
+	storage.Appender
+
+	mut    sync.Mutex
+	floats []string
+}
+
+// testAppendable returns its testAppender.
+type testAppendable struct {
+	*testAppender
+}
+
+func (a testAppendable) Appender(_ context.Context) storage.Appender {
+	return a.testAppender
+}
+
+func (a *testAppender) Append(_ storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	a.floats = append(a.floats, fmt.Sprintf("%s %d %v", l, t, v))
+	return 0, nil
+}
+
+func (a *testAppender) Commit() error {
+	return nil
+}
+
+func (a *testAppender) Rollback() error {
+	return nil
+}
+
+func (a *testAppender) samples() []string {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return a.floats
+}
+
+func getServiceData(name string) (interface{}, error) {
+	switch name {
+	case labelstore.ServiceName:
+		return labelstore.New(nil, prom.DefaultRegisterer), nil
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
@@ -0,0 +1,116 @@
+package cardinalitylimit
+
+import (
+	"context"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// overflowAppendable wraps the appenders of next so that the samples written
+// to overflow series are summed per series and timestamp, and only appended
+// when the appender is committed. The series exceeding a limit usually have
+// samples with the same timestamps, for example when they are scraped from
+// the same target, which would otherwise be duplicate samples of the overflow
+// series.
+type overflowAppendable struct {
+	next storage.Appendable
+}
+
+var _ storage.Appendable = overflowAppendable{}
+
+func (a overflowAppendable) Appender(ctx context.Context) storage.Appender {
+	return &overflowAppender{
+		Appender: a.next.Appender(ctx),
+		index:    make(map[overflowKey]int),
+	}
+}
+
+type overflowKey struct {
+	hash uint64
+	t    int64
+}
+
+// overflowSample is the sum of the samples of an overflow series at a
+// timestamp. h is nil for float samples.
+type overflowSample struct {
+	labels labels.Labels
+	t      int64
+	v      float64
+	h      *histogram.FloatHistogram
+}
+
+type overflowAppender struct {
+	storage.Appender
+
+	index   map[overflowKey]int
+	samples []overflowSample
+}
+
+// appendFloat adds v to the sample of the overflow series l at t.
+func (a *overflowAppender) appendFloat(l labels.Labels, t int64, v float64) {
+	key := overflowKey{hash: l.Hash(), t: t}
+	i, ok := a.index[key]
+	if !ok {
+		a.index[key] = len(a.samples)
+		a.samples = append(a.samples, overflowSample{labels: l, t: t, v: v})
+		return
+	}
+	// Float samples can't be added to native histograms.
+	if a.samples[i].h == nil {
+		a.samples[i].v += v
+	}
+}
+
+// appendHistogram merges the native histogram into the sample of the
+// overflow series l at t.
+func (a *overflowAppender) appendHistogram(l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) {
+	if fh == nil {
+		fh = h.ToFloat(nil)
+	} else {
+		fh = fh.Copy()
+	}
+
+	key := overflowKey{hash: l.Hash(), t: t}
+	i, ok := a.index[key]
+	if !ok {
+		a.index[key] = len(a.samples)
+		a.samples = append(a.samples, overflowSample{labels: l, t: t, h: fh})
+		return
+	}
+	// Histograms with incompatible schemas, and float samples, can't be
+	// merged, so the histogram is dropped.
+	if a.samples[i].h != nil {
+		if merged, err := a.samples[i].h.Add(fh); err == nil {
+			a.samples[i].h = merged
+		}
+	}
+}
+
+// Commit appends the samples of the overflow series before committing.
+func (a *overflowAppender) Commit() error {
+	for _, s := range a.samples {
+		var err error
+		if s.h != nil {
+			// The counter reset hint of the input histograms doesn't apply
+			// to the merged histogram.
+			s.h.CounterResetHint = histogram.UnknownCounterReset
+			_, err = a.Appender.AppendHistogram(0, s.labels, s.t, nil, s.h.Compact(0))
+		} else {
+			_, err = a.Appender.Append(0, s.labels, s.t, s.v)
+		}
+		if err != nil {
+			_ = a.Appender.Rollback()
+			return err
+		}
+	}
+	return a.Appender.Commit()
+}
+
+// Rollback discards the samples of the overflow series.
+func (a *overflowAppender) Rollback() error {
+	a.samples = nil
+	clear(a.index)
+	return a.Appender.Rollback()
+}
@@ -0,0 +1,234 @@
+package cardinalitylimit
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Limits which can prevent a new series from being admitted.
+const (
+	limitGlobal = "global"
+	limitMetric = "metric"
+)
+
+// tracker tracks the active series, and admits new series as long as the
+// series limits aren't reached. A series is active until it's marked as stale,
+// or until no sample was received for it during the active series timeout.
+type tracker struct {
+	mut sync.Mutex
+
+	maxSeries          int
+	maxSeriesPerMetric int
+	metricLimits       map[string]int
+	trackedLabels      []string
+
+	series map[uint64]*activeSeries
+	// metrics maps metric names to their number of active series.
+	metrics map[string]int
+	// labelValues maps tracked label names to the number of active series of
+	// each of their values.
+	labelValues map[string]map[string]int
+}
+
+type activeSeries struct {
+	labels   labels.Labels
+	metric   string
+	lastSeen time.Time
+}
+
+func newTracker(args Arguments) *tracker {
+	t := &tracker{
+		series:  make(map[uint64]*activeSeries),
+		metrics: make(map[string]int),
+	}
+	t.update(args)
+	return t
+}
+
+// update changes the limits and the tracked labels. The series which are
+// already active aren't affected by lower limits.
+func (t *tracker) update(args Arguments) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.maxSeries = args.MaxSeries
+	t.maxSeriesPerMetric = args.MaxSeriesPerMetric
+	t.metricLimits = make(map[string]int, len(args.MetricLimits))
+	for _, l := range args.MetricLimits {
+		t.metricLimits[l.Name] = l.MaxSeries
+	}
+
+	if t.labelValues != nil && slices.Equal(t.trackedLabels, args.TrackedLabels) {
+		return
+	}
+	t.trackedLabels = slices.Clone(args.TrackedLabels)
+	t.labelValues = make(map[string]map[string]int, len(t.trackedLabels))
+	for _, name := range t.trackedLabels {
+		t.labelValues[name] = make(map[string]int)
+	}
+	for _, s := range t.series {
+		t.trackLabels(s.labels, 1)
+	}
+}
+
+// metricLimit returns the maximum number of series of a metric, or 0 if the
+// number of series isn't limited.
+func (t *tracker) metricLimit(metric string) int {
+	if limit, ok := t.metricLimits[metric]; ok {
+		return limit
+	}
+	return t.maxSeriesPerMetric
+}
+
+// admit reports whether samples of the series can be forwarded. If the series
+// isn't active, it's admitted if none of the limits is reached. Otherwise,
+// the limit which is reached is returned.
+func (t *tracker) admit(l labels.Labels, now time.Time) (bool, string) {
+	h := l.Hash()
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if s, ok := t.series[h]; ok {
+		s.lastSeen = now
+		return true, ""
+	}
+
+	metric := l.Get(labels.MetricName)
+	if t.maxSeries > 0 && len(t.series) >= t.maxSeries {
+		return false, limitGlobal
+	}
+	if limit := t.metricLimit(metric); limit > 0 && t.metrics[metric] >= limit {
+		return false, limitMetric
+	}
+
+	t.series[h] = &activeSeries{labels: l, metric: metric, lastSeen: now}
+	t.metrics[metric]++
+	t.trackLabels(l, 1)
+	return true, ""
+}
+
+// active reports whether the series is active.
+func (t *tracker) active(l labels.Labels) bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	_, ok := t.series[l.Hash()]
+	return ok
+}
+
+// remove removes the series from the active series, and reports whether it
+// was active.
+func (t *tracker) remove(l labels.Labels) bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	h := l.Hash()
+	s, ok := t.series[h]
+	if !ok {
+		return false
+	}
+	t.deleteSeries(h, s)
+	return true
+}
+
+// gc removes the series which didn't receive any sample since cutoff.
+func (t *tracker) gc(cutoff time.Time) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for h, s := range t.series {
+		if s.lastSeen.Before(cutoff) {
+			t.deleteSeries(h, s)
+		}
+	}
+}
+
+func (t *tracker) deleteSeries(h uint64, s *activeSeries) {
+	delete(t.series, h)
+	t.metrics[s.metric]--
+	if t.metrics[s.metric] <= 0 {
+		delete(t.metrics, s.metric)
+	}
+	t.trackLabels(s.labels, -1)
+}
+
+// trackLabels adds delta to the number of series of the values of the tracked
+// labels of l.
+func (t *tracker) trackLabels(l labels.Labels, delta int) {
+	for _, name := range t.trackedLabels {
+		v := l.Get(name)
+		if v == "" {
+			continue
+		}
+		values := t.labelValues[name]
+		values[v] += delta
+		if values[v] <= 0 {
+			delete(values, v)
+		}
+	}
+}
+
+// activeSeriesCount returns the number of active series.
+func (t *tracker) activeSeriesCount() int {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return len(t.series)
+}
+
+// report returns the cardinality report, with the topN metrics and tracked
+// label values with the most active series.
+func (t *tracker) report(topN int) DebugInfo {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	info := DebugInfo{
+		ActiveSeries: len(t.series),
+		MaxSeries:    t.maxSeries,
+	}
+	for metric, series := range t.metrics {
+		info.Metrics = append(info.Metrics, MetricCardinality{
+			Name:      metric,
+			Series:    series,
+			MaxSeries: t.metricLimit(metric),
+		})
+	}
+	sortByCardinality(info.Metrics, func(m MetricCardinality) (int, string) { return m.Series, m.Name })
+	info.Metrics = truncate(info.Metrics, topN)
+
+	for _, name := range t.trackedLabels {
+		label := LabelCardinality{
+			Name:   name,
+			Values: len(t.labelValues[name]),
+		}
+		for v, series := range t.labelValues[name] {
+			label.TopValues = append(label.TopValues, ValueCardinality{Value: v, Series: series})
+		}
+		sortByCardinality(label.TopValues, func(v ValueCardinality) (int, string) { return v.Series, v.Value })
+		label.TopValues = truncate(label.TopValues, topN)
+		info.Labels = append(info.Labels, label)
+	}
+	return info
+}
+
+// sortByCardinality sorts by descending number of series, and then by name.
+func sortByCardinality[T any](s []T, key func(T) (int, string)) {
+	slices.SortFunc(s, func(a, b T) int {
+		aSeries, aName := key(a)
+		bSeries, bName := key(b)
+		if c := cmp.Compare(bSeries, aSeries); c != 0 {
+			return c
+		}
+		return cmp.Compare(aName, bName)
+	})
+}
+
+func truncate[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
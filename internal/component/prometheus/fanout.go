@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"time"
 
@@ -15,11 +16,16 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/service/labelstore"
 )
 
 var _ storage.Appendable = (*Fanout)(nil)
 
+func init() {
+	argument.RegisterCapsuleType("MetricsReceiver", reflect.TypeFor[storage.Appendable]())
+}
+
 // Fanout supports the default Alloy style of appendables since it can go to multiple outputs. It also allows the intercepting of appends.
 type Fanout struct {
 	mut sync.RWMutex
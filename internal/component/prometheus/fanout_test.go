@@ -4,7 +4,11 @@ import (
 	"testing"
 
 	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/util/testappender"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 
 	"github.com/prometheus/prometheus/storage"
 
@@ -26,3 +30,25 @@ func TestCommit(t *testing.T) {
 	err := app.Commit()
 	require.NoError(t, err)
 }
+
+func TestUpdateMetadata(t *testing.T) {
+	ls := labelstore.New(nil, prometheus.NewRegistry())
+	app1, app2 := testappender.NewCollectingAppender(), testappender.NewCollectingAppender()
+	fanout := NewFanout([]storage.Appendable{
+		testappender.ConstantAppendable{Inner: app1},
+		nil,
+		testappender.ConstantAppendable{Inner: app2},
+	}, "", prometheus.NewRegistry(), ls)
+
+	lbls := labels.FromStrings("__name__", "requests_total", "job", "api")
+	md := metadata.Metadata{Type: model.MetricTypeCounter, Unit: "requests", Help: "Total number of requests."}
+	app := fanout.Appender(t.Context())
+	ref, err := app.UpdateMetadata(0, lbls, md)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	// The metadata is forwarded to every child with the global ref of the series.
+	require.Equal(t, storage.SeriesRef(ls.GetOrAddGlobalRefID(lbls)), ref)
+	require.Equal(t, md, app1.CollectedMetadata()[lbls.String()])
+	require.Equal(t, md, app2.CollectedMetadata()[lbls.String()])
+}
@@ -0,0 +1,62 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/util/testappender"
+)
+
+func TestInterceptor_UpdateMetadata(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "requests_total", "job", "api")
+	md := metadata.Metadata{Type: model.MetricTypeCounter, Unit: "requests", Help: "Total number of requests."}
+
+	t.Run("without hook", func(t *testing.T) {
+		ls := labelstore.New(nil, prometheus.NewRegistry())
+		next := testappender.NewCollectingAppender()
+		interceptor := NewInterceptor(testappender.ConstantAppendable{Inner: next}, ls)
+
+		app := interceptor.Appender(t.Context())
+		ref, err := app.UpdateMetadata(0, lbls, md)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+
+		require.Equal(t, storage.SeriesRef(ls.GetOrAddGlobalRefID(lbls)), ref)
+		require.Equal(t, md, next.CollectedMetadata()[lbls.String()])
+	})
+
+	t.Run("with hook", func(t *testing.T) {
+		ls := labelstore.New(nil, prometheus.NewRegistry())
+		next := testappender.NewCollectingAppender()
+		interceptor := NewInterceptor(testappender.ConstantAppendable{Inner: next}, ls,
+			WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata, next storage.Appender) (storage.SeriesRef, error) {
+				m.Unit = "seconds"
+				return next.UpdateMetadata(ref, l, m)
+			}),
+		)
+
+		app := interceptor.Appender(t.Context())
+		_, err := app.UpdateMetadata(0, lbls, md)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+
+		require.Equal(t, "seconds", next.CollectedMetadata()[lbls.String()].Unit)
+	})
+
+	t.Run("without next", func(t *testing.T) {
+		ls := labelstore.New(nil, prometheus.NewRegistry())
+		interceptor := NewInterceptor(nil, ls)
+
+		app := interceptor.Appender(t.Context())
+		_, err := app.UpdateMetadata(0, lbls, md)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+	})
+}
@@ -0,0 +1,104 @@
+package receive_http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/labels"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// createdTimestampHandler appends the created timestamps of the series of
+// Remote-Write 2.0 requests as zero samples, before the request is handled by
+// the next handler. The Prometheus write handler ignores created timestamps.
+type createdTimestampHandler struct {
+	logger     log.Logger
+	appendable storage.Appendable
+	next       http.Handler
+}
+
+func newCreatedTimestampHandler(logger log.Logger, appendable storage.Appendable, next http.Handler) http.Handler {
+	return &createdTimestampHandler{
+		logger:     logger,
+		appendable: appendable,
+		next:       next,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *createdTimestampHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Content-Type"), "proto="+string(config.RemoteWriteProtoMsgV2)) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Invalid requests are rejected by the next handler.
+	if req, err := decodeWriteV2Request(body); err == nil {
+		h.appendCreatedTimestamps(r, req)
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *createdTimestampHandler) appendCreatedTimestamps(r *http.Request, req *writev2.Request) {
+	var (
+		app storage.Appender
+		b   = labels.NewScratchBuilder(0)
+	)
+	for _, ts := range req.Timeseries {
+		if ts.CreatedTimestamp == 0 {
+			continue
+		}
+
+		var t int64
+		switch {
+		case len(ts.Samples) > 0:
+			t = ts.Samples[0].Timestamp
+		case len(ts.Histograms) > 0:
+			t = ts.Histograms[0].Timestamp
+		default:
+			continue
+		}
+
+		if app == nil {
+			app = h.appendable.Appender(r.Context())
+		}
+		ls := ts.ToLabels(&b, req.Symbols)
+		if _, err := app.AppendCTZeroSample(0, ls, t, ts.CreatedTimestamp); err != nil && !errors.Is(err, storage.ErrOutOfOrderCT) {
+			level.Debug(h.logger).Log("msg", "failed to append created timestamp from remote write", "series", ls.String(), "err", err)
+		}
+	}
+	if app == nil {
+		return
+	}
+	if err := app.Commit(); err != nil {
+		level.Error(h.logger).Log("msg", "failed to append created timestamps from remote write", "err", err)
+	}
+}
+
+func decodeWriteV2Request(body []byte) (*writev2.Request, error) {
+	decompressed, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, err
+	}
+	var req writev2.Request
+	if err := req.Unmarshal(decompressed); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
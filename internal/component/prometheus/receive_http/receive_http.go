@@ -67,12 +67,15 @@ func New(opts component.Options, args Arguments) (*Component, error) {
 	uncheckedCollector := util.NewUncheckedCollector(nil)
 	opts.Registerer.MustRegister(uncheckedCollector)
 
-	//TODO: Make this configurable in the future?
-	supportedRemoteWriteProtoMsgs := config.RemoteWriteProtoMsgs{config.RemoteWriteProtoMsgV1}
+	// Remote-Write 2.0 requests are accepted alongside Remote-Write 1.0
+	// requests. Senders which don't support Remote-Write 2.0 keep sending 1.0
+	// requests.
+	supportedRemoteWriteProtoMsgs := config.RemoteWriteProtoMsgs{config.RemoteWriteProtoMsgV1, config.RemoteWriteProtoMsgV2}
+	handler := remote.NewWriteHandler(opts.Logger, opts.Registerer, fanout, supportedRemoteWriteProtoMsgs)
 
 	c := &Component{
 		opts:               opts,
-		handler:            remote.NewWriteHandler(opts.Logger, opts.Registerer, fanout, supportedRemoteWriteProtoMsgs),
+		handler:            newCreatedTimestampHandler(opts.Logger, fanout, handler),
 		fanout:             fanout,
 		uncheckedCollector: uncheckedCollector,
 	}
@@ -18,8 +18,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	promconfig "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/stretchr/testify/assert"
@@ -79,6 +83,95 @@ func TestForwardsMetrics(t *testing.T) {
 	verifyExpectations(t, input, expected, actualSamples, args, ctx)
 }
 
+func TestForwardsRemoteWrite2(t *testing.T) {
+	timestamp := time.Now().Add(time.Second).UnixMilli()
+	lbls := labels.FromStrings("__name__", "requests_total", "cluster", "local")
+
+	symbols := writev2.NewSymbolTable()
+	input := &writev2.Request{
+		Timeseries: []writev2.TimeSeries{{
+			LabelsRefs: symbols.SymbolizeLabels(lbls, nil),
+			Samples:    []writev2.Sample{{Timestamp: timestamp, Value: 12}},
+			Exemplars: []writev2.Exemplar{{
+				LabelsRefs: symbols.SymbolizeLabels(labels.FromStrings("trace_id", "abc"), nil),
+				Value:      1,
+				Timestamp:  timestamp,
+			}},
+			Metadata: writev2.Metadata{
+				Type:    writev2.Metadata_METRIC_TYPE_COUNTER,
+				HelpRef: symbols.Symbolize("Total requests."),
+				UnitRef: symbols.Symbolize("requests"),
+			},
+			CreatedTimestamp: timestamp - 1000,
+		}},
+	}
+	input.Symbols = symbols.Symbols()
+
+	received := make(chan string, 100)
+	ls := labelstore.New(nil, prometheus.DefaultRegisterer)
+	appendable := alloyprom.NewInterceptor(
+		nil,
+		ls,
+		alloyprom.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, ts int64, val float64, _ storage.Appender) (storage.SeriesRef, error) {
+			received <- fmt.Sprintf("sample %s %d %v", l, ts, val)
+			return ref, nil
+		}),
+		alloyprom.WithCTZeroSampleHook(func(ref storage.SeriesRef, l labels.Labels, ts, ct int64, _ storage.Appender) (storage.SeriesRef, error) {
+			received <- fmt.Sprintf("created_timestamp %s %d %d", l, ts, ct)
+			return ref, nil
+		}),
+		alloyprom.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, _ storage.Appender) (storage.SeriesRef, error) {
+			received <- fmt.Sprintf("exemplar %s %s %v", l, e.Labels, e.Value)
+			return ref, nil
+		}),
+		alloyprom.WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata, _ storage.Appender) (storage.SeriesRef, error) {
+			received <- fmt.Sprintf("metadata %s %s %s %q", l, m.Type, m.Unit, m.Help)
+			return ref, nil
+		}),
+	)
+
+	args := Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    getFreePort(t),
+			},
+			GRPC: testGRPCConfig(t),
+		},
+		ForwardTo: []storage.Appendable{appendable},
+	}
+	comp, err := New(testOptions(t), args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	go func() {
+		require.NoError(t, comp.Run(ctx))
+	}()
+	waitForServerToBeReady(t, args)
+
+	endpoint := fmt.Sprintf(
+		"http://%s:%d/api/v1/metrics/write",
+		args.Server.HTTP.ListenAddress,
+		args.Server.HTTP.ListenPort,
+	)
+	require.NoError(t, requestV2(ctx, endpoint, input))
+
+	expected := []string{
+		fmt.Sprintf("created_timestamp %s %d %d", lbls, timestamp, timestamp-1000),
+		fmt.Sprintf("sample %s %d 12", lbls, timestamp),
+		fmt.Sprintf(`exemplar %s {trace_id="abc"} 1`, lbls),
+		fmt.Sprintf(`metadata %s counter requests "Total requests."`, lbls),
+	}
+	for _, exp := range expected {
+		select {
+		case actual := <-received:
+			require.Equal(t, exp, actual)
+		case <-ctx.Done():
+			t.Fatalf("test timed out")
+		}
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	timestamp := time.Now().Add(time.Second).UnixMilli()
 	input01 := []prompb.TimeSeries{{
@@ -382,6 +475,30 @@ func request(ctx context.Context, rawRemoteWriteURL string, req *prompb.WriteReq
 	return err
 }
 
+func requestV2(ctx context.Context, rawRemoteWriteURL string, req *writev2.Request) error {
+	remoteWriteURL, err := url.Parse(rawRemoteWriteURL)
+	if err != nil {
+		return err
+	}
+
+	client, err := remote.NewWriteClient("remote-write-client", &remote.ClientConfig{
+		URL:           &config.URL{URL: remoteWriteURL},
+		Timeout:       model.Duration(30 * time.Second),
+		WriteProtoMsg: promconfig.RemoteWriteProtoMsgV2,
+	})
+	if err != nil {
+		return err
+	}
+
+	buf, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Store(ctx, snappy.Encode(nil, buf), 0)
+	return err
+}
+
 func testOptions(t *testing.T) component.Options {
 	return component.Options{
 		ID:         "prometheus.receive_http.test",
@@ -0,0 +1,310 @@
+package receive_pushgateway
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// staleNaN is the value of staleness markers.
+var staleNaN = math.Float64frombits(value.StaleNaN)
+
+// group is the last pushed state of the metrics of a grouping key.
+type group struct {
+	key      labels.Labels
+	families map[string]*family
+	lastPush time.Time
+}
+
+// family is a pushed metric family, with the series of all its samples.
+type family struct {
+	name     string
+	metadata metadata.Metadata
+	series   []pushedSeries
+}
+
+// pushedSeries is a series pushed with a float or a native histogram value.
+type pushedSeries struct {
+	labels labels.Labels
+	value  float64
+	h      *histogram.Histogram
+	fh     *histogram.FloatHistogram
+}
+
+// groupStore keeps the last pushed metrics of each grouping key.
+type groupStore struct {
+	mut    sync.Mutex
+	groups map[string]*group
+}
+
+func newGroupStore() *groupStore {
+	return &groupStore{groups: make(map[string]*group)}
+}
+
+// push stores the pushed families in the group of the grouping key. If
+// replace is true, all the families of the group are replaced. Otherwise,
+// only the families with the same names as the pushed families are replaced.
+// The series which are no longer part of the group are returned.
+func (s *groupStore) push(key labels.Labels, families []*family, replace bool, now time.Time) []pushedSeries {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	g, ok := s.groups[key.String()]
+	if !ok {
+		g = &group{key: key, families: make(map[string]*family)}
+		s.groups[key.String()] = g
+	}
+	g.lastPush = now
+
+	pushed := make(map[string]*family, len(families))
+	for _, f := range families {
+		pushed[f.name] = f
+	}
+
+	var removed []pushedSeries
+	for _, name := range slices.Sorted(maps.Keys(g.families)) {
+		newFamily, ok := pushed[name]
+		if !replace && !ok {
+			continue
+		}
+		removed = append(removed, removedSeries(g.families[name], newFamily)...)
+		delete(g.families, name)
+	}
+	for _, f := range families {
+		g.families[f.name] = f
+	}
+	return removed
+}
+
+// removedSeries returns the series of old which aren't part of new.
+func removedSeries(old, new *family) []pushedSeries {
+	var keep map[uint64]struct{}
+	if new != nil {
+		keep = make(map[uint64]struct{}, len(new.series))
+		for _, s := range new.series {
+			keep[s.labels.Hash()] = struct{}{}
+		}
+	}
+
+	var removed []pushedSeries
+	for _, s := range old.series {
+		if _, ok := keep[s.labels.Hash()]; !ok {
+			removed = append(removed, s)
+		}
+	}
+	return removed
+}
+
+// delete deletes the group of the grouping key, and returns its series.
+func (s *groupStore) delete(key labels.Labels) []pushedSeries {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	g, ok := s.groups[key.String()]
+	if !ok {
+		return nil
+	}
+	delete(s.groups, key.String())
+	return g.allSeries()
+}
+
+// expire deletes the groups which weren't pushed since cutoff, and returns
+// their series.
+func (s *groupStore) expire(cutoff time.Time) (int, []pushedSeries) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var (
+		expired int
+		removed []pushedSeries
+	)
+	for k, g := range s.groups {
+		if g.lastPush.Before(cutoff) {
+			expired++
+			removed = append(removed, g.allSeries()...)
+			delete(s.groups, k)
+		}
+	}
+	return expired, removed
+}
+
+// families returns the families of all the groups.
+func (s *groupStore) families() []*family {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var res []*family
+	for _, g := range s.groups {
+		for _, f := range g.families {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// len returns the number of groups.
+func (s *groupStore) len() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return len(s.groups)
+}
+
+func (g *group) allSeries() []pushedSeries {
+	var res []pushedSeries
+	for _, f := range g.families {
+		res = append(res, f.series...)
+	}
+	return res
+}
+
+// appendFamilies appends the samples of the families and their metadata with
+// the timestamp t.
+func appendFamilies(app storage.Appender, families []*family, t int64) error {
+	for _, f := range families {
+		for _, s := range f.series {
+			var err error
+			if s.h != nil || s.fh != nil {
+				_, err = app.AppendHistogram(0, s.labels, t, s.h, s.fh)
+			} else {
+				_, err = app.Append(0, s.labels, t, s.value)
+			}
+			if err != nil {
+				return err
+			}
+			if f.metadata.Type != model.MetricTypeUnknown || f.metadata.Help != "" || f.metadata.Unit != "" {
+				if _, err := app.UpdateMetadata(0, s.labels, f.metadata); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// appendStaleMarkers appends staleness markers for the series with the
+// timestamp t.
+func appendStaleMarkers(app storage.Appender, series []pushedSeries, t int64) error {
+	for _, s := range series {
+		if _, err := app.Append(0, s.labels, t, staleNaN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseFamilies parses pushed metrics in the Prometheus text, OpenMetrics
+// text, or Prometheus protobuf exposition format, and adds the labels of the
+// grouping key to their series. The families are returned in the order they
+// were pushed.
+func parseFamilies(body []byte, contentType string, key labels.Labels) ([]*family, error) {
+	// Like scrapes, the Prometheus text format is used if the content type is
+	// invalid.
+	p, _ := textparse.New(body, contentType, false, labels.NewSymbolTable())
+
+	var (
+		families []*family
+		byName   = make(map[string]*family)
+		current  string
+		lset     labels.Labels
+		lb       = labels.NewBuilder(labels.EmptyLabels())
+	)
+	getFamily := func(name string) *family {
+		f, ok := byName[name]
+		if !ok {
+			f = &family{name: name, metadata: metadata.Metadata{Type: model.MetricTypeUnknown}}
+			byName[name] = f
+			families = append(families, f)
+		}
+		return f
+	}
+
+	for {
+		et, err := p.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return families, nil
+			}
+			return nil, err
+		}
+
+		var s pushedSeries
+		var ts *int64
+		switch et {
+		case textparse.EntryType:
+			name, typ := p.Type()
+			current = string(name)
+			getFamily(current).metadata.Type = typ
+			continue
+		case textparse.EntryHelp:
+			name, help := p.Help()
+			current = string(name)
+			getFamily(current).metadata.Help = string(help)
+			continue
+		case textparse.EntryUnit:
+			name, unit := p.Unit()
+			current = string(name)
+			getFamily(current).metadata.Unit = string(unit)
+			continue
+		case textparse.EntryComment:
+			continue
+		case textparse.EntryHistogram:
+			_, ts, s.h, s.fh = p.Histogram()
+		default:
+			_, ts, s.value = p.Series()
+		}
+		p.Metric(&lset)
+		name := lset.Get(labels.MetricName)
+		if ts != nil {
+			return nil, fmt.Errorf("pushed metrics must not have timestamps, but %s has one", lset)
+		}
+
+		lb.Reset(lset)
+		var conflict error
+		key.Range(func(l labels.Label) {
+			if v := lset.Get(l.Name); v != "" && v != l.Value {
+				conflict = fmt.Errorf("label %s=%q of %s conflicts with the grouping key", l.Name, v, lset)
+			}
+			lb.Set(l.Name, l.Value)
+		})
+		if conflict != nil {
+			return nil, conflict
+		}
+		s.labels = lb.Labels()
+
+		f := getFamily(familyName(current, name))
+		f.series = append(f.series, s)
+	}
+}
+
+// familySuffixes are the suffixes of the series names of metric families.
+var familySuffixes = []string{"_bucket", "_count", "_sum", "_total", "_created", "_info", "_gcount", "_gsum"}
+
+// familyName returns the name of the family of the metric, which is current
+// if the metric belongs to the family described by the last metadata entry.
+func familyName(current, metric string) string {
+	if current == "" || metric == current {
+		return metric
+	}
+	if suffix, ok := strings.CutPrefix(metric, current); ok {
+		for _, s := range familySuffixes {
+			if suffix == s {
+				return current
+			}
+		}
+	}
+	return metric
+}
@@ -0,0 +1,396 @@
+package receive_pushgateway
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component"
+	fnet "github.com/grafana/alloy/internal/component/common/net"
+	alloyprom "github.com/grafana/alloy/internal/component/prometheus"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "prometheus.receive_pushgateway",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// prometheus.receive_pushgateway component.
+type Arguments struct {
+	Server    *fnet.ServerConfig   `alloy:",squash"`
+	ForwardTo []storage.Appendable `alloy:"forward_to,attr"`
+
+	// How often the samples of all the groups are forwarded.
+	ForwardInterval time.Duration `alloy:"forward_interval,attr,optional"`
+	// How long a group is kept after it was last pushed. 0 keeps the groups
+	// until they're deleted.
+	GroupTTL time.Duration `alloy:"group_ttl,attr,optional"`
+	// The maximum size of the body of a push.
+	MaxRequestBodySize units.Base2Bytes `alloy:"max_request_body_size,attr,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = Arguments{
+		Server:             fnet.DefaultServerConfig(),
+		ForwardInterval:    time.Minute,
+		GroupTTL:           time.Hour,
+		MaxRequestBodySize: 16 * units.MiB,
+	}
+}
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	if args.ForwardInterval <= 0 {
+		return fmt.Errorf("forward_interval must be greater than 0")
+	}
+	if args.GroupTTL < 0 {
+		return fmt.Errorf("group_ttl must not be negative")
+	}
+	if args.MaxRequestBodySize <= 0 {
+		return fmt.Errorf("max_request_body_size must be greater than 0")
+	}
+	return nil
+}
+
+// Component implements the prometheus.receive_pushgateway component.
+type Component struct {
+	opts               component.Options
+	fanout             *alloyprom.Fanout
+	uncheckedCollector *util.UncheckedCollector
+	groups             *groupStore
+	now                func() time.Time
+
+	groupsGauge    prometheus.Gauge
+	expiredCounter prometheus.Counter
+
+	// forwardMut is held while the groups are changed and the changes are
+	// forwarded, so that they are forwarded in the order they were made.
+	forwardMut sync.Mutex
+
+	updateMut sync.RWMutex
+	args      Arguments
+	server    *fnet.TargetServer
+}
+
+// New creates a new prometheus.receive_pushgateway component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	service, err := opts.GetServiceData(labelstore.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	ls := service.(labelstore.LabelStore)
+
+	uncheckedCollector := util.NewUncheckedCollector(nil)
+	opts.Registerer.MustRegister(uncheckedCollector)
+
+	c := &Component{
+		opts:               opts,
+		fanout:             alloyprom.NewFanout(args.ForwardTo, opts.ID, opts.Registerer, ls),
+		uncheckedCollector: uncheckedCollector,
+		groups:             newGroupStore(),
+		now:                time.Now,
+	}
+
+	c.groupsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "prometheus_receive_pushgateway_groups",
+		Help: "Number of pushed groups",
+	})
+	c.expiredCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "prometheus_receive_pushgateway_groups_expired_total",
+		Help: "Total number of groups deleted because they weren't pushed during the group TTL",
+	})
+	for _, metric := range []prometheus.Collector{c.groupsGauge, c.expiredCounter} {
+		if err := opts.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run satisfies the Component interface.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.updateMut.Lock()
+		defer c.updateMut.Unlock()
+		c.shutdownServer()
+	}()
+
+	ticker := time.NewTicker(c.forwardInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			level.Info(c.opts.Logger).Log("msg", "terminating due to context done")
+			return nil
+		case <-ticker.C:
+			c.expireGroups(ctx)
+			c.forwardGroups(ctx)
+			ticker.Reset(c.forwardInterval())
+		}
+	}
+}
+
+// Update satisfies the Component interface.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	c.updateMut.Lock()
+	defer c.updateMut.Unlock()
+
+	serverNeedsUpdate := !reflect.DeepEqual(c.args.Server, newArgs.Server)
+	if !serverNeedsUpdate {
+		c.args = newArgs
+		return nil
+	}
+	c.shutdownServer()
+
+	s, err := c.createNewServer(newArgs)
+	if err != nil {
+		return err
+	}
+	c.server = s
+
+	err = c.server.MountAndRun(c.registerRoutes)
+	if err != nil {
+		return err
+	}
+
+	c.args = newArgs
+	return nil
+}
+
+// registerRoutes registers the routes of the Pushgateway API.
+func (c *Component) registerRoutes(router *mux.Router) {
+	router.PathPrefix("/metrics/job").Methods(http.MethodPut).Handler(c.pushHandler(true))
+	router.PathPrefix("/metrics/job").Methods(http.MethodPost).Handler(c.pushHandler(false))
+	router.PathPrefix("/metrics/job").Methods(http.MethodDelete).HandlerFunc(c.handleDelete)
+}
+
+// pushHandler handles pushes. With replace, the pushed metrics replace all
+// the metrics of the group, like PUT requests to the Pushgateway. Otherwise,
+// they only replace the metrics with the same names, like POST requests.
+func (c *Component) pushHandler(replace bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := parseGroupingKey(r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c.updateMut.RLock()
+		maxBodySize := int64(c.args.MaxRequestBodySize)
+		c.updateMut.RUnlock()
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		families, err := parseFamilies(body, r.Header.Get("Content-Type"), key)
+		if err != nil {
+			level.Debug(c.opts.Logger).Log("msg", "failed to parse pushed metrics", "grouping_key", key, "err", err)
+			http.Error(w, fmt.Sprintf("failed to parse pushed metrics: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		c.forwardMut.Lock()
+		defer c.forwardMut.Unlock()
+
+		now := c.now()
+		removed := c.groups.push(key, families, replace, now)
+		c.groupsGauge.Set(float64(c.groups.len()))
+
+		if err := c.forward(r.Context(), families, removed, now); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func (c *Component) handleDelete(w http.ResponseWriter, r *http.Request) {
+	key, err := parseGroupingKey(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.forwardMut.Lock()
+	defer c.forwardMut.Unlock()
+
+	removed := c.groups.delete(key)
+	c.groupsGauge.Set(float64(c.groups.len()))
+	if err := c.forward(r.Context(), nil, removed, c.now()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// forward appends the samples of the families, and staleness markers for the
+// removed series. forwardMut must be held when calling forward.
+func (c *Component) forward(ctx context.Context, families []*family, removed []pushedSeries, now time.Time) error {
+	if len(families) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	t := now.UnixMilli()
+	app := c.fanout.Appender(ctx)
+	if err := appendStaleMarkers(app, removed, t); err != nil {
+		_ = app.Rollback()
+		return err
+	}
+	if err := appendFamilies(app, families, t); err != nil {
+		_ = app.Rollback()
+		return err
+	}
+	return app.Commit()
+}
+
+// forwardGroups forwards the samples of all the groups.
+func (c *Component) forwardGroups(ctx context.Context) {
+	c.forwardMut.Lock()
+	defer c.forwardMut.Unlock()
+
+	if err := c.forward(ctx, c.groups.families(), nil, c.now()); err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to forward pushed metrics", "err", err)
+	}
+}
+
+// expireGroups deletes the groups which weren't pushed during the group TTL.
+func (c *Component) expireGroups(ctx context.Context) {
+	c.updateMut.RLock()
+	ttl := c.args.GroupTTL
+	c.updateMut.RUnlock()
+	if ttl == 0 {
+		return
+	}
+
+	c.forwardMut.Lock()
+	defer c.forwardMut.Unlock()
+
+	now := c.now()
+	expired, removed := c.groups.expire(now.Add(-ttl))
+	c.expiredCounter.Add(float64(expired))
+	c.groupsGauge.Set(float64(c.groups.len()))
+	if err := c.forward(ctx, nil, removed, now); err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to forward staleness markers of expired groups", "err", err)
+	}
+}
+
+func (c *Component) forwardInterval() time.Duration {
+	c.updateMut.RLock()
+	defer c.updateMut.RUnlock()
+	return c.args.ForwardInterval
+}
+
+func (c *Component) createNewServer(args Arguments) (*fnet.TargetServer, error) {
+	// [server.Server] registers new metrics every time it is created. To
+	// avoid issues with re-registering metrics with the same name, we create a
+	// new registry for the server every time we create one, and pass it to an
+	// unchecked collector to bypass uniqueness checking.
+	serverRegistry := prometheus.NewRegistry()
+	c.uncheckedCollector.SetCollector(serverRegistry)
+
+	s, err := fnet.NewTargetServer(
+		c.opts.Logger,
+		"prometheus_receive_pushgateway",
+		serverRegistry,
+		args.Server,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server: %v", err)
+	}
+
+	return s, nil
+}
+
+// shutdownServer will shut down the currently used server.
+// It is not goroutine-safe and an updateMut write lock must be held when it's called.
+func (c *Component) shutdownServer() {
+	if c.server != nil {
+		c.server.StopAndShutdown()
+		c.server = nil
+	}
+}
+
+// parseGroupingKey parses the grouping key of a Pushgateway URL path of the
+// form /metrics/job/<JOB>{/<LABEL_NAME>/<LABEL_VALUE>}. Label names can have
+// the @base64 suffix to indicate that the label value is encoded with the URL
+// safe base64 encoding.
+func parseGroupingKey(path string) (labels.Labels, error) {
+	rest, ok := strings.CutPrefix(path, "/metrics/")
+	if !ok {
+		return labels.EmptyLabels(), fmt.Errorf("invalid path %q", path)
+	}
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(parts)%2 != 0 {
+		return labels.EmptyLabels(), fmt.Errorf("odd number of components in grouping key path %q", path)
+	}
+	if parts[0] != model.JobLabel && parts[0] != model.JobLabel+"@base64" {
+		return labels.EmptyLabels(), fmt.Errorf("grouping key path %q must start with the job label", path)
+	}
+
+	b := labels.NewBuilder(labels.EmptyLabels())
+	for i := 0; i < len(parts); i += 2 {
+		name, value := parts[i], parts[i+1]
+		if n, ok := strings.CutSuffix(name, "@base64"); ok {
+			decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+			if err != nil {
+				return labels.EmptyLabels(), fmt.Errorf("invalid base64 encoding of label %q: %w", n, err)
+			}
+			name, value = n, string(decoded)
+		}
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return labels.EmptyLabels(), fmt.Errorf("invalid label name %q in grouping key", name)
+		}
+		if b.Get(name) != "" {
+			return labels.EmptyLabels(), fmt.Errorf("duplicate label %q in grouping key", name)
+		}
+		b.Set(name, value)
+	}
+
+	key := b.Labels()
+	if key.Get(model.JobLabel) == "" {
+		return labels.EmptyLabels(), fmt.Errorf("job name must not be empty")
+	}
+	return key, nil
+}
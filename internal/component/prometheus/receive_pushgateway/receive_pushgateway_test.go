@@ -0,0 +1,361 @@
+package receive_pushgateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/gorilla/mux"
+	"github.com/phayes/freeport"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/grafana/alloy/internal/component"
+	fnet "github.com/grafana/alloy/internal/component/common/net"
+	alloyprom "github.com/grafana/alloy/internal/component/prometheus"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func TestPush(t *testing.T) {
+	c, app, router := newTestComponent(t)
+
+	push(t, router, http.MethodPut, "/metrics/job/batch/instance/a", `
+# TYPE job_duration_seconds gauge
+# HELP job_duration_seconds Duration of the job.
+job_duration_seconds 12.5
+# TYPE job_records_total counter
+job_records_total{table="users"} 100
+`)
+	require.Equal(t, []string{
+		`{__name__="job_duration_seconds", instance="a", job="batch"} 1000 12.5`,
+		`{__name__="job_records_total", instance="a", job="batch", table="users"} 1000 100`,
+	}, app.reset())
+	require.Equal(t, metadata.Metadata{Type: "gauge", Help: "Duration of the job."}, app.metadata[`{__name__="job_duration_seconds", instance="a", job="batch"}`])
+
+	// POST only replaces the pushed metrics.
+	push(t, router, http.MethodPost, "/metrics/job/batch/instance/a", `
+job_records_total{table="orders"} 20
+`)
+	require.Equal(t, []string{
+		`{__name__="job_records_total", instance="a", job="batch", table="users"} 1000 NaN`,
+		`{__name__="job_records_total", instance="a", job="batch", table="orders"} 1000 20`,
+	}, app.reset())
+
+	// PUT replaces all the metrics of the group.
+	push(t, router, http.MethodPut, "/metrics/job/batch/instance/a", `
+job_duration_seconds 15
+`)
+	require.Equal(t, []string{
+		`{__name__="job_records_total", instance="a", job="batch", table="orders"} 1000 NaN`,
+		`{__name__="job_duration_seconds", instance="a", job="batch"} 1000 15`,
+	}, app.reset())
+
+	// Other groups are kept separately.
+	push(t, router, http.MethodPut, "/metrics/job@base64/YmF0Y2gvbmlnaHRseQ/instance/b", `
+job_duration_seconds 3
+`)
+	require.Equal(t, []string{
+		`{__name__="job_duration_seconds", instance="b", job="batch/nightly"} 1000 3`,
+	}, app.reset())
+
+	c.forwardGroups(t.Context())
+	require.ElementsMatch(t, []string{
+		`{__name__="job_duration_seconds", instance="a", job="batch"} 1000 15`,
+		`{__name__="job_duration_seconds", instance="b", job="batch/nightly"} 1000 3`,
+	}, app.reset())
+
+	// DELETE deletes the group.
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/metrics/job/batch/instance/a", nil))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Equal(t, []string{
+		`{__name__="job_duration_seconds", instance="a", job="batch"} 1000 NaN`,
+	}, app.reset())
+	require.Equal(t, 1, c.groups.len())
+}
+
+func TestPush_Invalid(t *testing.T) {
+	_, app, router := newTestComponent(t)
+
+	tests := map[string]struct {
+		path string
+		body string
+		err  string
+	}{
+		"timestamp": {
+			path: "/metrics/job/batch",
+			body: "up 1 1000\n",
+			err:  "pushed metrics must not have timestamps",
+		},
+		"conflicting label": {
+			path: "/metrics/job/batch",
+			body: `up{job="other"} 1` + "\n",
+			err:  `label job="other" of {__name__="up", job="other"} conflicts with the grouping key`,
+		},
+		"odd path": {
+			path: "/metrics/job/batch/instance",
+			err:  "odd number of components",
+		},
+		"empty job": {
+			path: "/metrics/job@base64/=",
+			err:  "job name must not be empty",
+		},
+		"invalid label name": {
+			path: "/metrics/job/batch/__name__/up",
+			err:  `invalid label name "__name__"`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body)))
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			require.Contains(t, rec.Body.String(), tc.err)
+		})
+	}
+	require.Empty(t, app.reset())
+}
+
+func TestPush_TooLarge(t *testing.T) {
+	_, app, router := newTestComponent(t)
+
+	body := strings.Repeat("# HELP up Whether the job is up.\n", 100) + "up 1\n"
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/metrics/job/batch", strings.NewReader(body)))
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.Empty(t, app.reset())
+}
+
+func TestPush_HistogramFamily(t *testing.T) {
+	_, app, router := newTestComponent(t)
+
+	push(t, router, http.MethodPut, "/metrics/job/batch", `
+# TYPE job_latency_seconds histogram
+job_latency_seconds_count 2
+job_latency_seconds_sum 3
+job_latency_seconds_bucket{le="+Inf"} 2
+`)
+	require.Equal(t, []string{
+		`{__name__="job_latency_seconds_count", job="batch"} 1000 2`,
+		`{__name__="job_latency_seconds_sum", job="batch"} 1000 3`,
+		`{__name__="job_latency_seconds_bucket", job="batch", le="+Inf"} 1000 2`,
+	}, app.reset())
+
+	// All the series of the family are replaced by a POST of the family.
+	push(t, router, http.MethodPost, "/metrics/job/batch", `
+# TYPE job_latency_seconds histogram
+job_latency_seconds_count 1
+job_latency_seconds_sum 1
+`)
+	require.Equal(t, []string{
+		`{__name__="job_latency_seconds_bucket", job="batch", le="+Inf"} 1000 NaN`,
+		`{__name__="job_latency_seconds_count", job="batch"} 1000 1`,
+		`{__name__="job_latency_seconds_sum", job="batch"} 1000 1`,
+	}, app.reset())
+}
+
+func TestPush_Protobuf(t *testing.T) {
+	_, app, router := newTestComponent(t)
+
+	var buf bytes.Buffer
+	format := expfmt.NewFormat(expfmt.TypeProtoDelim)
+	enc := expfmt.NewEncoder(&buf, format)
+	require.NoError(t, enc.Encode(&dto.MetricFamily{
+		Name: proto.String("job_last_success_timestamp_seconds"),
+		Help: proto.String("Time of the last success."),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{
+			Label: []*dto.LabelPair{{Name: proto.String("table"), Value: proto.String("users")}},
+			Gauge: &dto.Gauge{Value: proto.Float64(1700000000)},
+		}},
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/metrics/job/batch", &buf)
+	req.Header.Set("Content-Type", string(format))
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.Equal(t, []string{
+		`{__name__="job_last_success_timestamp_seconds", job="batch", table="users"} 1000 1.7e+09`,
+	}, app.reset())
+	require.Equal(t, metadata.Metadata{Type: "gauge", Help: "Time of the last success."}, app.metadata[`{__name__="job_last_success_timestamp_seconds", job="batch", table="users"}`])
+}
+
+func TestExpireGroups(t *testing.T) {
+	c, app, router := newTestComponent(t)
+
+	push(t, router, http.MethodPut, "/metrics/job/a", "up 1\n")
+	c.now = func() time.Time { return time.UnixMilli(1000).Add(30 * time.Minute) }
+	push(t, router, http.MethodPut, "/metrics/job/b", "up 1\n")
+	app.reset()
+
+	c.now = func() time.Time { return time.UnixMilli(1000).Add(61 * time.Minute) }
+	c.expireGroups(t.Context())
+	require.Equal(t, []string{
+		fmt.Sprintf(`{__name__="up", job="a"} %d NaN`, time.UnixMilli(1000).Add(61*time.Minute).UnixMilli()),
+	}, app.reset())
+	require.Equal(t, 1, c.groups.len())
+}
+
+func TestServer(t *testing.T) {
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	app := &testAppender{metadata: make(map[string]metadata.Metadata)}
+	var args Arguments
+	args.SetToDefault()
+	args.Server = &fnet.ServerConfig{
+		HTTP: &fnet.HTTPConfig{ListenAddress: "localhost", ListenPort: port},
+		GRPC: &fnet.GRPCConfig{ListenAddress: "localhost", ListenPort: 0},
+	}
+	args.ForwardTo = []storage.Appendable{testAppendable{app}}
+
+	c, err := New(testOptions(t), args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() {
+		require.NoError(t, c.Run(ctx))
+	}()
+
+	url := fmt.Sprintf("http://localhost:%d/metrics/job/batch", port)
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodPut, url, strings.NewReader("up 1\n"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Equal(t, 1, c.groups.len())
+}
+
+func TestFamilyName(t *testing.T) {
+	require.Equal(t, "http_requests", familyName("http_requests", "http_requests_total"))
+	require.Equal(t, "latency", familyName("latency", "latency_bucket"))
+	require.Equal(t, "latency_other", familyName("latency", "latency_other"))
+	require.Equal(t, "up", familyName("", "up"))
+}
+
+func newTestComponent(t *testing.T) (*Component, *testAppender, *mux.Router) {
+	app := &testAppender{metadata: make(map[string]metadata.Metadata)}
+	c := &Component{
+		opts:   testOptions(t),
+		groups: newGroupStore(),
+		now:    func() time.Time { return time.UnixMilli(1000) },
+		groupsGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "groups",
+		}),
+		expiredCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "expired",
+		}),
+		args: Arguments{GroupTTL: time.Hour, MaxRequestBodySize: units.KiB},
+	}
+	ls := labelstore.New(nil, prometheus.NewRegistry())
+	c.fanout = alloyprom.NewFanout([]storage.Appendable{testAppendable{app}}, c.opts.ID, prometheus.NewRegistry(), ls)
+
+	router := mux.NewRouter()
+	c.registerRoutes(router)
+	return c, app, router
+}
+
+func push(t *testing.T, router *mux.Router, method, path, body string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func testOptions(t *testing.T) component.Options {
+	return component.Options{
+		ID:         "prometheus.receive_pushgateway.test",
+		Logger:     util.TestAlloyLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		GetServiceData: func(name string) (interface{}, error) {
+			return labelstore.New(nil, prometheus.DefaultRegisterer), nil
+		},
+	}
+}
+
+// testAppender records the samples and metadata it receives.
+type testAppender struct {
+	storage.Appender
+
+	mut      sync.Mutex
+	samples  []string
+	metadata map[string]metadata.Metadata
+}
+
+// testAppendable returns its testAppender.
+type testAppendable struct {
+	*testAppender
+}
+
+func (a testAppendable) Appender(_ context.Context) storage.Appender {
+	return a.testAppender
+}
+
+func (a *testAppender) Append(_ storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	if value.IsStaleNaN(v) {
+		a.samples = append(a.samples, fmt.Sprintf("%s %d NaN", l, t))
+	} else {
+		a.samples = append(a.samples, fmt.Sprintf("%s %d %v", l, t, v))
+	}
+	return 0, nil
+}
+
+func (a *testAppender) AppendHistogram(_ storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	if h != nil {
+		a.samples = append(a.samples, fmt.Sprintf("%s %d %s", l, t, h))
+	} else {
+		a.samples = append(a.samples, fmt.Sprintf("%s %d %s", l, t, fh))
+	}
+	return 0, nil
+}
+
+func (a *testAppender) UpdateMetadata(_ storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	a.metadata[l.String()] = m
+	return 0, nil
+}
+
+func (a *testAppender) Commit() error {
+	return nil
+}
+
+func (a *testAppender) Rollback() error {
+	return nil
+}
+
+// reset returns the recorded samples, and clears them.
+func (a *testAppender) reset() []string {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	res := a.samples
+	a.samples = nil
+	return res
+}
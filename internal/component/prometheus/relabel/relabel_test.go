@@ -8,6 +8,7 @@ import (
 	"time"
 
 	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/alloy/internal/component"
 	alloy_relabel "github.com/grafana/alloy/internal/component/common/relabel"
@@ -16,9 +17,11 @@ import (
 	"github.com/grafana/alloy/internal/service/labelstore"
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/internal/util/testappender"
 	"github.com/grafana/alloy/syntax"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
@@ -92,6 +95,49 @@ func TestNil(t *testing.T) {
 	relabeller.relabel(0, lbls)
 }
 
+func TestMetadata(t *testing.T) {
+	app := testappender.NewCollectingAppender()
+	relabeller, err := New(component.Options{
+		ID:             "1",
+		Logger:         util.TestAlloyLogger(t),
+		OnStateChange:  func(e component.Exports) {},
+		Registerer:     prom.NewRegistry(),
+		GetServiceData: getServiceData,
+	}, Arguments{
+		ForwardTo: []storage.Appendable{testappender.ConstantAppendable{Inner: app}},
+		MetricRelabelConfigs: []*alloy_relabel.Config{
+			{
+				SourceLabels: []string{"__name__"},
+				Regex:        alloy_relabel.Regexp(relabel.MustNewRegexp("dropped_.*")),
+				Action:       "drop",
+			},
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        alloy_relabel.Regexp(relabel.MustNewRegexp("(.+)")),
+				TargetLabel:  "new_label",
+				Replacement:  "new_value",
+				Action:       "replace",
+			},
+		},
+		CacheSize: 100_000,
+	})
+	require.NoError(t, err)
+
+	md := metadata.Metadata{Type: model.MetricTypeGauge, Unit: "bytes", Help: "Memory usage."}
+	in := relabeller.receiver.Appender(t.Context())
+	_, err = in.UpdateMetadata(0, labels.FromStrings("__name__", "memory_bytes", "__address__", "localhost"), md)
+	require.NoError(t, err)
+	_, err = in.UpdateMetadata(0, labels.FromStrings("__name__", "dropped_bytes", "__address__", "localhost"), md)
+	require.NoError(t, err)
+	require.NoError(t, in.Commit())
+
+	// The metadata is forwarded with the relabeled series, and the metadata of
+	// dropped series isn't forwarded.
+	require.Equal(t, map[string]metadata.Metadata{
+		`{__address__="localhost", __name__="memory_bytes", new_label="new_value"}`: md,
+	}, app.CollectedMetadata())
+}
+
 func TestLRU(t *testing.T) {
 	relabeller := generateRelabel(t)
 
@@ -0,0 +1,178 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/prometheus/prometheus/storage/remote"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// metadataSender periodically sends the metric metadata of the series in the
+// WAL to the endpoints which receive Remote-Write 1.0 requests.
+//
+// Remote-Write 2.0 requests send the metadata alongside each series, but
+// Remote-Write 1.0 requests send the metadata in separate requests. The
+// Prometheus remote storage reads the metadata of these requests from a scrape
+// manager, which the component doesn't have, so the metadata is read from the
+// WAL instead.
+type metadataSender struct {
+	logger log.Logger
+	// list returns the metadata to send.
+	list func() []scrape.MetricMetadata
+
+	sentTotal   *prometheus.CounterVec
+	failedTotal *prometheus.CounterVec
+
+	mut    sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newMetadataSender(logger log.Logger, reg prometheus.Registerer, list func() []scrape.MetricMetadata) (*metadataSender, error) {
+	s := &metadataSender{
+		logger: logger,
+		list:   list,
+		sentTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prometheus_remote_write_metadata_sent_total",
+			Help: "Total number of metadata entries sent to Remote-Write 1.0 endpoints.",
+		}, []string{"remote_name", "url"}),
+		failedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prometheus_remote_write_metadata_failed_total",
+			Help: "Total number of metadata entries which failed to be sent to Remote-Write 1.0 endpoints.",
+		}, []string{"remote_name", "url"}),
+	}
+	for _, c := range []prometheus.Collector{s.sentTotal, s.failedTotal} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// ApplyConfig stops sending metadata to the previous endpoints, and starts
+// sending metadata to the endpoints of cfgs which receive Remote-Write 1.0
+// requests and have metadata sending enabled.
+func (s *metadataSender) ApplyConfig(cfgs []*config.RemoteWriteConfig) error {
+	type endpoint struct {
+		client remote.WriteClient
+		cfg    config.MetadataConfig
+	}
+	var endpoints []endpoint
+	for _, cfg := range cfgs {
+		if cfg.ProtobufMessage != config.RemoteWriteProtoMsgV1 || !cfg.MetadataConfig.Send {
+			continue
+		}
+		client, err := remote.NewWriteClient(cfg.Name, &remote.ClientConfig{
+			URL:              cfg.URL,
+			Timeout:          cfg.RemoteTimeout,
+			HTTPClientConfig: cfg.HTTPClientConfig,
+			SigV4Config:      cfg.SigV4Config,
+			AzureADConfig:    cfg.AzureADConfig,
+			GoogleIAMConfig:  cfg.GoogleIAMConfig,
+			Headers:          cfg.Headers,
+			WriteProtoMsg:    config.RemoteWriteProtoMsgV1,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create the metadata client of %s: %w", cfg.URL.Redacted(), err)
+		}
+		endpoints = append(endpoints, endpoint{client: client, cfg: cfg.MetadataConfig})
+	}
+
+	s.Stop()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, e := range endpoints {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.run(ctx, e.client, e.cfg)
+		}()
+	}
+	return nil
+}
+
+// Stop stops sending metadata.
+func (s *metadataSender) Stop() {
+	s.mut.Lock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.mut.Unlock()
+	s.wg.Wait()
+}
+
+func (s *metadataSender) run(ctx context.Context, client remote.WriteClient, cfg config.MetadataConfig) {
+	ticker := time.NewTicker(time.Duration(cfg.SendInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.send(ctx, client, cfg.MaxSamplesPerSend)
+		}
+	}
+}
+
+// send sends the metadata to the endpoint in batches of at most batchSize
+// entries. Batches which fail to be sent aren't retried, as all the metadata is
+// sent again after the send interval.
+func (s *metadataSender) send(ctx context.Context, client remote.WriteClient, batchSize int) {
+	var (
+		metadata = s.list()
+		sent     = s.sentTotal.WithLabelValues(client.Name(), client.Endpoint())
+		failed   = s.failedTotal.WithLabelValues(client.Name(), client.Endpoint())
+	)
+	if batchSize <= 0 {
+		batchSize = len(metadata)
+	}
+
+	for start := 0; start < len(metadata); start += batchSize {
+		batch := metadata[start:min(start+batchSize, len(metadata))]
+		if err := storeMetadata(ctx, client, batch); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failed.Add(float64(len(batch)))
+			level.Error(s.logger).Log("msg", "failed to send metadata", "url", client.Endpoint(), "count", len(batch), "err", err)
+			continue
+		}
+		sent.Add(float64(len(batch)))
+	}
+}
+
+// storeMetadata sends a Remote-Write 1.0 request which only contains metadata.
+func storeMetadata(ctx context.Context, client remote.WriteClient, metadata []scrape.MetricMetadata) error {
+	req := prompb.WriteRequest{Metadata: make([]prompb.MetricMetadata, 0, len(metadata))}
+	for _, md := range metadata {
+		req.Metadata = append(req.Metadata, prompb.MetricMetadata{
+			MetricFamilyName: md.Metric,
+			Type:             prompb.FromMetadataType(md.Type),
+			Help:             md.Help,
+			Unit:             md.Unit,
+		})
+	}
+	data, err := proto.Marshal(&req)
+	if err != nil {
+		return err
+	}
+	_, err = client.Store(ctx, snappy.Encode(nil, data), 0)
+	return err
+}
@@ -0,0 +1,139 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	common "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/config"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/prometheus/prometheus/storage/remote"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// maxProbeTimeout bounds how long probing an endpoint for Remote-Write 2.0
+// support can block updating the component.
+const maxProbeTimeout = 5 * time.Second
+
+// remoteWrite2ContentType is the Content-Type of Remote-Write 2.0 requests.
+var remoteWrite2ContentType = "application/x-protobuf;proto=" + string(config.RemoteWriteProtoMsgV2)
+
+// protoMsgNegotiator determines the protobuf message sent to each endpoint.
+// Endpoints configured to send Remote-Write 2.0 requests are probed with an
+// empty request, and fall back to Remote-Write 1.0 if they respond with a 415
+// Unsupported Media Type status code, as required by the Remote-Write 2.0
+// specification for receivers which don't support the message.
+//
+// The result of the probe is cached per endpoint URL, so that an endpoint is
+// only probed again when its configuration changes.
+type protoMsgNegotiator struct {
+	logger log.Logger
+	probe  func(ctx context.Context, cfg *config.RemoteWriteConfig) (bool, error)
+
+	// negotiated maps the URL of endpoints to the negotiated message.
+	negotiated map[string]negotiatedProtoMsg
+}
+
+type negotiatedProtoMsg struct {
+	cfg config.RemoteWriteConfig
+	msg config.RemoteWriteProtoMsg
+}
+
+func newProtoMsgNegotiator(logger log.Logger) *protoMsgNegotiator {
+	return &protoMsgNegotiator{
+		logger:     logger,
+		probe:      probeRemoteWrite2,
+		negotiated: make(map[string]negotiatedProtoMsg),
+	}
+}
+
+// Negotiate sets the protobuf message of the endpoints configured to send
+// Remote-Write 2.0 requests to the message they support.
+func (n *protoMsgNegotiator) Negotiate(ctx context.Context, cfgs []*config.RemoteWriteConfig) {
+	negotiated := make(map[string]negotiatedProtoMsg, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.ProtobufMessage != config.RemoteWriteProtoMsgV2 {
+			continue
+		}
+
+		url := cfg.URL.String()
+		if prev, ok := n.negotiated[url]; ok && equalEndpointConfigs(prev.cfg, *cfg) {
+			negotiated[url] = prev
+			cfg.ProtobufMessage = prev.msg
+			continue
+		}
+
+		res := negotiatedProtoMsg{cfg: *cfg, msg: config.RemoteWriteProtoMsgV2}
+		supported, err := n.probe(ctx, cfg)
+		switch {
+		case err != nil:
+			// The endpoint may be temporarily unavailable, so it's not assumed
+			// that it doesn't support Remote-Write 2.0. The endpoint is probed
+			// again on the next update.
+			level.Warn(n.logger).Log("msg", "failed to probe endpoint for Remote-Write 2.0 support, sending Remote-Write 2.0 requests", "url", cfg.URL.Redacted(), "err", err)
+			continue
+		case !supported:
+			level.Warn(n.logger).Log("msg", "endpoint doesn't support Remote-Write 2.0, falling back to Remote-Write 1.0", "url", cfg.URL.Redacted())
+			res.msg = config.RemoteWriteProtoMsgV1
+		}
+		negotiated[url] = res
+		cfg.ProtobufMessage = res.msg
+	}
+	n.negotiated = negotiated
+}
+
+// equalEndpointConfigs reports whether two endpoint configurations would
+// probe the endpoint in the same way.
+func equalEndpointConfigs(a, b config.RemoteWriteConfig) bool {
+	return a.URL.String() == b.URL.String() &&
+		reflect.DeepEqual(a.Headers, b.Headers) &&
+		reflect.DeepEqual(a.HTTPClientConfig, b.HTTPClientConfig)
+}
+
+// probeRemoteWrite2 sends an empty Remote-Write 2.0 request to the endpoint,
+// and reports whether the endpoint accepted its protobuf message.
+func probeRemoteWrite2(ctx context.Context, cfg *config.RemoteWriteConfig) (bool, error) {
+	client, err := common.NewClientFromConfig(cfg.HTTPClientConfig, "remote_storage_write_probe_client")
+	if err != nil {
+		return false, err
+	}
+	defer client.CloseIdleConnections()
+
+	timeout := min(time.Duration(cfg.RemoteTimeout), maxProbeTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	data, err := (&writev2.Request{}).Marshal()
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL.String(), bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return false, err
+	}
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Encoding", string(remote.SnappyBlockCompression))
+	req.Header.Set("Content-Type", remoteWrite2ContentType)
+	req.Header.Set("User-Agent", remote.UserAgent)
+	req.Header.Set(remote.RemoteWriteVersionHeader, remote.RemoteWriteVersion20HeaderValue)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	return resp.StatusCode != http.StatusUnsupportedMediaType, nil
+}
@@ -62,7 +62,9 @@ type Component struct {
 	mut sync.RWMutex
 	cfg Arguments
 
-	receiver *prometheus.Interceptor
+	receiver   *prometheus.Interceptor
+	negotiator *protoMsgNegotiator
+	metadata   *metadataSender
 
 	debugDataPublisher livedebugging.DebugDataPublisher
 }
@@ -85,10 +87,17 @@ func New(o component.Options, c Arguments) (*Component, error) {
 	}
 
 	remoteLogger := log.With(o.Logger, "subcomponent", "rw")
-	remoteStore := remote.NewStorage(remoteLogger, o.Registerer, startTime, o.DataPath, remoteFlushDeadline, nil, false)
+	// The WAL logs metadata records, which Remote-Write 2.0 requests send
+	// alongside the series.
+	remoteStore := remote.NewStorage(remoteLogger, o.Registerer, startTime, o.DataPath, remoteFlushDeadline, nil, true)
 
 	walStorage.SetNotifier(remoteStore)
 
+	metadataSender, err := newMetadataSender(remoteLogger, o.Registerer, walStorage.ListMetadata)
+	if err != nil {
+		return nil, err
+	}
+
 	service, err := o.GetServiceData(labelstore.ServiceName)
 	if err != nil {
 		return nil, err
@@ -106,6 +115,8 @@ func New(o component.Options, c Arguments) (*Component, error) {
 		walStore:           walStorage,
 		remoteStore:        remoteStore,
 		storage:            storage.NewFanout(o.Logger, walStorage, remoteStore),
+		negotiator:         newProtoMsgNegotiator(remoteLogger),
+		metadata:           metadataSender,
 		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
 	}
 	componentID := livedebugging.ComponentID(res.opts.ID)
@@ -207,6 +218,26 @@ func New(o component.Options, c Arguments) (*Component, error) {
 			))
 			return globalRef, nextErr
 		}),
+		prometheus.WithCTZeroSampleHook(func(globalRef storage.SeriesRef, l labels.Labels, t, ct int64, next storage.Appender) (storage.SeriesRef, error) {
+			if res.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			localID := ls.GetLocalRefID(res.opts.ID, uint64(globalRef))
+			newRef, nextErr := next.AppendCTZeroSample(storage.SeriesRef(localID), l, t, ct)
+			if localID == 0 {
+				ls.GetOrAddLink(res.opts.ID, uint64(newRef), l)
+			}
+			res.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+				componentID,
+				livedebugging.PrometheusMetric,
+				1,
+				func() string {
+					return fmt.Sprintf("created_timestamp: ts=%d, labels=%s, created_timestamp=%d", t, l, ct)
+				},
+			))
+			return globalRef, nextErr
+		}),
 	)
 
 	// Immediately export the receiver which remains the same for the component
@@ -228,6 +259,7 @@ var _ component.LiveDebugging = (*Component)(nil)
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		c.exited.Store(true)
+		c.metadata.Stop()
 
 		level.Debug(c.log).Log("msg", "closing storage")
 		err := c.storage.Close()
@@ -318,6 +350,17 @@ func (c *Component) Update(newConfig component.Arguments) error {
 		cfg.Headers[alloyseed.LegacyHeaderName] = uid
 		cfg.Headers[alloyseed.HeaderName] = uid
 	}
+	// Endpoints which don't support Remote-Write 2.0 fall back to
+	// Remote-Write 1.0.
+	c.negotiator.Negotiate(context.Background(), convertedConfig.RemoteWriteConfigs)
+	if err := c.metadata.ApplyConfig(convertedConfig.RemoteWriteConfigs); err != nil {
+		return err
+	}
+	// The metadata is sent by the metadata sender instead of the remote
+	// storage, which can't read it.
+	for _, cfg := range convertedConfig.RemoteWriteConfigs {
+		cfg.MetadataConfig.Send = false
+	}
 	err = c.remoteStore.ApplyConfig(convertedConfig)
 	if err != nil {
 		return err
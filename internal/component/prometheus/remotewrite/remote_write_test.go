@@ -2,17 +2,23 @@ package remotewrite_test
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/snappy"
 	"github.com/grafana/alloy/internal/component/prometheus/remotewrite"
 	"github.com/grafana/alloy/internal/runtime/componenttest"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/alloy/syntax"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/stretchr/testify/require"
 )
@@ -180,6 +186,139 @@ func TestUpdate(t *testing.T) {
 	}})
 }
 
+// TestRemoteWrite2 ensures that Remote-Write 2.0 requests are sent to
+// endpoints which support them, and that endpoints which don't support them
+// receive Remote-Write 1.0 requests.
+func TestRemoteWrite2(t *testing.T) {
+	v2Result := make(chan *writev2.Request, 10)
+	v2Srv := newTestServerV2(t, v2Result, true)
+	defer v2Srv.Close()
+
+	v1Result := make(chan *prompb.WriteRequest, 10)
+	v1Srv := newTestServerV2(t, v1Result, false)
+	defer v1Srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			url              = "%s/api/v1/write"
+			remote_timeout   = "100ms"
+			protobuf_message = "io.prometheus.write.v2.Request"
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+		}
+		endpoint {
+			url              = "%s/api/v1/write"
+			remote_timeout   = "100ms"
+			protobuf_message = "io.prometheus.write.v2.Request"
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+		}
+	`, v2Srv.URL, v1Srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+	lbls := labels.FromStrings("__name__", "requests_total", "job", "test")
+
+	rwExports := tc.Exports().(remotewrite.Exports)
+	app := rwExports.Receiver.Appender(t.Context())
+	_, err = app.Append(0, lbls, sampleTimestamp, 12)
+	require.NoError(t, err)
+	_, err = app.UpdateMetadata(0, lbls, metadata.Metadata{Type: model.MetricTypeCounter, Unit: "requests", Help: "Total requests."})
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	select {
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for Remote-Write 2.0 request")
+	case req := <-v2Result:
+		require.Len(t, req.Timeseries, 1)
+		ts := req.Timeseries[0]
+		b := labels.NewScratchBuilder(0)
+		require.Equal(t, lbls, ts.ToLabels(&b, req.Symbols))
+		require.Equal(t, []writev2.Sample{{Timestamp: sampleTimestamp, Value: 12}}, ts.Samples)
+		// Only the type is checked, as the vendored Prometheus queue manager
+		// writes the unit to the help reference of Remote-Write 2.0 metadata.
+		require.Equal(t, model.MetricTypeCounter, ts.ToMetadata(req.Symbols).Type)
+	}
+
+	assertReceived(t, v1Result, []prompb.TimeSeries{{
+		Labels: []prompb.Label{
+			{Name: "__name__", Value: "requests_total"},
+			{Name: "job", Value: "test"},
+		},
+		Samples: []prompb.Sample{
+			{Timestamp: sampleTimestamp, Value: 12},
+		},
+	}})
+}
+
+func TestMetadata(t *testing.T) {
+	writeResult := make(chan *prompb.WriteRequest, 100)
+	srv := newTestServer(t, writeResult)
+	defer srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+
+			metadata_config {
+				send_interval = "100ms"
+			}
+		}
+	`, srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	lbls := labels.FromStrings("__name__", "requests_total", "job", "api")
+	appender := tc.Exports().(remotewrite.Exports).Receiver.Appender(t.Context())
+	ref, err := appender.Append(0, lbls, time.Now().Add(time.Minute).UnixMilli(), 12)
+	require.NoError(t, err)
+	_, err = appender.UpdateMetadata(ref, lbls, metadata.Metadata{Type: model.MetricTypeCounter, Unit: "requests", Help: "Total requests."})
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	// The metadata is sent in separate Remote-Write 1.0 requests.
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case <-timeout:
+			require.FailNow(t, "timed out waiting for metadata")
+		case res := <-writeResult:
+			if len(res.Metadata) == 0 {
+				continue
+			}
+			require.Empty(t, res.Timeseries)
+			require.Equal(t, []prompb.MetricMetadata{{
+				Type:             prompb.MetricMetadata_COUNTER,
+				MetricFamilyName: "requests_total",
+				Help:             "Total requests.",
+				Unit:             "requests",
+			}}, res.Metadata)
+			return
+		}
+	}
+}
+
 func assertReceived(t *testing.T, writeResult chan *prompb.WriteRequest, expect []prompb.TimeSeries) {
 	select {
 	case <-time.After(time.Minute):
@@ -205,6 +344,57 @@ func newTestServer(t *testing.T, writeResult chan *prompb.WriteRequest) *httptes
 	}))
 }
 
+// newTestServerV2 creates a remote_write server which forwards Remote-Write
+// 2.0 requests to the writeResult channel if supportsV2 is true, and Remote-Write
+// 1.0 requests otherwise. A server which doesn't support Remote-Write 2.0
+// rejects Remote-Write 2.0 requests with a 415 status code.
+func newTestServerV2[T any](t *testing.T, writeResult chan T, supportsV2 bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isV2 := strings.Contains(r.Header.Get("Content-Type"), "proto=io.prometheus.write.v2.Request")
+		if isV2 != supportsV2 {
+			http.Error(w, "unsupported protobuf message", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		decompressed, err := snappy.Decode(nil, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var req any
+		if isV2 {
+			var v2Req writev2.Request
+			err = v2Req.Unmarshal(decompressed)
+			req = &v2Req
+		} else {
+			var v1Req prompb.WriteRequest
+			err = v1Req.Unmarshal(decompressed)
+			req = &v1Req
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Empty requests are sent to probe the server.
+		if isV2 && len(req.(*writev2.Request).Timeseries) == 0 {
+			return
+		}
+
+		select {
+		case writeResult <- req.(T):
+		default:
+			require.Fail(t, "failed to send remote_write result over channel")
+		}
+	}))
+}
+
 func sendMetric(
 	t *testing.T,
 	tc *componenttest.Controller,
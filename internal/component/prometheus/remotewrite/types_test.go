@@ -204,6 +204,27 @@ func TestAlloyConfig(t *testing.T) {
 				c.RemoteWriteConfigs[0].ProtobufMessage = config.RemoteWriteProtoMsgV1
 			}),
 		},
+		{
+			testName: "RemoteWrite2",
+			cfg: `
+			endpoint {
+				url              = "http://0.0.0.0:11111/api/v1/write"
+				protobuf_message = "io.prometheus.write.v2.Request"
+			}
+			`,
+			expectedCfg: expectedCfg(func(c *config.Config) {
+				c.RemoteWriteConfigs[0].ProtobufMessage = config.RemoteWriteProtoMsgV2
+			}),
+		},
+		{
+			testName: "UnknownProtobufMessage",
+			cfg: `
+			endpoint {
+				url              = "http://0.0.0.0:11111/api/v1/write"
+				protobuf_message = "io.prometheus.write.v3.Request"
+			}`,
+			errorMsg: "unknown remote write protobuf message io.prometheus.write.v3.Request",
+		},
 		{
 			testName: "TooManyAuth1",
 			cfg: `
@@ -0,0 +1,87 @@
+package rules
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/prometheus/common/model"
+	promconfig "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
+
+	types "github.com/grafana/alloy/internal/component/common/config"
+)
+
+// AlertmanagerOptions configures an Alertmanager the alerts are sent to.
+type AlertmanagerOptions struct {
+	URL              string                  `alloy:"url,attr"`
+	Timeout          time.Duration           `alloy:"timeout,attr,optional"`
+	HTTPClientConfig *types.HTTPClientConfig `alloy:",squash"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (o *AlertmanagerOptions) SetToDefault() {
+	*o = AlertmanagerOptions{
+		Timeout:          10 * time.Second,
+		HTTPClientConfig: types.CloneDefaultHTTPClientConfig(),
+	}
+}
+
+// Validate implements syntax.Validator.
+func (o *AlertmanagerOptions) Validate() error {
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return fmt.Errorf("invalid Alertmanager URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid Alertmanager URL %q: the scheme must be http or https", o.URL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid Alertmanager URL %q: the host is missing", o.URL)
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	if o.HTTPClientConfig != nil {
+		return o.HTTPClientConfig.Validate()
+	}
+	return nil
+}
+
+// notifierConfig returns the configuration of the notifier sending the alerts
+// to the Alertmanagers, and the target groups of the Alertmanagers. The
+// Alertmanagers aren't discovered, so each one has a static target group.
+func notifierConfig(alertmanagers []*AlertmanagerOptions, externalLabels map[string]string) (*promconfig.Config, map[string][]*targetgroup.Group, error) {
+	cfg := &promconfig.Config{
+		GlobalConfig: promconfig.GlobalConfig{ExternalLabels: labels.FromMap(externalLabels)},
+	}
+	targetGroups := make(map[string][]*targetgroup.Group, len(alertmanagers))
+
+	for i, am := range alertmanagers {
+		u, err := url.Parse(am.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		httpClientConfig := types.CloneDefaultHTTPClientConfig()
+		if am.HTTPClientConfig != nil {
+			httpClientConfig = am.HTTPClientConfig
+		}
+
+		cfg.AlertingConfig.AlertmanagerConfigs = append(cfg.AlertingConfig.AlertmanagerConfigs, &promconfig.AlertmanagerConfig{
+			HTTPClientConfig: *httpClientConfig.Convert(),
+			Scheme:           u.Scheme,
+			PathPrefix:       u.Path,
+			Timeout:          model.Duration(am.Timeout),
+			APIVersion:       promconfig.AlertmanagerAPIVersionV2,
+		})
+		// The keys of the target groups match the keys of
+		// AlertmanagerConfigs.ToMap.
+		targetGroups[fmt.Sprintf("config-%d", i)] = []*targetgroup.Group{{
+			Targets: []model.LabelSet{{model.AddressLabel: model.LabelValue(u.Host)}},
+			Source:  am.URL,
+		}}
+	}
+	return cfg, targetGroups, nil
+}
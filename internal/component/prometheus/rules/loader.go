@@ -0,0 +1,59 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+	promrules "github.com/prometheus/prometheus/rules"
+	"sigs.k8s.io/yaml" // Used for CRD compatibility instead of gopkg.in/yaml.v2
+)
+
+// prometheusRuleKind is the kind of the PrometheusRule custom resource.
+const prometheusRuleKind = "PrometheusRule"
+
+// parseRuleGroups parses rule groups in the Prometheus rule file format, or
+// the rule groups of a PrometheusRule custom resource.
+func parseRuleGroups(content string) (*rulefmt.RuleGroups, error) {
+	buf := []byte(content)
+
+	var resource struct {
+		Kind string `json:"kind"`
+	}
+	if err := yaml.Unmarshal(buf, &resource); err == nil && resource.Kind == prometheusRuleKind {
+		var crd promv1.PrometheusRule
+		if err := yaml.Unmarshal(buf, &crd); err != nil {
+			return nil, fmt.Errorf("failed to parse PrometheusRule: %w", err)
+		}
+		if buf, err = yaml.Marshal(crd.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, errs := rulefmt.Parse(buf)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return groups, nil
+}
+
+// groupLoader loads the rule groups of the component into the rules manager.
+// The groups are set before they're loaded with Manager.Update.
+type groupLoader struct {
+	groups *rulefmt.RuleGroups
+}
+
+var _ promrules.GroupLoader = (*groupLoader)(nil)
+
+func (l *groupLoader) Load(_ string) (*rulefmt.RuleGroups, []error) {
+	if l.groups == nil {
+		return &rulefmt.RuleGroups{}, nil
+	}
+	return l.groups, nil
+}
+
+func (l *groupLoader) Parse(query string) (parser.Expr, error) {
+	return parser.ParseExpr(query)
+}
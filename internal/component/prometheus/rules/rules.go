@@ -0,0 +1,335 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/notifier"
+	"github.com/prometheus/prometheus/promql"
+	promrules "github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/prometheus"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/labelstore"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "prometheus.rules",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// truncateInterval is how often the samples older than the retention are
+// removed from the local storage.
+var truncateInterval = time.Minute
+
+// Arguments holds values which are used to configure the prometheus.rules
+// component.
+type Arguments struct {
+	// Where the series recorded by the rules should be forwarded to.
+	ForwardTo []storage.Appendable `alloy:"forward_to,attr"`
+
+	// The rule groups, in the Prometheus rule file format or as a
+	// PrometheusRule resource.
+	Rules string `alloy:"rules,attr"`
+
+	// The evaluation interval of the groups which don't set one.
+	EvaluationInterval time.Duration `alloy:"evaluation_interval,attr,optional"`
+
+	// How long the samples are kept in the local storage.
+	Retention time.Duration `alloy:"retention,attr,optional"`
+
+	// The labels added to the alerts sent to the Alertmanagers.
+	ExternalLabels map[string]string `alloy:"external_labels,attr,optional"`
+
+	// The Alertmanagers the alerts are sent to.
+	Alertmanagers []*AlertmanagerOptions `alloy:"alertmanager,block,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (arg *Arguments) SetToDefault() {
+	*arg = Arguments{
+		EvaluationInterval: time.Minute,
+		Retention:          time.Hour,
+	}
+}
+
+// Validate implements syntax.Validator.
+func (arg *Arguments) Validate() error {
+	if arg.EvaluationInterval <= 0 {
+		return fmt.Errorf("evaluation_interval must be greater than 0")
+	}
+	if arg.Retention <= 0 {
+		return fmt.Errorf("retention must be greater than 0")
+	}
+	if _, err := parseRuleGroups(arg.Rules); err != nil {
+		return fmt.Errorf("invalid rules: %w", err)
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the prometheus.rules component.
+type Exports struct {
+	Receiver storage.Appendable `alloy:"receiver,attr"`
+}
+
+// Component implements the prometheus.rules component.
+type Component struct {
+	opts     component.Options
+	fanout   *prometheus.Fanout
+	storage  *localStorage
+	loader   *groupLoader
+	manager  *promrules.Manager
+	notifier *notifier.Manager
+	// targets sends the target groups of the Alertmanagers to the notifier.
+	targets chan map[string][]*targetgroup.Group
+	cancel  context.CancelFunc
+
+	mut  sync.RWMutex
+	args Arguments
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new prometheus.rules component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	data, err := o.GetServiceData(labelstore.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	ls := data.(labelstore.LabelStore)
+
+	localStorage, err := newLocalStorage(filepath.Join(o.DataPath, "head"), o.Registerer, log.With(o.Logger, "subcomponent", "storage"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the local storage: %w", err)
+	}
+
+	// The recorded series are also appended to the local storage, so that
+	// rules can use the series recorded by other rules.
+	fanout := prometheus.NewFanout(nil, o.ID, o.Registerer, ls)
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		Logger:               log.With(o.Logger, "subcomponent", "engine"),
+		Reg:                  o.Registerer,
+		MaxSamples:           50_000_000,
+		Timeout:              2 * time.Minute,
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+
+	notifierManager := notifier.NewManager(&notifier.Options{
+		QueueCapacity: 10_000,
+		Registerer:    o.Registerer,
+	}, log.With(o.Logger, "subcomponent", "notifier"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loader := &groupLoader{}
+	c := &Component{
+		opts:     o,
+		fanout:   fanout,
+		storage:  localStorage,
+		loader:   loader,
+		notifier: notifierManager,
+		targets:  make(chan map[string][]*targetgroup.Group, 1),
+		cancel:   cancel,
+		manager: promrules.NewManager(&promrules.ManagerOptions{
+			Appendable:  fanout,
+			Queryable:   localStorage,
+			QueryFunc:   promrules.EngineQueryFunc(engine, localStorage),
+			NotifyFunc:  promrules.SendAlerts(notifierManager, ""),
+			Context:     ctx,
+			Logger:      log.With(o.Logger, "subcomponent", "manager"),
+			Registerer:  o.Registerer,
+			GroupLoader: loader,
+			// The defaults of Prometheus.
+			OutageTolerance: time.Hour,
+			ForGracePeriod:  10 * time.Minute,
+			ResendDelay:     time.Minute,
+		}),
+	}
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: localStorage})
+
+	// The manager must be running before its groups are updated or stopped,
+	// since groups which never started evaluating can't be stopped. The
+	// notifier must be running before alerts are sent.
+	go c.manager.Run()
+	go c.notifier.Run(c.targets)
+
+	if err := c.Update(args); err != nil {
+		c.manager.Stop()
+		c.notifier.Stop()
+		cancel()
+		_ = localStorage.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.manager.Stop()
+		c.notifier.Stop()
+		c.cancel()
+		if err := c.storage.Close(); err != nil {
+			level.Warn(c.opts.Logger).Log("msg", "failed to close the local storage", "err", err)
+		}
+	}()
+
+	ticker := time.NewTicker(truncateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			c.mut.RLock()
+			retention := c.args.Retention
+			c.mut.RUnlock()
+
+			if err := c.storage.truncate(now, retention); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to truncate the local storage", "err", err)
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+	groups, err := parseRuleGroups(newArgs.Rules)
+	if err != nil {
+		return err
+	}
+
+	notifierCfg, targetGroups, err := notifierConfig(newArgs.Alertmanagers, newArgs.ExternalLabels)
+	if err != nil {
+		return err
+	}
+	if err := c.notifier.ApplyConfig(notifierCfg); err != nil {
+		return err
+	}
+	// Replace the target groups which weren't received by the notifier yet.
+	select {
+	case <-c.targets:
+	default:
+	}
+	c.targets <- targetGroups
+
+	c.fanout.UpdateChildren(append([]storage.Appendable{c.storage}, newArgs.ForwardTo...))
+	c.loader.groups = groups
+	// The component ID is used as the file name of the groups.
+	externalLabels := labels.FromMap(newArgs.ExternalLabels)
+	if err := c.manager.Update(newArgs.EvaluationInterval, []string{c.opts.ID}, externalLabels, "", promrules.DefaultEvalIterationFunc); err != nil {
+		return err
+	}
+	c.args = newArgs
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	var info DebugInfo
+	for _, g := range c.manager.RuleGroups() {
+		group := DebugInfoGroup{
+			Name:               g.Name(),
+			Interval:           g.Interval(),
+			LastEvaluation:     g.GetLastEvaluation(),
+			EvaluationDuration: g.GetEvaluationTime(),
+		}
+		for _, r := range g.Rules() {
+			rule := DebugInfoRule{
+				Expr:           r.Query().String(),
+				Health:         string(r.Health()),
+				LastEvaluation: r.GetEvaluationTimestamp(),
+			}
+			if err := r.LastError(); err != nil {
+				rule.LastError = err.Error()
+			}
+			switch r := r.(type) {
+			case *promrules.AlertingRule:
+				rule.Alert = r.Name()
+				rule.State = r.State().String()
+				for _, a := range r.ActiveAlerts() {
+					rule.ActiveAlerts = append(rule.ActiveAlerts, DebugInfoAlert{
+						Labels:   a.Labels.String(),
+						State:    a.State.String(),
+						Value:    a.Value,
+						ActiveAt: a.ActiveAt,
+						FiredAt:  a.FiredAt,
+					})
+				}
+				slices.SortFunc(rule.ActiveAlerts, func(a, b DebugInfoAlert) int { return strings.Compare(a.Labels, b.Labels) })
+			default:
+				rule.Record = r.Name()
+			}
+			group.Rules = append(group.Rules, rule)
+		}
+		info.Groups = append(info.Groups, group)
+	}
+	slices.SortFunc(info.Groups, func(a, b DebugInfoGroup) int { return strings.Compare(a.Name, b.Name) })
+	return info
+}
+
+// DebugInfo is the debug information of the prometheus.rules component.
+type DebugInfo struct {
+	Groups []DebugInfoGroup `alloy:"group,block,optional"`
+}
+
+// DebugInfoGroup is the debug information of a rule group.
+type DebugInfoGroup struct {
+	Name               string          `alloy:"name,attr"`
+	Interval           time.Duration   `alloy:"interval,attr"`
+	LastEvaluation     time.Time       `alloy:"last_evaluation,attr,optional"`
+	EvaluationDuration time.Duration   `alloy:"evaluation_duration,attr"`
+	Rules              []DebugInfoRule `alloy:"rule,block,optional"`
+}
+
+// DebugInfoRule is the debug information of a recording or an alerting rule.
+type DebugInfoRule struct {
+	Record         string           `alloy:"record,attr,optional"`
+	Alert          string           `alloy:"alert,attr,optional"`
+	Expr           string           `alloy:"expr,attr"`
+	Health         string           `alloy:"health,attr"`
+	LastError      string           `alloy:"last_error,attr,optional"`
+	LastEvaluation time.Time        `alloy:"last_evaluation,attr,optional"`
+	State          string           `alloy:"state,attr,optional"`
+	ActiveAlerts   []DebugInfoAlert `alloy:"active_alert,block,optional"`
+}
+
+// DebugInfoAlert is the debug information of a pending or firing alert.
+type DebugInfoAlert struct {
+	Labels   string    `alloy:"labels,attr"`
+	State    string    `alloy:"state,attr"`
+	Value    float64   `alloy:"value,attr"`
+	ActiveAt time.Time `alloy:"active_at,attr"`
+	FiredAt  time.Time `alloy:"fired_at,attr,optional"`
+}
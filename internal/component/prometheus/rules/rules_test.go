@@ -0,0 +1,323 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/internal/util/testappender"
+	"github.com/grafana/alloy/syntax"
+)
+
+const testRules = `
+groups:
+  - name: requests
+    rules:
+      - record: job:requests_total:sum
+        expr: sum by (job) (requests_total)
+      - record: requests_total:sum
+        expr: sum(job:requests_total:sum)
+`
+
+func TestRules(t *testing.T) {
+	c, app := newTestComponent(t, testRules)
+
+	now := time.Now()
+	in := c.storage.Appender(t.Context())
+	appendFloat(t, in, now.Add(-30*time.Second), 1, "job", "api", "pod", "a")
+	appendFloat(t, in, now.Add(-30*time.Second), 2, "job", "api", "pod", "b")
+	appendFloat(t, in, now.Add(-30*time.Second), 4, "job", "web", "pod", "c")
+	require.NoError(t, in.Commit())
+
+	groups := c.manager.RuleGroups()
+	require.Len(t, groups, 1)
+	groups[0].Eval(t.Context(), now)
+
+	samples := app.CollectedSamples()
+	require.Len(t, samples, 3)
+	require.Equal(t, 3.0, samples[`{__name__="job:requests_total:sum", job="api"}`].Value)
+	require.Equal(t, 4.0, samples[`{__name__="job:requests_total:sum", job="web"}`].Value)
+	// The series recorded by the first rule are used by the second one.
+	require.Equal(t, 7.0, samples[`{__name__="requests_total:sum"}`].Value)
+	require.Equal(t, now.UnixMilli(), samples[`{__name__="requests_total:sum"}`].Timestamp)
+
+	info := c.DebugInfo().(DebugInfo)
+	require.Len(t, info.Groups, 1)
+	require.Equal(t, "requests", info.Groups[0].Name)
+	require.Len(t, info.Groups[0].Rules, 2)
+	require.Equal(t, "ok", info.Groups[0].Rules[0].Health)
+}
+
+func TestRules_Update(t *testing.T) {
+	c, app := newTestComponent(t, testRules)
+
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(fmt.Sprintf("forward_to = []\nrules = %q", `
+groups:
+  - name: pods
+    interval: 30m
+    rules:
+      - record: pods
+        expr: count(requests_total)
+`)), &args))
+	args.ForwardTo = []storage.Appendable{testappender.ConstantAppendable{Inner: app}}
+	require.NoError(t, c.Update(args))
+
+	groups := c.manager.RuleGroups()
+	require.Len(t, groups, 1)
+	require.Equal(t, "pods", groups[0].Name())
+	require.Equal(t, 30*time.Minute, groups[0].Interval())
+}
+
+func TestRules_Alerts(t *testing.T) {
+	received := make(chan []byte, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/alertmanager/api/v2/alerts" {
+			received <- body
+		}
+	}))
+	defer srv.Close()
+
+	c, app := newTestComponent(t, `
+groups:
+  - name: alerts
+    rules:
+      - alert: HighRequests
+        expr: sum by (job) (requests_total) > 2
+        labels:
+          severity: critical
+      - alert: PendingRequests
+        expr: sum by (job) (requests_total) > 2
+        for: 1h
+`)
+
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(fmt.Sprintf(`
+		forward_to = []
+		evaluation_interval = "1h"
+		rules = %q
+		external_labels = { cluster = "edge" }
+		alertmanager {
+			url = %q
+		}
+	`, c.args.Rules, srv.URL+"/alertmanager")), &args))
+	args.ForwardTo = []storage.Appendable{testappender.ConstantAppendable{Inner: app}}
+	require.NoError(t, c.Update(args))
+	// The alerts sent before the notifier discovered the Alertmanager are
+	// dropped.
+	require.Eventually(t, func() bool {
+		return len(c.notifier.Alertmanagers()) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	now := time.Now()
+	in := c.storage.Appender(t.Context())
+	appendFloat(t, in, now.Add(-30*time.Second), 1, "job", "api", "pod", "a")
+	appendFloat(t, in, now.Add(-30*time.Second), 4, "job", "web", "pod", "c")
+	require.NoError(t, in.Commit())
+
+	groups := c.manager.RuleGroups()
+	require.Len(t, groups, 1)
+	// The ALERTS series are only recorded once the for state of the alerts is
+	// restored, which the group does after its first evaluation.
+	groups[0].RestoreForState(now)
+	groups[0].Eval(t.Context(), now)
+
+	// Only the firing alert is sent to the Alertmanager.
+	select {
+	case body := <-received:
+		var alerts []struct {
+			Labels map[string]string `json:"labels"`
+		}
+		require.NoError(t, json.Unmarshal(body, &alerts))
+		require.Len(t, alerts, 1)
+		require.Equal(t, map[string]string{
+			"alertname": "HighRequests",
+			"cluster":   "edge",
+			"job":       "web",
+			"severity":  "critical",
+		}, alerts[0].Labels)
+	case <-time.After(10 * time.Second):
+		t.Fatal("no alerts received by the Alertmanager")
+	}
+
+	// The ALERTS series are forwarded.
+	samples := app.CollectedSamples()
+	require.Contains(t, samples, `{__name__="ALERTS", alertname="HighRequests", alertstate="firing", job="web", severity="critical"}`)
+	require.Contains(t, samples, `{__name__="ALERTS", alertname="PendingRequests", alertstate="pending", job="web"}`)
+
+	info := c.DebugInfo().(DebugInfo)
+	require.Len(t, info.Groups, 1)
+	rules := info.Groups[0].Rules
+	require.Len(t, rules, 2)
+	require.Equal(t, "HighRequests", rules[0].Alert)
+	require.Equal(t, "firing", rules[0].State)
+	require.Len(t, rules[0].ActiveAlerts, 1)
+	require.Equal(t, "firing", rules[0].ActiveAlerts[0].State)
+	require.Equal(t, 4.0, rules[0].ActiveAlerts[0].Value)
+	require.Equal(t, "pending", rules[1].State)
+	require.Len(t, rules[1].ActiveAlerts, 1)
+	require.True(t, rules[1].ActiveAlerts[0].FiredAt.IsZero())
+}
+
+func TestRules_OutOfOrderSamples(t *testing.T) {
+	c, _ := newTestComponent(t, testRules)
+
+	now := time.Now()
+	in := c.storage.Appender(t.Context())
+	appendFloat(t, in, now, 1, "job", "api")
+	require.NoError(t, in.Commit())
+
+	// Out of order samples are dropped without failing the append.
+	in = c.storage.Appender(t.Context())
+	appendFloat(t, in, now.Add(-time.Minute), 2, "job", "api")
+	require.NoError(t, in.Commit())
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := map[string]struct {
+		rules string
+		err   string
+	}{
+		"recording rules": {
+			rules: testRules,
+		},
+		"PrometheusRule resource": {
+			rules: `
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: requests
+spec:
+  groups:
+    - name: requests
+      rules:
+        - record: job:requests_total:sum
+          expr: sum by (job) (requests_total)
+`,
+		},
+		"alerting rule": {
+			rules: `
+groups:
+  - name: alerts
+    rules:
+      - alert: HighErrorRate
+        expr: rate(errors_total[5m]) > 1
+        for: 5m
+`,
+		},
+		"invalid expression": {
+			rules: `
+groups:
+  - name: requests
+    rules:
+      - record: job:requests_total:sum
+        expr: sum by (job) (
+`,
+			err: "invalid rules",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte(fmt.Sprintf("forward_to = []\nrules = %q", tc.rules)), &args)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestAlertmanagerOptions_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg string
+		err string
+	}{
+		"valid": {
+			cfg: `url = "https://alertmanager:9093/prefix"`,
+		},
+		"invalid scheme": {
+			cfg: `url = "ftp://alertmanager:9093"`,
+			err: "the scheme must be http or https",
+		},
+		"missing host": {
+			cfg: `url = "http://"`,
+			err: "the host is missing",
+		},
+		"invalid timeout": {
+			cfg: "url = \"http://alertmanager:9093\"\ntimeout = \"0s\"",
+			err: "timeout must be greater than 0",
+		},
+		"invalid HTTP client config": {
+			cfg: "url = \"http://alertmanager:9093\"\nbearer_token = \"token\"\nbearer_token_file = \"/token\"",
+			err: "at most one of",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var opts AlertmanagerOptions
+			err := syntax.Unmarshal([]byte(tc.cfg), &opts)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func newTestComponent(t *testing.T, rules string) (*Component, testappender.CollectingAppender) {
+	var args Arguments
+	// The groups are evaluated by the tests, and are only evaluated once an
+	// hour in the background.
+	require.NoError(t, syntax.Unmarshal([]byte(fmt.Sprintf("forward_to = []\nevaluation_interval = \"1h\"\nrules = %q", rules)), &args))
+
+	app := testappender.NewCollectingAppender()
+	args.ForwardTo = []storage.Appendable{testappender.ConstantAppendable{Inner: app}}
+
+	c, err := New(component.Options{
+		ID:             "prometheus.rules.test",
+		Logger:         util.TestAlloyLogger(t),
+		DataPath:       t.TempDir(),
+		OnStateChange:  func(e component.Exports) {},
+		Registerer:     prom.NewRegistry(),
+		GetServiceData: getServiceData,
+	}, args)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.manager.Stop()
+		c.notifier.Stop()
+		require.NoError(t, c.storage.Close())
+	})
+	return c, app
+}
+
+func appendFloat(t *testing.T, app storage.Appender, ts time.Time, v float64, lbls ...string) {
+	_, err := app.Append(0, labels.FromStrings(append([]string{"__name__", "requests_total"}, lbls...)...), ts.UnixMilli(), v)
+	require.NoError(t, err)
+}
+
+func getServiceData(name string) (interface{}, error) {
+	switch name {
+	case labelstore.ServiceName:
+		return labelstore.New(nil, prom.DefaultRegisterer), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	"github.com/go-kit/log"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"go.uber.org/atomic"
+)
+
+// errStorageClosed is returned when samples are appended after the component
+// exited.
+var errStorageClosed = errors.New("the local storage is closed")
+
+// localStorage is an in-memory TSDB head storing the samples the rules are
+// evaluated against.
+type localStorage struct {
+	head            *tsdb.Head
+	closed          atomic.Bool
+	samplesRejected prometheus_client.Counter
+}
+
+var (
+	_ storage.Appendable = (*localStorage)(nil)
+	_ storage.Queryable  = (*localStorage)(nil)
+)
+
+// newLocalStorage creates a head which memory-maps its full chunks in dir.
+// The content of dir is deleted, since the head isn't persisted.
+func newLocalStorage(dir string, reg prometheus_client.Registerer, logger log.Logger) (*localStorage, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	s := &localStorage{
+		samplesRejected: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "prometheus_rules_samples_rejected_total",
+			Help: "Total number of samples rejected by the local storage because they're out of order or too old.",
+		}),
+	}
+	if err := reg.Register(s.samplesRejected); err != nil {
+		return nil, err
+	}
+
+	opts := tsdb.DefaultHeadOptions()
+	opts.ChunkDirRoot = dir
+	opts.EnableNativeHistograms.Store(true)
+	head, err := tsdb.NewHead(reg, logger, nil, nil, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := head.Init(math.MinInt64); err != nil {
+		_ = head.Close()
+		return nil, err
+	}
+	s.head = head
+	return s, nil
+}
+
+// Appender implements storage.Appendable.
+func (s *localStorage) Appender(ctx context.Context) storage.Appender {
+	return &localAppender{storage: s, head: s.head.Appender(ctx)}
+}
+
+// Querier implements storage.Queryable.
+func (s *localStorage) Querier(mint, maxt int64) (storage.Querier, error) {
+	return tsdb.NewBlockQuerier(tsdb.NewRangeHead(s.head, mint, maxt), mint, maxt)
+}
+
+// truncate removes the samples older than the retention.
+func (s *localStorage) truncate(now time.Time, retention time.Duration) error {
+	return s.head.Truncate(now.Add(-retention).UnixMilli())
+}
+
+// Close closes the head, after which samples can't be appended anymore.
+func (s *localStorage) Close() error {
+	s.closed.Store(true)
+	return s.head.Close()
+}
+
+// localAppender appends samples to the head of the local storage.
+//
+// The series references of the samples come from the label store, and are
+// different from the references of the head series, so they're ignored.
+// Samples which the head can't accept because they're out of order or too old
+// are dropped, so that they don't fail the appends of other components.
+// Exemplars, metadata and created timestamps aren't used by the rules, and
+// are dropped.
+type localAppender struct {
+	storage *localStorage
+	head    storage.Appender
+}
+
+var _ storage.Appender = (*localAppender)(nil)
+
+func (a *localAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	if a.storage.closed.Load() {
+		return 0, errStorageClosed
+	}
+	_, err := a.head.Append(0, l, t, v)
+	return ref, a.filterError(err)
+}
+
+func (a *localAppender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	if a.storage.closed.Load() {
+		return 0, errStorageClosed
+	}
+	_, err := a.head.AppendHistogram(0, l, t, h, fh)
+	return ref, a.filterError(err)
+}
+
+func (a *localAppender) AppendExemplar(ref storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *localAppender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, _ metadata.Metadata) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *localAppender) AppendCTZeroSample(ref storage.SeriesRef, _ labels.Labels, _, _ int64) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *localAppender) Commit() error {
+	return a.head.Commit()
+}
+
+func (a *localAppender) Rollback() error {
+	return a.head.Rollback()
+}
+
+// filterError drops the errors of samples the head can't accept.
+func (a *localAppender) filterError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrOutOfOrderSample),
+		errors.Is(err, storage.ErrOutOfBounds),
+		errors.Is(err, storage.ErrTooOldSample),
+		errors.Is(err, storage.ErrDuplicateSampleForTimestamp):
+		a.storage.samplesRejected.Inc()
+		return nil
+	default:
+		return err
+	}
+}
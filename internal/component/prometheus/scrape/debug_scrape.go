@@ -0,0 +1,333 @@
+package scrape
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+// DebugScrapeResult is the result of a one-off scrape of a target, performed
+// on demand to debug the target.
+type DebugScrapeResult struct {
+	JobName         string            `json:"job"`
+	URL             string            `json:"url"`
+	Labels          map[string]string `json:"labels"`
+	Duration        string            `json:"duration"`
+	StatusCode      int               `json:"status_code,omitempty"`
+	ResponseHeaders http.Header       `json:"response_headers,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	Samples         []DebugSample     `json:"samples"`
+	// SampleLimitExceeded reports whether the target exposes more samples than
+	// sample_limit, in which case the whole scrape is rejected.
+	SampleLimitExceeded bool `json:"sample_limit_exceeded"`
+}
+
+// DebugSample is a sample parsed from the response of a debug scrape, with
+// the labels it would be forwarded with.
+type DebugSample struct {
+	Labels    string `json:"labels"`
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+	// Rejected is the reason why the sample would be rejected by the limits
+	// of the component.
+	Rejected string `json:"rejected,omitempty"`
+}
+
+// Handler implements http.Component. It serves debug scrapes of the active
+// targets of the component at /scrape?url=<target URL>.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scrape", c.handleDebugScrape)
+	return mux
+}
+
+func (c *Component) handleDebugScrape(w http.ResponseWriter, r *http.Request) {
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		http.Error(w, "missing url query parameter", http.StatusBadRequest)
+		return
+	}
+
+	job, target := c.findActiveTarget(targetURL)
+	if target == nil {
+		http.Error(w, fmt.Sprintf("target %q is not scraped by this component", targetURL), http.StatusNotFound)
+		return
+	}
+
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	res := c.debugScrape(r.Context(), args, job, target)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// findActiveTarget returns the active target with the given URL, and the
+// name of its job.
+func (c *Component) findActiveTarget(targetURL string) (string, *scrape.Target) {
+	for job, targets := range c.scraper.TargetsActive() {
+		for _, t := range targets {
+			if t != nil && t.URL().String() == targetURL {
+				return job, t
+			}
+		}
+	}
+	return "", nil
+}
+
+// debugScrape scrapes the target once, and reports the samples exposed by the
+// target with the labels they would be forwarded with, and the samples which
+// would be rejected by the limits of the component. The samples aren't
+// forwarded.
+func (c *Component) debugScrape(ctx context.Context, args Arguments, job string, target *scrape.Target) DebugScrapeResult {
+	lb := labels.NewScratchBuilder(0)
+	res := DebugScrapeResult{
+		JobName: job,
+		URL:     target.URL().String(),
+		Labels:  target.Labels(&lb).Map(),
+		Samples: []DebugSample{},
+	}
+
+	start := time.Now()
+	body, contentType, err := c.debugScrapeTarget(ctx, args, job, target, &res)
+	res.Duration = time.Since(start).String()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	if err := parseDebugScrape(args, target, body, contentType, start.UnixMilli(), &res); err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// debugScrapeTarget requests the target the same way the scrape loop does,
+// and returns the uncompressed response body and its content type.
+func (c *Component) debugScrapeTarget(ctx context.Context, args Arguments, job string, target *scrape.Target, res *DebugScrapeResult) ([]byte, string, error) {
+	sc := getPromScrapeConfigs(job, args)
+	client, err := config_util.NewClientFromConfig(sc.HTTPClientConfig, sc.JobName, c.httpClientOptions...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	defer client.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(ctx, args.ScrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL().String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Add("Accept", acceptHeader(sc.ScrapeProtocols))
+	req.Header.Add("Accept-Encoding", "gzip")
+	req.Header.Set("User-Agent", scrape.UserAgent)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(args.ScrapeTimeout.Seconds(), 'f', -1, 64))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	res.StatusCode = resp.StatusCode
+	res.ResponseHeaders = resp.Header
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+
+	bodySizeLimit := int64(args.BodySizeLimit)
+	if bodySizeLimit <= 0 {
+		bodySizeLimit = math.MaxInt64
+	}
+	var r io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipr, err := gzip.NewReader(bufio.NewReader(resp.Body))
+		if err != nil {
+			return nil, "", err
+		}
+		defer gzipr.Close()
+		r = gzipr
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, bodySizeLimit))
+	if err != nil {
+		return nil, "", err
+	}
+	if n >= bodySizeLimit {
+		return nil, "", fmt.Errorf("body size limit exceeded (limit: %d bytes)", bodySizeLimit)
+	}
+	return buf.Bytes(), resp.Header.Get("Content-Type"), nil
+}
+
+// parseDebugScrape parses the response body of a debug scrape into samples,
+// applying the target labels and the limits as the scrape loop does.
+func parseDebugScrape(args Arguments, target *scrape.Target, body []byte, contentType string, defTime int64, res *DebugScrapeResult) error {
+	// Like the scrape loop, the Prometheus text format is used if the content
+	// type is invalid.
+	p, _ := textparse.New(body, contentType, args.ScrapeClassicHistograms, labels.NewSymbolTable())
+
+	var (
+		lset    labels.Labels
+		samples int
+	)
+	for {
+		et, err := p.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var (
+			parsedTs *int64
+			val      string
+		)
+		switch et {
+		case textparse.EntryType, textparse.EntryHelp, textparse.EntryUnit, textparse.EntryComment:
+			continue
+		case textparse.EntryHistogram:
+			if !args.ScrapeNativeHistograms {
+				continue
+			}
+			var (
+				h  *histogram.Histogram
+				fh *histogram.FloatHistogram
+			)
+			_, parsedTs, h, fh = p.Histogram()
+			if h != nil {
+				val = h.String()
+			} else if fh != nil {
+				val = fh.String()
+			}
+		default:
+			var v float64
+			_, parsedTs, v = p.Series()
+			val = strconv.FormatFloat(v, 'g', -1, 64)
+			if !value.IsStaleNaN(v) {
+				samples++
+			}
+		}
+		ts := defTime
+		if args.HonorTimestamps && parsedTs != nil {
+			ts = *parsedTs
+		}
+
+		p.Metric(&lset)
+		lset = mutateSampleLabels(lset, target, args.HonorLabels)
+
+		sample := DebugSample{
+			Labels:    lset.String(),
+			Timestamp: ts,
+			Value:     val,
+		}
+		switch {
+		case !lset.Has(labels.MetricName):
+			sample.Rejected = "__name__ label is missing"
+		case args.SampleLimit > 0 && samples > int(args.SampleLimit):
+			res.SampleLimitExceeded = true
+			sample.Rejected = fmt.Sprintf("sample_limit exceeded (limit: %d)", args.SampleLimit)
+		default:
+			if err := verifyLabelLimits(lset, args); err != nil {
+				sample.Rejected = err.Error()
+			}
+		}
+		res.Samples = append(res.Samples, sample)
+	}
+}
+
+// mutateSampleLabels adds the labels of the target to the labels of a scraped
+// sample. With honorLabels, the labels of the sample are kept when they
+// conflict with the target labels. Otherwise, they're renamed with the
+// exported_ prefix.
+func mutateSampleLabels(lset labels.Labels, target *scrape.Target, honorLabels bool) labels.Labels {
+	lb := labels.NewBuilder(lset)
+
+	if honorLabels {
+		target.LabelsRange(func(l labels.Label) {
+			if !lset.Has(l.Name) {
+				lb.Set(l.Name, l.Value)
+			}
+		})
+		return lb.Labels()
+	}
+
+	var conflicting []labels.Label
+	target.LabelsRange(func(l labels.Label) {
+		if v := lset.Get(l.Name); v != "" {
+			conflicting = append(conflicting, labels.Label{Name: l.Name, Value: v})
+		}
+		lb.Set(l.Name, l.Value)
+	})
+	for _, l := range conflicting {
+		name := l.Name
+		for {
+			name = model.ExportedLabelPrefix + name
+			if lb.Get(name) == "" {
+				lb.Set(name, l.Value)
+				break
+			}
+		}
+	}
+	return lb.Labels()
+}
+
+// verifyLabelLimits returns an error if the labels of a sample exceed the
+// label limits of the component.
+func verifyLabelLimits(lset labels.Labels, args Arguments) error {
+	met := lset.Get(labels.MetricName)
+	if args.LabelLimit > 0 && lset.Len() > int(args.LabelLimit) {
+		return fmt.Errorf("label_limit exceeded (metric: %.50s, number of labels: %d, limit: %d)", met, lset.Len(), args.LabelLimit)
+	}
+
+	return lset.Validate(func(l labels.Label) error {
+		if args.LabelNameLengthLimit > 0 && len(l.Name) > int(args.LabelNameLengthLimit) {
+			return fmt.Errorf("label_name_length_limit exceeded (metric: %.50s, label name: %.50s, length: %d, limit: %d)", met, l.Name, len(l.Name), args.LabelNameLengthLimit)
+		}
+		if args.LabelValueLengthLimit > 0 && len(l.Value) > int(args.LabelValueLengthLimit) {
+			return fmt.Errorf("label_value_length_limit exceeded (metric: %.50s, label name: %.50s, value: %.50q, length: %d, limit: %d)", met, l.Name, l.Value, len(l.Value), args.LabelValueLengthLimit)
+		}
+		return nil
+	})
+}
+
+// acceptHeader returns the Accept header of scrape requests for the scrape
+// protocols, in order of preference.
+func acceptHeader(sps []config.ScrapeProtocol) string {
+	var vals []string
+	weight := len(config.ScrapeProtocolsHeaders) + 1
+	for _, sp := range sps {
+		vals = append(vals, fmt.Sprintf("%s;q=0.%d", config.ScrapeProtocolsHeaders[sp], weight))
+		weight--
+	}
+	// Default match anything.
+	vals = append(vals, fmt.Sprintf("*/*;q=0.%d", weight))
+	return strings.Join(vals, ",")
+}
@@ -201,12 +201,15 @@ type Component struct {
 	dtMutex            sync.Mutex
 	distributedTargets *discovery.DistributedTargets
 
+	httpClientOptions []config_util.HTTPClientOption
+
 	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
 var (
 	_ component.Component     = (*Component)(nil)
 	_ component.LiveDebugging = (*Component)(nil)
+	_ http.Component          = (*Component)(nil)
 )
 
 // New creates a new prometheus.scrape component.
@@ -241,6 +244,9 @@ func New(o component.Options, args Arguments) (*Component, error) {
 			config_util.WithDialContextFunc(httpData.DialFunc),
 		},
 		EnableNativeHistogramsIngestion: args.ScrapeNativeHistograms,
+		// Pass the metadata of the scraped metrics to the appenders of the
+		// downstream components, so that it can be sent with the samples.
+		AppendMetadata: true,
 	}
 
 	unregisterer := util.WrapWithUnregisterer(o.Registerer)
@@ -270,6 +276,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		targetsGauge:        targetsGauge,
 		movedTargetsCounter: movedTargetsCounter,
 		unregisterer:        unregisterer,
+		httpClientOptions:   scrapeOptions.HTTPClientOptions,
 	}
 
 	interceptor := c.newInterceptor(ls)
@@ -2,17 +2,24 @@ package scrape
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/ckit/memconn"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/alloy/internal/component"
@@ -23,6 +30,7 @@ import (
 	"github.com/grafana/alloy/internal/service/labelstore"
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/internal/util/testappender"
 	"github.com/grafana/alloy/syntax"
 )
 
@@ -194,6 +202,70 @@ func TestForwardingToAppendable(t *testing.T) {
 	require.Equal(t, receivedSamples, sample)
 }
 
+func TestForwardingMetadata(t *testing.T) {
+	reg := prometheus_client.NewRegistry()
+	counter := prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "test_requests_total",
+		Help: "Total number of requests.",
+	})
+	reg.MustRegister(counter)
+	counter.Inc()
+
+	srv := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(fmt.Sprintf(`
+		targets         = [{ __address__ = %q }]
+		forward_to      = []
+		scrape_interval = "100ms"
+		scrape_timeout  = "85ms"
+	`, u.Host)), &args))
+	app := testappender.NewCollectingAppender()
+	args.ForwardTo = []storage.Appendable{testappender.ConstantAppendable{Inner: app}}
+
+	opts := component.Options{
+		Logger:     util.TestAlloyLogger(t),
+		Registerer: prometheus_client.NewRegistry(),
+		GetServiceData: func(name string) (interface{}, error) {
+			switch name {
+			case http_service.ServiceName:
+				return http_service.Data{
+					HTTPListenAddr:   "localhost:12345",
+					MemoryListenAddr: "alloy.internal:1245",
+					BaseHTTPPath:     "/",
+					DialFunc:         (&net.Dialer{}).DialContext,
+				}, nil
+			case cluster.ServiceName:
+				return cluster.Mock(), nil
+			case labelstore.ServiceName:
+				return labelstore.New(nil, prometheus_client.DefaultRegisterer), nil
+			case livedebugging.ServiceName:
+				return livedebugging.NewLiveDebugging(), nil
+			default:
+				return nil, fmt.Errorf("service %q does not exist", name)
+			}
+		},
+	}
+
+	s, err := New(opts, args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go s.Run(ctx)
+
+	// The metadata of the scraped metrics is forwarded along with the samples.
+	series := labels.FromStrings("__name__", "test_requests_total", "instance", u.Host).String()
+	require.EventuallyWithT(t, func(t *assert.CollectT) {
+		require.Equal(t, metadata.Metadata{
+			Type: model.MetricTypeCounter,
+			Help: "Total number of requests.",
+		}, app.CollectedMetadata()[series])
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
 // TestCustomDialer ensures that prometheus.scrape respects the custom dialer
 // given to it.
 func TestCustomDialer(t *testing.T) {
@@ -278,3 +350,96 @@ func TestValidateScrapeConfig(t *testing.T) {
 	err := syntax.Unmarshal([]byte(exampleAlloyConfig), &args)
 	require.ErrorContains(t, err, "scrape_timeout (20s) greater than scrape_interval (10s) for scrape config with job name \"local\"")
 }
+
+func TestDebugScrape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = fmt.Fprint(w, `
+# TYPE http_requests_total counter
+http_requests_total{job="app",code="200"} 10
+http_requests_total{job="app",code="500",path="/a/very/very/long/path"} 2
+up 1
+`)
+	}))
+	defer srv.Close()
+
+	opts := component.Options{
+		Logger:     util.TestAlloyLogger(t),
+		Registerer: prometheus_client.NewRegistry(),
+		GetServiceData: func(name string) (interface{}, error) {
+			switch name {
+			case http_service.ServiceName:
+				return http_service.Data{DialFunc: (&net.Dialer{}).DialContext}, nil
+			case cluster.ServiceName:
+				return cluster.Mock(), nil
+			case labelstore.ServiceName:
+				return labelstore.New(nil, prometheus_client.DefaultRegisterer), nil
+			case livedebugging.ServiceName:
+				return livedebugging.NewLiveDebugging(), nil
+			default:
+				return nil, fmt.Errorf("service %q does not exist", name)
+			}
+		},
+	}
+
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(fmt.Sprintf(`
+		targets                  = [{ "__address__" = %q }]
+		forward_to               = []
+		job_name                 = "test"
+		sample_limit             = 2
+		label_value_length_limit = 20
+	`, addr)), &args))
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() {
+		require.NoError(t, c.Run(ctx))
+	}()
+
+	targetURL := srv.URL + "/metrics"
+	require.Eventually(t, func() bool {
+		_, target := c.findActiveTarget(targetURL)
+		return target != nil
+	}, 15*time.Second, 100*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scrape?url="+url.QueryEscape(targetURL), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res DebugScrapeResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, "test", res.JobName)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/plain; version=0.0.4", res.ResponseHeaders.Get("Content-Type"))
+	require.Empty(t, res.Error)
+	require.True(t, res.SampleLimitExceeded)
+
+	for i := range res.Samples {
+		res.Samples[i].Timestamp = 0
+	}
+	require.Equal(t, []DebugSample{
+		{
+			Labels: fmt.Sprintf(`{__name__="http_requests_total", code="200", exported_job="app", instance=%q, job="test"}`, addr),
+			Value:  "10",
+		},
+		{
+			Labels:   fmt.Sprintf(`{__name__="http_requests_total", code="500", exported_job="app", instance=%q, job="test", path="/a/very/very/long/path"}`, addr),
+			Value:    "2",
+			Rejected: `label_value_length_limit exceeded (metric: http_requests_total, label name: path, value: "/a/very/very/long/path", length: 22, limit: 20)`,
+		},
+		{
+			Labels:   fmt.Sprintf(`{__name__="up", instance=%q, job="test"}`, addr),
+			Value:    "1",
+			Rejected: "sample_limit exceeded (limit: 2)",
+		},
+	}, res.Samples)
+
+	rec = httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scrape?url=http://unknown/metrics", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
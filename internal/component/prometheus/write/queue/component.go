@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"reflect"
 	"sync"
@@ -9,7 +10,6 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
-	promqueue "github.com/grafana/walqueue/implementations/prometheus"
 	"github.com/prometheus/prometheus/storage"
 )
 
@@ -26,36 +26,43 @@ func init() {
 }
 
 func NewComponent(opts component.Options, args Arguments) (*Queue, error) {
+	walMetrics, err := newWALMetrics(opts.Registerer)
+	if err != nil {
+		return nil, err
+	}
 	s := &Queue{
-		opts:      opts,
-		args:      args,
-		log:       opts.Logger,
-		endpoints: map[string]promqueue.Queue{},
+		opts:       opts,
+		args:       args,
+		log:        opts.Logger,
+		endpoints:  map[string]*endpoint{},
+		walMetrics: walMetrics,
 	}
 	s.opts.OnStateChange(Exports{Receiver: s})
-	err := s.createEndpoints()
+	err = s.createEndpoints()
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-// Queue is a queue based WAL used to send data to a remote_write endpoint. Queue supports replaying
-// and TTLs.
+// Queue is a queue based WAL used to send data to a remote_write endpoint. Queue supports replaying,
+// TTLs and retention limits for the WAL of each endpoint.
 type Queue struct {
-	mut       sync.RWMutex
-	args      Arguments
-	opts      component.Options
-	log       log.Logger
-	endpoints map[string]promqueue.Queue
-	ctx       context.Context
+	mut        sync.RWMutex
+	args       Arguments
+	opts       component.Options
+	log        log.Logger
+	endpoints  map[string]*endpoint
+	walMetrics *walMetrics
+	// ctx is the context of Run, nil until Run is called. Endpoints created
+	// before are started by Run.
+	ctx context.Context
 }
 
 // Run starts the component, blocking until ctx is canceled or the component
 // suffers a fatal error. Run is guaranteed to be called exactly once per
 // Component.
 func (s *Queue) Run(ctx context.Context) error {
-	s.ctx = ctx
 	defer func() {
 		s.mut.Lock()
 		defer s.mut.Unlock()
@@ -64,13 +71,19 @@ func (s *Queue) Run(ctx context.Context) error {
 			ep.Stop()
 		}
 	}()
+
+	s.mut.Lock()
+	s.ctx = ctx
 	for _, ep := range s.endpoints {
 		// If any of these fail to start thats a problem.
 		err := ep.Start(ctx)
 		if err != nil {
+			s.mut.Unlock()
 			return err
 		}
 	}
+	s.mut.Unlock()
+
 	<-ctx.Done()
 	return nil
 }
@@ -108,15 +121,17 @@ func (s *Queue) Update(args component.Arguments) error {
 			// TODO drain the signals and re-add them
 			ep.Stop()
 		}
-		nativeCfg := epCfg.ToNativeType()
 		// Create
-		end, err := promqueue.NewQueue(epCfg.Name, nativeCfg, filepath.Join(s.opts.DataPath, epCfg.Name, "wal"), uint32(s.args.Persistence.MaxSignalsToBatch), s.args.Persistence.BatchInterval, s.args.TTL, s.opts.Registerer, "alloy", s.opts.Logger)
+		end, err := newEndpoint(epCfg.Name, epCfg.ToNativeType(), s.walDir(epCfg.Name), s.args, s.walMetrics, s.opts.Registerer, s.opts.Logger)
 		if err != nil {
 			return err
 		}
-		err = end.Start(s.ctx)
-		if err != nil {
-			return err
+		// The endpoint is started by Run if it's not running yet.
+		if s.ctx != nil {
+			err = end.Start(s.ctx)
+			if err != nil {
+				return err
+			}
 		}
 		s.endpoints[epCfg.Name] = end
 	}
@@ -130,16 +145,21 @@ func (s *Queue) Update(args component.Arguments) error {
 
 func (s *Queue) createEndpoints() error {
 	for _, ep := range s.args.Endpoints {
-		nativeCfg := ep.ToNativeType()
-		end, err := promqueue.NewQueue(ep.Name, nativeCfg, filepath.Join(s.opts.DataPath, ep.Name, "wal"), uint32(s.args.Persistence.MaxSignalsToBatch), s.args.Persistence.BatchInterval, s.args.TTL, s.opts.Registerer, "alloy", s.opts.Logger)
+		end, err := newEndpoint(ep.Name, ep.ToNativeType(), s.walDir(ep.Name), s.args, s.walMetrics, s.opts.Registerer, s.opts.Logger)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to create endpoint %s: %w", ep.Name, err)
 		}
 		s.endpoints[ep.Name] = end
 	}
 	return nil
 }
 
+// walDir returns the directory of the WAL of the endpoint. Each endpoint has its own WAL, which
+// is read independently of the WAL of the other endpoints.
+func (s *Queue) walDir(endpoint string) string {
+	return filepath.Join(s.opts.DataPath, endpoint, "wal")
+}
+
 // Appender returns a new appender for the storage. The implementation
 // can choose whether or not to use the context, for deadlines or to check
 // for errors.
@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	promqueue "github.com/grafana/walqueue/implementations/prometheus"
+	"github.com/grafana/walqueue/network"
+	"github.com/grafana/walqueue/serialization"
+	"github.com/grafana/walqueue/stats"
+	"github.com/grafana/walqueue/types"
+	v1 "github.com/grafana/walqueue/types/v1"
+	v2 "github.com/grafana/walqueue/types/v2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// zstdDecoder is a reusable decoder for zstd decompression.
+var zstdDecoder, _ = zstd.NewReader(nil)
+
+// endpoint sends the signals appended to it to a remote_write endpoint
+// through its WAL.
+//
+// It's assembled like the queue of walqueue's prometheus implementation, from
+// the same serializer and network client, but with fileQueue as WAL so that
+// the replay order and the retention limits are applied when the WAL is read.
+type endpoint struct {
+	network            types.NetworkClient
+	files              *fileQueue
+	serializer         types.PrometheusSerializer
+	logger             log.Logger
+	ttl                time.Duration
+	stats              *promqueue.Stats
+	metaStats          *promqueue.Stats
+	externalLabels     map[string]string
+	requestMoreSignals chan types.RequestMoreSignals[types.Datum]
+}
+
+func newEndpoint(name string, cc types.ConnectionConfig, dir string, args Arguments, walMetrics *walMetrics, registerer prometheus.Registerer, logger log.Logger) (*endpoint, error) {
+	statshub := stats.NewStats()
+	reg := prometheus.WrapRegistererWith(prometheus.Labels{"endpoint": name}, registerer)
+	seriesStats := promqueue.NewStats("alloy", "queue_series", false, reg, statshub)
+	seriesStats.SeriesBackwardsCompatibility(reg)
+	metaStats := promqueue.NewStats("alloy", "queue_metadata", true, reg, statshub)
+	metaStats.MetaBackwardsCompatibility(reg)
+	requestMoreSignals := make(chan types.RequestMoreSignals[types.Datum], 1)
+
+	networkClient, err := network.New(cc, logger, statshub, requestMoreSignals)
+	if err != nil {
+		return nil, err
+	}
+	files, err := newFileQueue(name, dir, args.Persistence, walMetrics, statshub, logger)
+	if err != nil {
+		return nil, err
+	}
+	serializer, err := serialization.NewSerializer(types.SerializerConfig{
+		MaxSignalsInBatch: uint32(args.Persistence.MaxSignalsToBatch),
+		FlushFrequency:    args.Persistence.BatchInterval,
+	}, files, statshub.SendSerializerStats, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint{
+		network:            networkClient,
+		files:              files,
+		serializer:         serializer,
+		logger:             logger,
+		ttl:                args.TTL,
+		stats:              seriesStats,
+		metaStats:          metaStats,
+		externalLabels:     cc.ExternalLabels,
+		requestMoreSignals: requestMoreSignals,
+	}, nil
+}
+
+func (e *endpoint) Start(ctx context.Context) error {
+	e.network.Start(ctx)
+	e.files.Start(ctx)
+	if err := e.serializer.Start(ctx); err != nil {
+		e.network.Stop()
+		e.files.Stop()
+		return err
+	}
+	go e.run(ctx)
+	return nil
+}
+
+func (e *endpoint) Stop() {
+	e.network.Stop()
+	e.files.Stop()
+	e.serializer.Stop()
+	e.stats.Unregister()
+	e.metaStats.Unregister()
+}
+
+// Appender returns a new appender writing to the WAL.
+func (e *endpoint) Appender(ctx context.Context) storage.Appender {
+	return serialization.NewAppender(ctx, 0, e.serializer, e.externalLabels, e.logger)
+}
+
+// run sends the signals of the next files of the WAL each time the network
+// client requests more signals.
+func (e *endpoint) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req, ok := <-e.requestMoreSignals:
+			if !ok {
+				return
+			}
+			for {
+				f, ok := e.files.next(ctx)
+				if !ok {
+					return
+				}
+				meta, buf, err := e.files.read(f)
+				if err != nil {
+					level.Error(e.logger).Log("msg", "unable to get file contents", "name", f.path, "err", err)
+					continue
+				}
+				// Files without any signals, generally because they can't be
+				// read, are skipped.
+				if items := e.deserialize(meta, buf); len(items) > 0 {
+					req.Response <- items
+					break
+				}
+			}
+		}
+	}
+}
+
+// deserialize returns the signals of a file of the WAL, without the series
+// which exceed the TTL.
+func (e *endpoint) deserialize(meta map[string]string, buf []byte) []types.Datum {
+	compressedBytes := len(buf)
+	var (
+		uncompressedBuf []byte
+		err             error
+	)
+
+	// Files without compression type were compressed with snappy.
+	switch compressionType := meta["compression"]; compressionType {
+	case "zstd":
+		uncompressedBuf, err = zstdDecoder.DecodeAll(buf, nil)
+	case "", "snappy":
+		uncompressedBuf, err = snappy.Decode(nil, buf)
+	default:
+		level.Error(e.logger).Log("msg", "unknown compression type", "type", compressionType)
+		return nil
+	}
+	if err != nil {
+		level.Debug(e.logger).Log("msg", "error decoding", "err", err)
+		return nil
+	}
+
+	defer func() {
+		fileID, err := strconv.Atoi(meta["file_id"])
+		if err != nil {
+			fileID = -1
+		}
+		e.stats.UpdateSerializer(types.SerializerStats{
+			FileIDRead:            fileID,
+			UncompressedBytesRead: len(uncompressedBuf),
+			CompressedBytesRead:   compressedBytes,
+		})
+	}()
+
+	var s types.Unmarshaller
+	switch version := types.FileFormat(meta["version"]); version {
+	case types.AlloyFileVersionV1:
+		s = v1.GetSerializer()
+	case types.AlloyFileVersionV2:
+		s = v2.NewFormat()
+	default:
+		level.Error(e.logger).Log("msg", "invalid version found for deserialization", "version", version)
+		return nil
+	}
+	items, err := s.Unmarshal(meta, uncompressedBuf)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "error deserializing", "err", err, "format", meta["version"])
+	}
+
+	pending := make([]types.Datum, 0, len(items))
+	for _, item := range items {
+		switch item := item.(type) {
+		case types.MetricDatum:
+			// Series exceeding the TTL are dropped instead of being sent.
+			if time.Since(time.UnixMilli(item.TimeStampMS())) > e.ttl {
+				item.Free()
+				e.stats.NetworkTTLDrops.Inc()
+				continue
+			}
+			pending = append(pending, item)
+		case types.MetadataDatum:
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/grafana/alloy/syntax/alloytypes"
 	"github.com/grafana/walqueue/types"
 	"github.com/prometheus/common/version"
@@ -16,6 +17,7 @@ func defaultArgs() Arguments {
 		Persistence: Persistence{
 			MaxSignalsToBatch: 10_000,
 			BatchInterval:     5 * time.Second,
+			ReplayOrder:       ReplayOldestFirst,
 		},
 	}
 }
@@ -32,8 +34,23 @@ type Persistence struct {
 	MaxSignalsToBatch int `alloy:"max_signals_to_batch,attr,optional"`
 	// How often to flush to the file queue if BatchSize isn't met.
 	BatchInterval time.Duration `alloy:"batch_interval,attr,optional"`
+	// The maximum size of the WAL of each endpoint, 0 means no limit.
+	MaxSize units.Base2Bytes `alloy:"max_size,attr,optional"`
+	// The maximum age of the WAL files of each endpoint, 0 means no limit.
+	MaxAge time.Duration `alloy:"max_age,attr,optional"`
+	// The order in which the WAL files found on startup are sent.
+	ReplayOrder string `alloy:"replay_order,attr,optional"`
 }
 
+const (
+	// ReplayOldestFirst sends the WAL files found on startup from the oldest
+	// to the newest.
+	ReplayOldestFirst = "oldest_first"
+	// ReplayNewestFirst sends the WAL files found on startup from the newest
+	// to the oldest.
+	ReplayNewestFirst = "newest_first"
+)
+
 type Exports struct {
 	Receiver storage.Appendable `alloy:"receiver,attr"`
 }
@@ -68,6 +85,17 @@ func (cc *EndpointConfig) SetToDefault() {
 }
 
 func (r *Arguments) Validate() error {
+	if r.Persistence.MaxSize < 0 {
+		return fmt.Errorf("max_size must not be negative")
+	}
+	if r.Persistence.MaxAge < 0 {
+		return fmt.Errorf("max_age must not be negative")
+	}
+	switch r.Persistence.ReplayOrder {
+	case "", ReplayOldestFirst, ReplayNewestFirst:
+	default:
+		return fmt.Errorf("replay_order must be %q or %q", ReplayOldestFirst, ReplayNewestFirst)
+	}
 	for _, conn := range r.Endpoints {
 		if conn.BatchCount <= 0 {
 			return fmt.Errorf("batch_count must be greater than 0")
@@ -101,3 +101,56 @@ func TestParralelismConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPersistence_Validate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		alloyCfg       string
+		expectedErrMsg string
+	}{
+		{
+			name: "default config is valid",
+		},
+		{
+			name: "retention limits",
+			alloyCfg: `
+			persistence {
+				max_size     = "10GiB"
+				max_age      = "24h"
+				replay_order = "newest_first"
+			}`,
+		},
+		{
+			name: "negative max_age",
+			alloyCfg: `
+			persistence {
+				max_age = "-1h"
+			}`,
+			expectedErrMsg: "max_age must not be negative",
+		},
+		{
+			name: "invalid replay_order",
+			alloyCfg: `
+			persistence {
+				replay_order = "random"
+			}`,
+			expectedErrMsg: `replay_order must be "oldest_first" or "newest_first"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte(tc.alloyCfg+`
+			endpoint "default" {
+				url = "http://example.com"
+			}`), &args)
+
+			if tc.expectedErrMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedErrMsg)
+			}
+		})
+	}
+}
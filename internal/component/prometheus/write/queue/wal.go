@@ -0,0 +1,321 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/walqueue/filequeue"
+	"github.com/grafana/walqueue/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const committedSuffix = ".committed"
+
+// walCheckInterval is how often the WAL of each endpoint is checked.
+var walCheckInterval = 15 * time.Second
+
+// walMetrics are the metrics of the WAL of each endpoint.
+type walMetrics struct {
+	backlogBytes    *prometheus.GaugeVec
+	backlogFiles    *prometheus.GaugeVec
+	oldestSampleAge *prometheus.GaugeVec
+	droppedBytes    *prometheus.CounterVec
+}
+
+func newWALMetrics(reg prometheus.Registerer) (*walMetrics, error) {
+	m := &walMetrics{
+		backlogBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "alloy_queue_wal_backlog_bytes",
+			Help: "Size in bytes of the WAL files which weren't sent to the endpoint yet.",
+		}, []string{"endpoint"}),
+		backlogFiles: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "alloy_queue_wal_backlog_files",
+			Help: "Number of WAL files which weren't sent to the endpoint yet.",
+		}, []string{"endpoint"}),
+		oldestSampleAge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "alloy_queue_wal_oldest_sample_age_seconds",
+			Help: "Age in seconds of the oldest WAL file which wasn't sent to the endpoint yet.",
+		}, []string{"endpoint"}),
+		droppedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alloy_queue_wal_dropped_bytes_total",
+			Help: "Total size in bytes of the WAL files deleted because of the retention limits.",
+		}, []string{"endpoint", "reason"}),
+	}
+	for _, c := range []prometheus.Collector{m.backlogBytes, m.backlogFiles, m.oldestSampleAge, m.droppedBytes} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// deleteEndpoint removes the series of the endpoint.
+func (m *walMetrics) deleteEndpoint(endpoint string) {
+	m.backlogBytes.DeleteLabelValues(endpoint)
+	m.backlogFiles.DeleteLabelValues(endpoint)
+	m.oldestSampleAge.DeleteLabelValues(endpoint)
+	m.droppedBytes.DeletePartialMatch(prometheus.Labels{"endpoint": endpoint})
+}
+
+// fileQueue is the WAL of an endpoint. Like the file queue of walqueue, it
+// implements types.FileStorage by storing each batch of signals written by
+// the serializer in a file named <id>.committed, and the files left by a
+// previous run are sent again on startup.
+//
+// Unlike the file queue of walqueue, files are only read when the endpoint
+// requests more signals, so that the queue decides the order files are sent
+// in, and the files exceeding the retention limits are deleted before they're
+// read.
+type fileQueue struct {
+	endpoint    string
+	dir         string
+	newestFirst bool
+	maxSize     int64
+	maxAge      time.Duration
+	metrics     *walMetrics
+	stats       types.StatsHub
+	logger      log.Logger
+	cancel      context.CancelFunc
+
+	mut         sync.Mutex
+	files       []walFile // Files which weren't read yet, ordered by ID.
+	maxID       int
+	replayMaxID int           // The maximum ID of the files found on startup.
+	added       chan struct{} // Signaled when a file is added.
+}
+
+var _ types.FileStorage = (*fileQueue)(nil)
+
+// newFileQueue returns the file queue of the WAL in dir, with the files left
+// by a previous run.
+func newFileQueue(endpoint, dir string, p Persistence, metrics *walMetrics, stats types.StatsHub, logger log.Logger) (*fileQueue, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	files, err := walFiles(dir, committedSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &fileQueue{
+		endpoint:    endpoint,
+		dir:         dir,
+		newestFirst: p.ReplayOrder == ReplayNewestFirst,
+		maxSize:     int64(p.MaxSize),
+		maxAge:      p.MaxAge,
+		metrics:     metrics,
+		stats:       stats,
+		logger:      log.With(logger, "endpoint", endpoint),
+		files:       files,
+		added:       make(chan struct{}, 1),
+	}
+	if len(files) > 0 {
+		q.maxID = files[len(files)-1].id
+		q.replayMaxID = q.maxID
+	}
+	return q, nil
+}
+
+// Start checks the retention limits of the WAL every walCheckInterval until
+// Stop is called or ctx is canceled.
+func (q *fileQueue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(walCheckInterval)
+		defer ticker.Stop()
+		for {
+			q.check(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops checking the WAL and removes the metrics of the endpoint.
+func (q *fileQueue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.metrics.deleteEndpoint(q.endpoint)
+}
+
+// Store writes the data to a new file of the WAL.
+func (q *fileQueue) Store(_ context.Context, meta map[string]string, data []byte) error {
+	q.mut.Lock()
+	q.maxID++
+	id := q.maxID
+	q.mut.Unlock()
+
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	meta["file_id"] = strconv.Itoa(id)
+	buf, err := (&filequeue.Record{Meta: meta, Data: data}).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(q.dir, strconv.Itoa(id)+committedSuffix)
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		return err
+	}
+	q.stats.SendSerializerStats(types.SerializerStats{FileIDWritten: id})
+
+	q.mut.Lock()
+	f := walFile{id: id, path: path, size: int64(len(buf)), modTime: time.Now()}
+	i, _ := slices.BinarySearchFunc(q.files, id, func(f walFile, id int) int { return f.id - id })
+	q.files = slices.Insert(q.files, i, f)
+	q.mut.Unlock()
+
+	select {
+	case q.added <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// next removes the next file to send from the queue, waiting for one to be
+// written if the queue is empty. With the newest first replay order, the
+// files found on startup are sent from the newest to the oldest, before the
+// files written afterwards. It returns false if ctx is canceled.
+func (q *fileQueue) next(ctx context.Context) (walFile, bool) {
+	for {
+		q.mut.Lock()
+		if len(q.files) > 0 {
+			i := 0
+			if q.newestFirst {
+				// The index of the newest file found on startup, if any is
+				// left, or of the oldest file written afterwards.
+				i, _ = slices.BinarySearchFunc(q.files, q.replayMaxID+1, func(f walFile, id int) int { return f.id - id })
+				i = max(i-1, 0)
+			}
+			f := q.files[i]
+			q.files = slices.Delete(q.files, i, i+1)
+			q.mut.Unlock()
+			return f, true
+		}
+		q.mut.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return walFile{}, false
+		case <-q.added:
+		}
+	}
+}
+
+// read returns the content of the file, which is deleted.
+func (q *fileQueue) read(f walFile) (map[string]string, []byte, error) {
+	defer func() {
+		if err := os.Remove(f.path); err != nil {
+			level.Error(q.logger).Log("msg", "unable to delete file", "file", f.path, "err", err)
+		}
+	}()
+
+	buf, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r filequeue.Record
+	if _, err := r.UnmarshalMsg(buf); err != nil {
+		return nil, nil, err
+	}
+	return r.Meta, r.Data, nil
+}
+
+// check deletes the oldest files exceeding the retention limits, and updates
+// the backlog metrics with the remaining files.
+func (q *fileQueue) check(now time.Time) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	var total int64
+	for _, f := range q.files {
+		total += f.size
+	}
+	for len(q.files) > 0 {
+		oldest := q.files[0]
+		var reason string
+		switch {
+		case q.maxAge > 0 && now.Sub(oldest.modTime) > q.maxAge:
+			reason = "max_age"
+		case q.maxSize > 0 && total > q.maxSize:
+			reason = "max_size"
+		}
+		if reason == "" {
+			break
+		}
+		// The file is removed from the queue even if it can't be deleted,
+		// so that it's not sent.
+		if err := os.Remove(oldest.path); err != nil {
+			level.Warn(q.logger).Log("msg", "failed to delete WAL file", "file", oldest.path, "err", err)
+		}
+		level.Debug(q.logger).Log("msg", "deleted WAL file exceeding the retention limits", "file", oldest.path, "reason", reason)
+		q.metrics.droppedBytes.WithLabelValues(q.endpoint, reason).Add(float64(oldest.size))
+		total -= oldest.size
+		q.files = q.files[1:]
+	}
+
+	q.metrics.backlogBytes.WithLabelValues(q.endpoint).Set(float64(total))
+	q.metrics.backlogFiles.WithLabelValues(q.endpoint).Set(float64(len(q.files)))
+	var age float64
+	if len(q.files) > 0 {
+		age = max(now.Sub(q.files[0].modTime).Seconds(), 0)
+	}
+	q.metrics.oldestSampleAge.WithLabelValues(q.endpoint).Set(age)
+}
+
+// walFile is a file of the WAL of an endpoint.
+type walFile struct {
+	id      int
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// walFiles returns the files of dir named <id><suffix>, ordered by ID.
+func walFiles(dir, suffix string) ([]walFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []walFile
+	for _, e := range entries {
+		idStr, ok := strings.CutSuffix(e.Name(), suffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			// The file was deleted since the directory was read.
+			continue
+		}
+		files = append(files, walFile{
+			id:      id,
+			path:    filepath.Join(dir, e.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	slices.SortFunc(files, func(a, b walFile) int { return a.id - b.id })
+	return files, nil
+}
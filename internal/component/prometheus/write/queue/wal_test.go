@@ -0,0 +1,202 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/walqueue/stats"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// writeWALFile writes a WAL file with the ID, size and modification time.
+func writeWALFile(t *testing.T, dir string, id int, size int, modTime time.Time) {
+	t.Helper()
+	name := filepath.Join(dir, strconv.Itoa(id)+committedSuffix)
+	require.NoError(t, os.WriteFile(name, make([]byte, size), 0644))
+	require.NoError(t, os.Chtimes(name, modTime, modTime))
+}
+
+func walFileIDs(t *testing.T, dir string) []int {
+	t.Helper()
+	files, err := walFiles(dir, committedSuffix)
+	require.NoError(t, err)
+	ids := make([]int, 0, len(files))
+	for _, f := range files {
+		ids = append(ids, f.id)
+	}
+	return ids
+}
+
+func TestFileQueue_Check(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name            string
+		persistence     Persistence
+		expectedIDs     []int
+		expectedBytes   float64
+		expectedAge     float64
+		expectedDropped map[string]float64
+	}{
+		{
+			name:          "no limits",
+			expectedIDs:   []int{1, 2, 3, 4},
+			expectedBytes: 400,
+			expectedAge:   4 * 60 * 60,
+		},
+		{
+			name:            "max_size",
+			persistence:     Persistence{MaxSize: 250},
+			expectedIDs:     []int{3, 4},
+			expectedBytes:   200,
+			expectedAge:     2 * 60 * 60,
+			expectedDropped: map[string]float64{"max_size": 200},
+		},
+		{
+			name:            "max_age",
+			persistence:     Persistence{MaxAge: 90 * time.Minute},
+			expectedIDs:     []int{4},
+			expectedBytes:   100,
+			expectedAge:     60 * 60,
+			expectedDropped: map[string]float64{"max_age": 300},
+		},
+		{
+			name:            "max_age and max_size",
+			persistence:     Persistence{MaxAge: 150 * time.Minute, MaxSize: 50},
+			expectedIDs:     []int{},
+			expectedBytes:   0,
+			expectedAge:     0,
+			expectedDropped: map[string]float64{"max_age": 200, "max_size": 200},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for id := 1; id <= 4; id++ {
+				writeWALFile(t, dir, id, 100, now.Add(-time.Duration(5-id)*time.Hour))
+			}
+
+			metrics, err := newWALMetrics(prometheus.NewRegistry())
+			require.NoError(t, err)
+			q := newTestFileQueue(t, dir, tc.persistence, metrics)
+			q.check(now)
+
+			require.Equal(t, tc.expectedIDs, walFileIDs(t, dir))
+			require.Equal(t, tc.expectedIDs, queuedIDs(q))
+			require.Equal(t, tc.expectedBytes, testutil.ToFloat64(metrics.backlogBytes.WithLabelValues("test")))
+			require.Equal(t, float64(len(tc.expectedIDs)), testutil.ToFloat64(metrics.backlogFiles.WithLabelValues("test")))
+			require.InDelta(t, tc.expectedAge, testutil.ToFloat64(metrics.oldestSampleAge.WithLabelValues("test")), 1)
+			for reason, dropped := range tc.expectedDropped {
+				require.Equal(t, dropped, testutil.ToFloat64(metrics.droppedBytes.WithLabelValues("test", reason)))
+			}
+
+			q.Stop()
+			require.Equal(t, 0, testutil.CollectAndCount(metrics.backlogBytes))
+		})
+	}
+}
+
+func TestFileQueue_ReplayOrder(t *testing.T) {
+	testCases := map[string]struct {
+		order    string
+		expected []int
+	}{
+		"oldest first": {order: ReplayOldestFirst, expected: []int{1, 2, 3, 4, 5}},
+		// The files found on startup are sent from the newest, before the
+		// files written afterwards.
+		"newest first": {order: ReplayNewestFirst, expected: []int{3, 2, 1, 4, 5}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			now := time.Now()
+			for id := 1; id <= 3; id++ {
+				writeWALFile(t, dir, id, 100, now)
+			}
+			metrics, err := newWALMetrics(prometheus.NewRegistry())
+			require.NoError(t, err)
+			q := newTestFileQueue(t, dir, Persistence{ReplayOrder: tc.order}, metrics)
+
+			require.NoError(t, q.Store(t.Context(), nil, []byte("4")))
+			require.NoError(t, q.Store(t.Context(), nil, []byte("5")))
+
+			var ids []int
+			for range tc.expected {
+				f, ok := q.next(t.Context())
+				require.True(t, ok)
+				ids = append(ids, f.id)
+			}
+			require.Equal(t, tc.expected, ids)
+
+			// The next file is waited for.
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+			defer cancel()
+			_, ok := q.next(ctx)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestFileQueue_StoreAndRead(t *testing.T) {
+	dir := t.TempDir()
+	metrics, err := newWALMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+	q := newTestFileQueue(t, dir, Persistence{}, metrics)
+
+	require.NoError(t, q.Store(t.Context(), map[string]string{"version": "test"}, []byte("data")))
+	f, ok := q.next(t.Context())
+	require.True(t, ok)
+	meta, data, err := q.read(f)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"version": "test", "file_id": "1"}, meta)
+	require.Equal(t, []byte("data"), data)
+	// Files are deleted once read.
+	require.Empty(t, walFileIDs(t, dir))
+
+	// The IDs of the files found on startup aren't reused.
+	writeWALFile(t, dir, 5, 100, time.Now())
+	q = newTestFileQueue(t, dir, Persistence{}, metrics)
+	require.NoError(t, q.Store(t.Context(), nil, []byte("data")))
+	require.Equal(t, []int{5, 6}, queuedIDs(q))
+}
+
+func TestFileQueue_CheckEvictsQueuedFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for id := 1; id <= 3; id++ {
+		writeWALFile(t, dir, id, 100, now)
+	}
+	metrics, err := newWALMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+	q := newTestFileQueue(t, dir, Persistence{MaxSize: 150}, metrics)
+
+	// Deleted files are removed from the queue, so they're never read.
+	q.check(now)
+	f, ok := q.next(t.Context())
+	require.True(t, ok)
+	require.Equal(t, 3, f.id)
+	require.Empty(t, queuedIDs(q))
+}
+
+func newTestFileQueue(t *testing.T, dir string, p Persistence, metrics *walMetrics) *fileQueue {
+	t.Helper()
+	q, err := newFileQueue("test", dir, p, metrics, stats.NewStats(), log.NewNopLogger())
+	require.NoError(t, err)
+	return q
+}
+
+func queuedIDs(q *fileQueue) []int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	ids := make([]int, 0, len(q.files))
+	for _, f := range q.files {
+		ids = append(ids, f.id)
+	}
+	return ids
+}
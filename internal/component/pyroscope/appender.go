@@ -3,12 +3,15 @@ package pyroscope
 import (
 	"context"
 	"net/url"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
 const (
@@ -21,6 +24,10 @@ const (
 
 var NoopAppendable = AppendableFunc(func(_ context.Context, _ labels.Labels, _ []*RawSample) error { return nil })
 
+func init() {
+	argument.RegisterCapsuleType("ProfilesReceiver", reflect.TypeFor[Appendable]())
+}
+
 type Appendable interface {
 	Appender() Appender
 }
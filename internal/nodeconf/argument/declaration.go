@@ -0,0 +1,356 @@
+package argument
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/token"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// TypeAttr is the name of the attribute holding the type of an argument.
+const TypeAttr = "type"
+
+const (
+	validationBlock = "validation"
+
+	// validationValue is the identifier which refers to the value of the
+	// argument in validation conditions.
+	validationValue = "value"
+)
+
+var (
+	builtinTypes = map[string]reflect.Type{
+		"any":    reflect.TypeFor[any](),
+		"string": reflect.TypeFor[string](),
+		"number": reflect.TypeFor[float64](),
+		"bool":   reflect.TypeFor[bool](),
+		"object": reflect.TypeFor[map[string]any](),
+		"target": reflect.TypeFor[map[string]string](),
+	}
+
+	// Globally registered capsule types
+	capsuleTypes = map[string]reflect.Type{}
+)
+
+// RegisterCapsuleType registers a capsule type which can be used in the type
+// of argument blocks, for example "otelcol.Consumer". If ty is an interface
+// type, any value implementing it is accepted.
+//
+// RegisterCapsuleType panics if name is already registered.
+func RegisterCapsuleType(name string, ty reflect.Type) {
+	if _, exist := capsuleTypes[name]; exist {
+		panic(fmt.Sprintf("Capsule type %q already registered", name))
+	}
+	capsuleTypes[name] = ty
+}
+
+// Type is the type of an argument.
+type Type struct {
+	name   string
+	goType reflect.Type
+}
+
+// String returns the type expression of t, for example "list(target)".
+func (t Type) String() string { return t.name }
+
+// Declaration holds the type and the validation rules of an argument block,
+// which are checked against the value passed by the caller of the module.
+type Declaration struct {
+	Name        string
+	Type        *Type // Type is nil when the argument block has no type.
+	Validations []ValidationRule
+
+	pos token.Pos // Position of the argument block.
+}
+
+// ValidationRule is a validation block of an argument block. The expressions
+// are evaluated with the value of the argument bound to "value".
+type ValidationRule struct {
+	Condition    ast.Expr
+	ErrorMessage ast.Expr // ErrorMessage is nil when not set.
+}
+
+// Declarations returns the declarations of the argument blocks in body,
+// indexed by label.
+func Declarations(body ast.Body) (map[string]*Declaration, diag.Diagnostics) {
+	var (
+		decls = make(map[string]*Declaration)
+		diags diag.Diagnostics
+	)
+	for _, stmt := range body {
+		b, ok := stmt.(*ast.BlockStmt)
+		if !ok || b.GetBlockName() != BlockName {
+			continue
+		}
+		decl, declDiags := ParseDeclaration(b)
+		diags.Merge(declDiags)
+		decls[decl.Name] = decl
+	}
+	return decls, diags
+}
+
+// ParseDeclaration reads the type and the validation rules of an argument
+// block.
+func ParseDeclaration(b *ast.BlockStmt) (*Declaration, diag.Diagnostics) {
+	var (
+		decl  = &Declaration{Name: b.Label, pos: b.NamePos}
+		diags diag.Diagnostics
+	)
+
+	for _, stmt := range b.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if stmt.Name.Name != TypeAttr {
+				continue
+			}
+			ty, typeDiags := ParseType(stmt.Value)
+			diags.Merge(typeDiags)
+			decl.Type = ty
+
+		case *ast.BlockStmt:
+			if stmt.GetBlockName() != validationBlock {
+				continue
+			}
+			var rule ValidationRule
+			for _, inner := range stmt.Body {
+				attr, ok := inner.(*ast.AttributeStmt)
+				if !ok {
+					diags.Add(diag.Diagnostic{
+						Severity: diag.SeverityLevelError,
+						Message:  fmt.Sprintf("unrecognized block name %q", inner.(*ast.BlockStmt).GetBlockName()),
+						StartPos: ast.StartPos(inner).Position(),
+						EndPos:   ast.EndPos(inner).Position(),
+					})
+					continue
+				}
+				switch attr.Name.Name {
+				case "condition":
+					rule.Condition = attr.Value
+				case "error_message":
+					rule.ErrorMessage = attr.Value
+				default:
+					diags.Add(diag.Diagnostic{
+						Severity: diag.SeverityLevelError,
+						Message:  fmt.Sprintf("unrecognized attribute name %q", attr.Name.Name),
+						StartPos: ast.StartPos(attr).Position(),
+						EndPos:   ast.EndPos(attr).Position(),
+					})
+				}
+			}
+			if rule.Condition == nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  "missing required attribute \"condition\"",
+					StartPos: ast.StartPos(stmt).Position(),
+					EndPos:   ast.EndPos(stmt).Position(),
+				})
+				continue
+			}
+			decl.Validations = append(decl.Validations, rule)
+		}
+	}
+
+	return decl, diags
+}
+
+// ParseType parses the type expression of an argument block, such as
+// list(target) or otelcol.Consumer.
+func ParseType(expr ast.Expr) (*Type, diag.Diagnostics) {
+	switch expr := expr.(type) {
+	case *ast.IdentifierExpr, *ast.AccessExpr:
+		name, ok := typeName(expr)
+		if !ok {
+			break
+		}
+		if ty, ok := builtinTypes[name]; ok {
+			return &Type{name: name, goType: ty}, nil
+		}
+		if ty, ok := capsuleTypes[name]; ok {
+			return &Type{name: name, goType: ty}, nil
+		}
+		return nil, typeError(expr, fmt.Sprintf("unknown type %q", name))
+
+	case *ast.CallExpr:
+		fn, ok := expr.Value.(*ast.IdentifierExpr)
+		if !ok || (fn.Ident.Name != "list" && fn.Ident.Name != "map") {
+			break
+		}
+		if len(expr.Args) != 1 {
+			return nil, typeError(expr, fmt.Sprintf("%s expects exactly one element type", fn.Ident.Name))
+		}
+		elem, diags := ParseType(expr.Args[0])
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		name := fmt.Sprintf("%s(%s)", fn.Ident.Name, elem.name)
+		if fn.Ident.Name == "list" {
+			return &Type{name: name, goType: reflect.SliceOf(elem.goType)}, nil
+		}
+		return &Type{name: name, goType: reflect.MapOf(reflect.TypeFor[string](), elem.goType)}, nil
+	}
+
+	return nil, typeError(expr, "type must be a type name such as string, list(target) or otelcol.Consumer")
+}
+
+// typeName returns the dot-separated name of an identifier or of a chain of
+// field accesses.
+func typeName(expr ast.Expr) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.IdentifierExpr:
+		return expr.Ident.Name, true
+	case *ast.AccessExpr:
+		prefix, ok := typeName(expr.Value)
+		if !ok {
+			return "", false
+		}
+		return prefix + "." + expr.Name.Name, true
+	}
+	return "", false
+}
+
+func typeError(expr ast.Expr, msg string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.SeverityLevelError,
+		Message:  msg,
+		StartPos: ast.StartPos(expr).Position(),
+		EndPos:   ast.EndPos(expr).Position(),
+	}}
+}
+
+// Check returns an error if v isn't of the type of the argument or if a
+// validation condition of the argument isn't satisfied. A null value is never
+// checked.
+//
+// v is passed as-is to the module, so it isn't converted to the type of the
+// argument: strings and numbers, which the arguments of components convert
+// to each other, are rejected where the other type is expected.
+func (d *Declaration) Check(v any) error {
+	if v == nil {
+		return nil
+	}
+
+	if d.Type != nil {
+		// Decoding the value with the same rules as the arguments of the
+		// components makes type errors consistent with them. The argument is
+		// bound to its own name so that errors refer to it.
+		var (
+			scope = vm.NewScope(map[string]any{d.Name: v})
+			expr  = &ast.IdentifierExpr{Ident: &ast.Ident{Name: d.Name, NamePos: d.pos}}
+		)
+		if err := vm.New(expr).Evaluate(scope, reflect.New(d.Type.goType).Interface()); err != nil {
+			return errors.New(errorMessage(err))
+		}
+		if err := checkConversions(d.Name, d.Type.goType, reflect.ValueOf(v)); err != nil {
+			return err
+		}
+	}
+
+	scope := vm.NewScope(map[string]any{validationValue: v})
+	for _, rule := range d.Validations {
+		var ok bool
+		if err := vm.New(rule.Condition).Evaluate(scope, &ok); err != nil {
+			return fmt.Errorf("evaluating validation condition: %s", errorMessage(err))
+		}
+		if ok {
+			continue
+		}
+
+		if rule.ErrorMessage == nil {
+			return fmt.Errorf("validation condition failed")
+		}
+		var msg string
+		if err := vm.New(rule.ErrorMessage).Evaluate(scope, &msg); err != nil {
+			return fmt.Errorf("evaluating validation error message: %s", errorMessage(err))
+		}
+		return errors.New(msg)
+	}
+
+	return nil
+}
+
+// checkConversions returns an error if v, which is assignable to ty, is a
+// string where ty expects a number or a number where ty expects a string.
+// path is the expression of v used in errors.
+func checkConversions(path string, ty reflect.Type, v reflect.Value) error {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch ty.Kind() {
+	case reflect.Float64:
+		if !isNumber(v.Kind()) {
+			return fmt.Errorf("%s should be number, got string", path)
+		}
+	case reflect.String:
+		if isNumber(v.Kind()) {
+			return fmt.Errorf("%s should be string, got number", path)
+		}
+	case reflect.Slice:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkConversions(fmt.Sprintf("%s[%d]", path, i), ty.Elem(), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			if err := checkConversions(fmt.Sprintf("%s[%q]", path, key.String()), ty.Elem(), v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// errorMessage returns the message of err without the position of the
+// expression, which doesn't belong to the caller of the module.
+func errorMessage(err error) string {
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) && len(diags) > 0 {
+		return diags[0].Message
+	}
+	return err.Error()
+}
+
+// ValueBody returns body without the type and the validation blocks of an
+// argument block, which can't be evaluated in the scope of the module.
+func ValueBody(body ast.Body) ast.Body {
+	res := make(ast.Body, 0, len(body))
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if stmt.Name.Name == TypeAttr {
+				continue
+			}
+		case *ast.BlockStmt:
+			if stmt.GetBlockName() == validationBlock {
+				continue
+			}
+		}
+		res = append(res, stmt)
+	}
+	return res
+}
@@ -15,6 +15,8 @@ const (
 	String
 	Git
 	HTTP
+	S3
+	OCI
 )
 
 const (
@@ -22,6 +24,8 @@ const (
 	BlockNameString = "import.string"
 	BlockNameHTTP   = "import.http"
 	BlockNameGit    = "import.git"
+	BlockNameS3     = "import.s3"
+	BlockNameOCI    = "import.oci"
 )
 
 const ModulePath = "module_path"
@@ -52,6 +56,10 @@ func NewImportSource(sourceType SourceType, managedOpts component.Options, eval
 		return NewImportHTTP(managedOpts, eval, onContentChange)
 	case Git:
 		return NewImportGit(managedOpts, eval, onContentChange)
+	case S3:
+		return NewImportS3(managedOpts, eval, onContentChange)
+	case OCI:
+		return NewImportOCI(managedOpts, eval, onContentChange)
 	}
 	panic(fmt.Errorf("unsupported source type: %v", sourceType))
 }
@@ -67,6 +75,10 @@ func GetSourceType(fullName string) SourceType {
 		return HTTP
 	case BlockNameGit:
 		return Git
+	case BlockNameS3:
+		return S3
+	case BlockNameOCI:
+		return OCI
 	}
 	panic(fmt.Errorf("name does not map to a known source type: %v", fullName))
 }
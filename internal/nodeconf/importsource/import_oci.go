@@ -0,0 +1,463 @@
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	prom_config "github.com/prometheus/common/config"
+
+	"github.com/grafana/alloy/internal/component"
+	common_config "github.com/grafana/alloy/internal/component/common/config"
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/useragent"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// ImportOCI imports a module from an artifact stored in an OCI registry.
+// There are currently no remote.oci component, the logic is implemented here.
+type ImportOCI struct {
+	opts            component.Options
+	log             log.Logger
+	eval            *vm.Evaluator
+	mut             sync.RWMutex
+	args            OCIArguments
+	client          *oci.Client
+	verifier        *oci.Verifier
+	digest          digest.Digest // Digest of the last pulled manifest.
+	artifactPath    string
+	onContentChange func(map[string]string)
+
+	argsChanged chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ ImportSource              = (*ImportOCI)(nil)
+	_ component.Component       = (*ImportOCI)(nil)
+	_ component.HealthComponent = (*ImportOCI)(nil)
+)
+
+type OCIArguments struct {
+	Reference          string         `alloy:"reference,attr"`
+	Digest             string         `alloy:"digest,attr,optional"`
+	Path               string         `alloy:"path,attr,optional"`
+	PullFrequency      time.Duration  `alloy:"pull_frequency,attr,optional"`
+	PollTimeout        time.Duration  `alloy:"poll_timeout,attr,optional"`
+	PlainHTTP          bool           `alloy:"plain_http,attr,optional"`
+	SignaturePublicKey string         `alloy:"signature_public_key,attr,optional"`
+	BasicAuth          *oci.BasicAuth `alloy:"basic_auth,block,optional"`
+
+	Client common_config.HTTPClientConfig `alloy:"client,block,optional"`
+}
+
+var DefaultOCIArguments = OCIArguments{
+	PullFrequency: time.Minute,
+	PollTimeout:   10 * time.Second,
+	Client:        common_config.DefaultHTTPClientConfig,
+}
+
+var (
+	_ syntax.Validator = (*OCIArguments)(nil)
+	_ syntax.Defaulter = (*OCIArguments)(nil)
+)
+
+// Validate implements syntax.Validator.
+func (args *OCIArguments) Validate() error {
+	ref, err := args.reference()
+	if err != nil {
+		return err
+	}
+	if args.Digest != "" && ref.Digest != "" && digest.Digest(args.Digest) != ref.Digest {
+		return fmt.Errorf("digest %q doesn't match the digest of reference %q", args.Digest, args.Reference)
+	}
+	if args.Path != "" && !filepath.IsLocal(args.Path) {
+		return fmt.Errorf("path %q must be a relative path within the artifact", args.Path)
+	}
+	if args.PullFrequency < 0 {
+		return fmt.Errorf("pull_frequency must not be negative")
+	}
+	if args.PollTimeout <= 0 {
+		return fmt.Errorf("poll_timeout must be greater than 0")
+	}
+	if args.PullFrequency > 0 && args.PollTimeout >= args.PullFrequency {
+		return fmt.Errorf("poll_timeout must be less than pull_frequency")
+	}
+	// The client authenticates to the registry with the credentials of
+	// basic_auth, which are also used to request bearer tokens.
+	if args.Client.BasicAuth != nil || args.Client.Authorization != nil || args.Client.OAuth2 != nil ||
+		args.Client.BearerToken != "" || args.Client.BearerTokenFile != "" {
+		return fmt.Errorf("the client block must not configure authentication, use the basic_auth block instead")
+	}
+	if args.SignaturePublicKey != "" {
+		if _, err := oci.NewVerifier(args.SignaturePublicKey); err != nil {
+			return fmt.Errorf("invalid signature_public_key: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *OCIArguments) SetToDefault() {
+	*args = DefaultOCIArguments
+}
+
+// reference returns the reference of the artifact, pinned to the digest
+// argument if it is set.
+func (args *OCIArguments) reference() (oci.Reference, error) {
+	ref, err := oci.ParseReference(args.Reference)
+	if err != nil {
+		return oci.Reference{}, err
+	}
+	if args.Digest != "" {
+		dgst, err := digest.Parse(args.Digest)
+		if err != nil {
+			return oci.Reference{}, fmt.Errorf("invalid digest %q: %w", args.Digest, err)
+		}
+		if ref.Digest == "" {
+			ref.Digest = dgst
+		}
+	}
+	return ref, nil
+}
+
+func NewImportOCI(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportOCI {
+	return &ImportOCI{
+		opts:            managedOpts,
+		log:             managedOpts.Logger,
+		eval:            eval,
+		artifactPath:    filepath.Join(managedOpts.DataPath, "artifact"),
+		argsChanged:     make(chan struct{}, 1),
+		onContentChange: onContentChange,
+	}
+}
+
+func (im *ImportOCI) Evaluate(scope *vm.Scope) error {
+	var arguments OCIArguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+
+	im.mut.RLock()
+	unchanged := equality.DeepEqual(im.args, arguments)
+	im.mut.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := im.Update(arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+	return nil
+}
+
+func (im *ImportOCI) Run(ctx context.Context) error {
+	var (
+		ticker  *time.Ticker
+		tickerC <-chan time.Time
+	)
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-im.argsChanged:
+			im.mut.RLock()
+			pullFrequency := im.args.PullFrequency
+			if ref, err := im.args.reference(); err == nil && ref.Digest != "" {
+				// Artifacts pinned to a digest can't change.
+				pullFrequency = 0
+			}
+			im.mut.RUnlock()
+			ticker, tickerC = im.updateTicker(pullFrequency, ticker, tickerC)
+
+		case <-tickerC:
+			level.Debug(im.log).Log("msg", "pulling artifact")
+			im.tickPull(ctx)
+		}
+	}
+}
+
+func (im *ImportOCI) updateTicker(pullFrequency time.Duration, ticker *time.Ticker, tickerC <-chan time.Time) (*time.Ticker, <-chan time.Time) {
+	if pullFrequency > 0 {
+		if ticker == nil {
+			ticker = time.NewTicker(pullFrequency)
+			tickerC = ticker.C
+		} else {
+			ticker.Reset(pullFrequency)
+		}
+		return ticker, tickerC
+	}
+
+	if ticker != nil {
+		ticker.Stop()
+	}
+	return nil, nil
+}
+
+func (im *ImportOCI) tickPull(ctx context.Context) {
+	im.mut.Lock()
+	ctx, cancel := context.WithTimeout(ctx, im.args.PollTimeout)
+	err := im.pull(ctx, im.args)
+	cancel()
+	im.mut.Unlock()
+
+	im.updateHealth(err)
+
+	if err != nil {
+		level.Error(im.log).Log("msg", "failed to pull artifact", "err", err)
+	}
+}
+
+func (im *ImportOCI) updateHealth(err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	if err != nil {
+		im.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		im.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// Update implements component.Component.
+// The artifact is pulled immediately. Failing to pull it is only an error
+// if no artifact was pulled before; otherwise the previous content is kept
+// and the pull is retried on the next tick.
+func (im *ImportOCI) Update(args component.Arguments) error {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	newArgs := args.(OCIArguments)
+
+	var verifier *oci.Verifier
+	if newArgs.SignaturePublicKey != "" {
+		var err error
+		if verifier, err = oci.NewVerifier(newArgs.SignaturePublicKey); err != nil {
+			im.updateHealth(err)
+			return err
+		}
+	}
+	httpClient, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		im.opts.ID,
+		prom_config.WithUserAgent(useragent.Get()),
+	)
+	if err != nil {
+		im.updateHealth(err)
+		return err
+	}
+	im.verifier = verifier
+	im.client = oci.NewClient(oci.ClientOptions{
+		PlainHTTP:  newArgs.PlainHTTP,
+		BasicAuth:  newArgs.BasicAuth,
+		HTTPClient: httpClient,
+	})
+
+	// Force the content to be updated since the arguments may select other
+	// files of the same artifact.
+	pulled := im.digest != ""
+	im.digest = ""
+
+	// The pull is bounded by poll_timeout since im.mut is held until it
+	// completes.
+	ctx, cancel := context.WithTimeout(context.Background(), newArgs.PollTimeout)
+	defer cancel()
+	err = im.pull(ctx, newArgs)
+	im.updateHealth(err)
+	if err != nil {
+		if !pulled {
+			return err
+		}
+		level.Error(im.log).Log("msg", "failed to pull artifact", "err", err)
+	}
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case im.argsChanged <- struct{}{}:
+	default:
+	}
+
+	im.args = newArgs
+	return nil
+}
+
+// pull fetches the manifest of the artifact and, if it changed since the
+// last pull, downloads its files and updates the controller. pull must only
+// be called with im.mut held.
+func (im *ImportOCI) pull(ctx context.Context, args OCIArguments) error {
+	ref, err := args.reference()
+	if err != nil {
+		return err
+	}
+
+	m, err := im.client.FetchManifest(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if m.Digest == im.digest {
+		return nil
+	}
+	if im.verifier != nil {
+		if err := im.verifier.Verify(ctx, im.client, ref, m.Digest); err != nil {
+			return err
+		}
+	}
+
+	files, err := im.fetchFiles(ctx, ref, m)
+	if err != nil {
+		return err
+	}
+	content, err := selectContent(files, args.Path)
+	if err != nil {
+		return fmt.Errorf("%s@%s: %w", args.Reference, m.Digest, err)
+	}
+
+	// The files are written to disk so that the module can import other
+	// files of the artifact with import.file.
+	if err := writeFiles(im.artifactPath, files); err != nil {
+		return err
+	}
+
+	level.Info(im.log).Log("msg", "pulled artifact", "reference", args.Reference, "digest", m.Digest)
+	im.digest = m.Digest
+	im.onContentChange(content)
+	return nil
+}
+
+// fetchFiles downloads the layers of the artifact which have a title, which
+// is the name of the file they were pushed from.
+func (im *ImportOCI) fetchFiles(ctx context.Context, ref oci.Reference, m *oci.Manifest) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, layer := range m.Layers {
+		name, ok := layer.Annotations[ocispec.AnnotationTitle]
+		if !ok {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("artifact contains file %q outside of its root", name)
+		}
+		bb, err := im.client.FetchBlob(ctx, ref, layer)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(name)] = string(bb)
+	}
+	return files, nil
+}
+
+// selectContent returns the module files at p among the files of an
+// artifact. p is either a file or a directory whose .alloy files are
+// returned; the root of the artifact is used when p is empty.
+func selectContent(files map[string]string, p string) (map[string]string, error) {
+	dir := "."
+	if p != "" {
+		p = path.Clean(filepath.ToSlash(p))
+		if bb, ok := files[p]; ok {
+			return map[string]string{p: bb}, nil
+		}
+		dir = p
+	}
+
+	content := make(map[string]string)
+	for name, bb := range files {
+		if path.Dir(name) == dir && strings.HasSuffix(name, ".alloy") {
+			content[path.Base(name)] = bb
+		}
+	}
+	if len(content) == 0 {
+		if p == "" {
+			return nil, fmt.Errorf("artifact contains no .alloy files")
+		}
+		return nil, fmt.Errorf("path %q doesn't match a file or a directory with .alloy files in the artifact", p)
+	}
+	return content, nil
+}
+
+// writeFiles replaces the content of dir with files. The files are written
+// to a temporary directory which is then renamed to dir, so that dir is left
+// unchanged if they can't be written.
+func writeFiles(dir string, files map[string]string) (err error) {
+	parent := filepath.Dir(dir)
+	if err := os.MkdirAll(parent, 0750); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(parent, filepath.Base(dir)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tmpDir)
+		}
+	}()
+	if err := os.Chmod(tmpDir, 0750); err != nil {
+		return err
+	}
+
+	for name, bb := range files {
+		filePath := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filePath, []byte(bb), 0640); err != nil {
+			return err
+		}
+	}
+
+	// A directory can't be renamed over a non-empty directory, so the
+	// previous files are moved aside before being removed.
+	oldDir := tmpDir + ".old"
+	if err := os.Rename(dir, oldDir); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		// Restore the previous files.
+		_ = os.Rename(oldDir, dir)
+		return err
+	}
+	return os.RemoveAll(oldDir)
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (im *ImportOCI) CurrentHealth() component.Health {
+	im.healthMut.RLock()
+	defer im.healthMut.RUnlock()
+	return im.health
+}
+
+// Update the evaluator.
+func (im *ImportOCI) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
+
+func (im *ImportOCI) ModulePath() string {
+	return im.artifactPath
+}
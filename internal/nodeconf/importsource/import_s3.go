@@ -0,0 +1,116 @@
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/grafana/alloy/internal/component"
+	remote_s3 "github.com/grafana/alloy/internal/component/remote/s3"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// ImportS3 imports a module from an S3-compatible bucket via the remote.s3 component.
+type ImportS3 struct {
+	managedRemoteS3 *remote_s3.Component
+	arguments       S3Arguments
+	managedOpts     component.Options
+	eval            *vm.Evaluator
+}
+
+var _ ImportSource = (*ImportS3)(nil)
+
+func NewImportS3(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportS3 {
+	opts := managedOpts
+	opts.OnStateChange = func(e component.Exports) {
+		onContentChange(map[string]string{opts.ID: e.(remote_s3.Exports).Content.Value})
+	}
+	return &ImportS3{
+		managedOpts: opts,
+		eval:        eval,
+	}
+}
+
+// S3Arguments holds values which are used to configure the remote.s3 component.
+type S3Arguments struct {
+	Path          string           `alloy:"path,attr"`
+	PollFrequency time.Duration    `alloy:"poll_frequency,attr,optional"`
+	Client        remote_s3.Client `alloy:"client,block,optional"`
+}
+
+// DefaultS3Arguments holds default settings for S3Arguments.
+var DefaultS3Arguments = S3Arguments{
+	PollFrequency: remote_s3.DefaultArguments.PollFrequency,
+}
+
+var (
+	_ syntax.Validator = (*S3Arguments)(nil)
+	_ syntax.Defaulter = (*S3Arguments)(nil)
+)
+
+// SetToDefault implements syntax.Defaulter.
+func (args *S3Arguments) SetToDefault() {
+	*args = DefaultS3Arguments
+}
+
+// Validate implements syntax.Validator.
+func (args *S3Arguments) Validate() error {
+	remoteS3Arguments := args.remoteS3Arguments()
+	return remoteS3Arguments.Validate()
+}
+
+func (args *S3Arguments) remoteS3Arguments() remote_s3.Arguments {
+	return remote_s3.Arguments{
+		Path:          args.Path,
+		PollFrequency: args.PollFrequency,
+		Options:       args.Client,
+	}
+}
+
+func (im *ImportS3) Evaluate(scope *vm.Scope) error {
+	var arguments S3Arguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+	remoteS3Arguments := arguments.remoteS3Arguments()
+	if im.managedRemoteS3 == nil {
+		var err error
+		im.managedRemoteS3, err = remote_s3.New(im.managedOpts, remoteS3Arguments)
+		if err != nil {
+			return fmt.Errorf("creating s3 component: %w", err)
+		}
+		im.arguments = arguments
+	}
+
+	if equality.DeepEqual(im.arguments, arguments) {
+		return nil
+	}
+
+	// Update the existing managed component
+	if err := im.managedRemoteS3.Update(remoteS3Arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+	im.arguments = arguments
+	return nil
+}
+
+func (im *ImportS3) Run(ctx context.Context) error {
+	return im.managedRemoteS3.Run(ctx)
+}
+
+func (im *ImportS3) CurrentHealth() component.Health {
+	return im.managedRemoteS3.CurrentHealth()
+}
+
+// Update the evaluator.
+func (im *ImportS3) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
+
+func (im *ImportS3) ModulePath() string {
+	dir, _ := path.Split(im.arguments.Path)
+	return dir
+}
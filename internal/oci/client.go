@@ -0,0 +1,298 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+const (
+	// mediaTypeDockerManifest is the media type of Docker image manifests,
+	// which have the same structure as OCI image manifests.
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	maxManifestSize = 4 << 20
+	maxBlobSize     = 16 << 20
+)
+
+// ErrNotFound is returned when a manifest or a blob doesn't exist in the
+// registry.
+var ErrNotFound = errors.New("not found")
+
+// BasicAuth holds the credentials used to authenticate to a registry.
+type BasicAuth struct {
+	Username string            `alloy:"username,attr"`
+	Password alloytypes.Secret `alloy:"password,attr"`
+}
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// PlainHTTP makes the client use HTTP instead of HTTPS.
+	PlainHTTP bool
+	// BasicAuth holds the credentials of the registry. Anonymous access is
+	// used when nil.
+	BasicAuth *BasicAuth
+	// HTTPClient is the client used for requests. http.DefaultClient is used
+	// when nil.
+	HTTPClient *http.Client
+}
+
+// Client pulls manifests and blobs from OCI registries with the distribution
+// API. Bearer tokens requested by registries are cached per repository.
+type Client struct {
+	opts ClientOptions
+
+	mut    sync.Mutex
+	tokens map[string]string // Bearer tokens by scope.
+}
+
+// NewClient creates a new Client.
+func NewClient(opts ClientOptions) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &Client{
+		opts:   opts,
+		tokens: make(map[string]string),
+	}
+}
+
+// Manifest is an image manifest along with its digest.
+type Manifest struct {
+	ocispec.Manifest
+	Digest digest.Digest
+}
+
+// FetchManifest fetches the manifest of the artifact identified by ref. If
+// ref has a digest, the content of the manifest is verified against it, so a
+// tag which was moved to another manifest is an error.
+func (c *Client) FetchManifest(ctx context.Context, ref Reference) (*Manifest, error) {
+	accept := strings.Join([]string{ocispec.MediaTypeImageManifest, mediaTypeDockerManifest}, ", ")
+	body, err := c.get(ctx, ref, "manifests/"+ref.manifestReference(), accept, maxManifestSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest of %s: %w", ref, err)
+	}
+
+	dgst := digest.FromBytes(body)
+	if ref.Digest != "" {
+		if actual := ref.Digest.Algorithm().FromBytes(body); actual != ref.Digest {
+			return nil, fmt.Errorf("manifest of %s has digest %s, expected %s", ref, actual, ref.Digest)
+		}
+		dgst = ref.Digest
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(body, &m.Manifest); err != nil {
+		return nil, fmt.Errorf("decoding manifest of %s: %w", ref, err)
+	}
+	switch m.MediaType {
+	case ocispec.MediaTypeImageManifest, mediaTypeDockerManifest, "":
+	case ocispec.MediaTypeImageIndex:
+		return nil, fmt.Errorf("%s is an image index, only image manifests are supported", ref)
+	default:
+		return nil, fmt.Errorf("%s has unsupported media type %q", ref, m.MediaType)
+	}
+	m.Digest = dgst
+	return &m, nil
+}
+
+// FetchBlob fetches a blob of the repository of ref and verifies its size
+// and digest.
+func (c *Client) FetchBlob(ctx context.Context, ref Reference, desc ocispec.Descriptor) ([]byte, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid blob digest %q: %w", desc.Digest, err)
+	}
+	if desc.Size > maxBlobSize {
+		return nil, fmt.Errorf("blob %s is larger than %d bytes", desc.Digest, maxBlobSize)
+	}
+
+	body, err := c.get(ctx, ref, "blobs/"+desc.Digest.String(), "", desc.Size)
+	if err != nil {
+		return nil, fmt.Errorf("fetching blob %s: %w", desc.Digest, err)
+	}
+	if int64(len(body)) != desc.Size {
+		return nil, fmt.Errorf("blob %s has size %d, expected %d", desc.Digest, len(body), desc.Size)
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(body); actual != desc.Digest {
+		return nil, fmt.Errorf("blob %s has digest %s", desc.Digest, actual)
+	}
+	return body, nil
+}
+
+// get reads the response to a GET request to a path of the repository of
+// ref. Responses larger than limit are rejected.
+func (c *Client) get(ctx context.Context, ref Reference, path, accept string, limit int64) ([]byte, error) {
+	u := url.URL{
+		Scheme: "https",
+		Host:   ref.Registry,
+		Path:   fmt.Sprintf("/v2/%s/%s", ref.Repository, path),
+	}
+	if c.opts.PlainHTTP {
+		u.Scheme = "http"
+	}
+	scope := fmt.Sprintf("repository:%s:pull", ref.Repository)
+
+	resp, err := c.do(ctx, u.String(), accept, scope)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The registry tells which authentication it requires in the
+		// challenge, so the request is retried once after authenticating.
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authenticate(ctx, challenge, scope); err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, u.String(), accept, scope); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response is larger than %d bytes", limit)
+	}
+	return body, nil
+}
+
+func (c *Client) do(ctx context.Context, u, accept, scope string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	c.mut.Lock()
+	token := c.tokens[scope]
+	c.mut.Unlock()
+
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.opts.BasicAuth != nil:
+		req.SetBasicAuth(c.opts.BasicAuth.Username, string(c.opts.BasicAuth.Password))
+	}
+	return c.opts.HTTPClient.Do(req)
+}
+
+// authenticate handles the challenge of a registry which rejected a request.
+// Basic challenges are handled by sending the credentials with each request,
+// so only bearer challenges need a token to be requested.
+func (c *Client) authenticate(ctx context.Context, challenge, scope string) error {
+	scheme, params := parseChallenge(challenge)
+	switch {
+	case strings.EqualFold(scheme, "basic") && c.opts.BasicAuth == nil:
+		return fmt.Errorf("registry requires credentials")
+	case strings.EqualFold(scheme, "basic"):
+		return fmt.Errorf("registry rejected the credentials")
+	case !strings.EqualFold(scheme, "bearer"):
+		return fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid realm %q in authentication challenge", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.opts.BasicAuth != nil {
+		req.SetBasicAuth(c.opts.BasicAuth.Username, string(c.opts.BasicAuth.Password))
+	}
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting token: unexpected status %s", resp.Status)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&tokenResp); err != nil {
+		return fmt.Errorf("decoding token: %w", err)
+	}
+	token := tokenResp.Token
+	if token == "" {
+		token = tokenResp.AccessToken
+	}
+	if token == "" {
+		return fmt.Errorf("registry returned an empty token")
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.tokens[scope] = token
+	return nil
+}
+
+// parseChallenge parses the scheme and the parameters of a WWW-Authenticate
+// header, such as Bearer realm="https://auth.example.com/token",service="registry".
+func parseChallenge(header string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params = make(map[string]string)
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas.
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			end := strings.Index(value, ",")
+			if end < 0 {
+				end = len(value)
+			}
+			params[key] = value[:end]
+			rest = value[end:]
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return scheme, params
+}
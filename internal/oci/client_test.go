@@ -0,0 +1,201 @@
+package oci_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+)
+
+func TestClient(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	dgst := registry.Push("modules/example", "v1", map[string]string{"main.alloy": "declare \"a\" {}"})
+	client := oci.NewClient(oci.ClientOptions{PlainHTTP: true})
+
+	t.Run("by tag", func(t *testing.T) {
+		ref, err := oci.ParseReference(registry.Host() + "/modules/example:v1")
+		require.NoError(t, err)
+
+		m, err := client.FetchManifest(context.Background(), ref)
+		require.NoError(t, err)
+		require.Equal(t, dgst, m.Digest)
+		require.Len(t, m.Layers, 1)
+
+		content, err := client.FetchBlob(context.Background(), ref, m.Layers[0])
+		require.NoError(t, err)
+		require.Equal(t, "declare \"a\" {}", string(content))
+	})
+
+	t.Run("by digest", func(t *testing.T) {
+		ref, err := oci.ParseReference(registry.Host() + "/modules/example@" + dgst.String())
+		require.NoError(t, err)
+
+		m, err := client.FetchManifest(context.Background(), ref)
+		require.NoError(t, err)
+		require.Equal(t, dgst, m.Digest)
+	})
+
+	t.Run("tag moved to another digest", func(t *testing.T) {
+		other := registry.Push("modules/example", "v2", map[string]string{"main.alloy": "declare \"b\" {}"})
+
+		ref, err := oci.ParseReference(registry.Host() + "/modules/example:v2@" + dgst.String())
+		require.NoError(t, err)
+
+		_, err = client.FetchManifest(context.Background(), ref)
+		require.EqualError(t, err, "manifest of "+ref.String()+" has digest "+other.String()+", expected "+dgst.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ref, err := oci.ParseReference(registry.Host() + "/modules/example:v3")
+		require.NoError(t, err)
+
+		_, err = client.FetchManifest(context.Background(), ref)
+		require.ErrorIs(t, err, oci.ErrNotFound)
+	})
+}
+
+func TestClient_Auth(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	registry.RequireAuth("user", "pass")
+	registry.Push("modules/example", "v1", map[string]string{"main.alloy": ""})
+
+	ref, err := oci.ParseReference(registry.Host() + "/modules/example:v1")
+	require.NoError(t, err)
+
+	t.Run("anonymous", func(t *testing.T) {
+		client := oci.NewClient(oci.ClientOptions{PlainHTTP: true})
+		_, err := client.FetchManifest(context.Background(), ref)
+		require.ErrorContains(t, err, "requesting token: unexpected status 401 Unauthorized")
+	})
+
+	t.Run("with credentials", func(t *testing.T) {
+		client := oci.NewClient(oci.ClientOptions{
+			PlainHTTP: true,
+			BasicAuth: &oci.BasicAuth{Username: "user", Password: "pass"},
+		})
+		_, err := client.FetchManifest(context.Background(), ref)
+		require.NoError(t, err)
+
+		// The token is reused by the following requests.
+		before := registry.Requests()
+		_, err = client.FetchManifest(context.Background(), ref)
+		require.NoError(t, err)
+		require.Equal(t, before+1, registry.Requests())
+	})
+}
+
+func TestVerifier(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signed := registry.Push("modules/example", "signed", map[string]string{"main.alloy": "a"})
+	registry.Sign("modules/example", signed, ecdsaKey)
+	registry.Sign("modules/example", signed, ed25519Key)
+	unsigned := registry.Push("modules/example", "unsigned", map[string]string{"main.alloy": "b"})
+
+	client := oci.NewClient(oci.ClientOptions{PlainHTTP: true})
+	ref, err := oci.ParseReference(registry.Host() + "/modules/example")
+	require.NoError(t, err)
+
+	t.Run("valid ecdsa signature", func(t *testing.T) {
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, ecdsaKey.Public()))
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(context.Background(), client, ref, signed))
+	})
+
+	t.Run("valid ed25519 signature", func(t *testing.T) {
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, ed25519Key.Public()))
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(context.Background(), client, ref, signed))
+	})
+
+	t.Run("signed with another key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, otherKey.Public()))
+		require.NoError(t, err)
+		err = verifier.Verify(context.Background(), client, ref, signed)
+		require.ErrorContains(t, err, "no valid signature for modules/example@"+signed.String())
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, ecdsaKey.Public()))
+		require.NoError(t, err)
+		err = verifier.Verify(context.Background(), client, ref, unsigned)
+		require.EqualError(t, err, "modules/example@"+unsigned.String()+" is not signed")
+	})
+
+	t.Run("signature of another repository", func(t *testing.T) {
+		copied := registry.Push("modules/copied", "latest", map[string]string{"main.alloy": "a"})
+		var payload oci.SignaturePayload
+		payload.Critical.Identity.DockerReference = registry.Host() + "/modules/example"
+		payload.Critical.Image.DockerManifestDigest = copied.String()
+		payload.Critical.Type = oci.SignaturePayloadType
+		registry.SignPayload("modules/copied", copied, payload, ecdsaKey)
+
+		copiedRef, err := oci.ParseReference(registry.Host() + "/modules/copied")
+		require.NoError(t, err)
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, ecdsaKey.Public()))
+		require.NoError(t, err)
+		err = verifier.Verify(context.Background(), client, copiedRef, copied)
+		require.ErrorContains(t, err, fmt.Sprintf("signature is for repository %q, expected %q", registry.Host()+"/modules/example", registry.Host()+"/modules/copied"))
+	})
+
+	t.Run("signed payload of another type", func(t *testing.T) {
+		typed := registry.Push("modules/example", "typed", map[string]string{"main.alloy": "c"})
+		var payload oci.SignaturePayload
+		payload.Critical.Identity.DockerReference = registry.Host() + "/modules/example"
+		payload.Critical.Image.DockerManifestDigest = typed.String()
+		payload.Critical.Type = "attestation"
+		registry.SignPayload("modules/example", typed, payload, ecdsaKey)
+
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, ecdsaKey.Public()))
+		require.NoError(t, err)
+		err = verifier.Verify(context.Background(), client, ref, typed)
+		require.ErrorContains(t, err, `signed payload has type "attestation", expected "cosign container image signature"`)
+	})
+
+	t.Run("unavailable signature is skipped", func(t *testing.T) {
+		partial := registry.Push("modules/example", "partial", map[string]string{"main.alloy": "d"})
+		var payload oci.SignaturePayload
+		payload.Critical.Type = "unavailable"
+		registry.DeleteBlob(registry.SignPayload("modules/example", partial, payload, ecdsaKey))
+		registry.Sign("modules/example", partial, ecdsaKey)
+
+		verifier, err := oci.NewVerifier(publicKeyPEM(t, ecdsaKey.Public()))
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(context.Background(), client, ref, partial))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := oci.NewVerifier("not a key")
+		require.EqualError(t, err, "public key must be PEM-encoded")
+	})
+}
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
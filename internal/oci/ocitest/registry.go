@@ -0,0 +1,243 @@
+// Package ocitest provides an in-memory OCI registry for tests.
+package ocitest
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/grafana/alloy/internal/oci"
+)
+
+const token = "test-token"
+
+var pathRegexp = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs)/([^/]+)$`)
+
+// Registry is an in-memory registry implementing the pull endpoints of the
+// distribution API. It must be closed after use using Close method.
+type Registry struct {
+	server *httptest.Server
+
+	mut       sync.Mutex
+	manifests map[string][]byte        // Manifests by repository and reference.
+	blobs     map[digest.Digest][]byte // Blobs of all repositories.
+	username  string
+	password  string
+	requests  int
+}
+
+// NewRegistry starts a new Registry.
+func NewRegistry() *Registry {
+	r := &Registry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[digest.Digest][]byte),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", r.handleToken)
+	mux.HandleFunc("/v2/", r.handleV2)
+	r.server = httptest.NewUnstartedServer(mux)
+	// Clients don't keep connections open, so tests can check that no
+	// goroutines are leaked.
+	r.server.Config.SetKeepAlivesEnabled(false)
+	r.server.Start()
+	return r
+}
+
+// Close shuts down the registry.
+func (r *Registry) Close() {
+	r.server.Close()
+}
+
+// Host returns the host of the registry, to be used in references.
+func (r *Registry) Host() string {
+	return r.server.Listener.Addr().String()
+}
+
+// Requests returns the number of requests made to the registry.
+func (r *Registry) Requests() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.requests
+}
+
+// RequireAuth makes the registry require bearer tokens, which are issued
+// for the given credentials.
+func (r *Registry) RequireAuth(username, password string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.username, r.password = username, password
+}
+
+// Push pushes an artifact with a layer for each file, titled with the name
+// of the file, and tags it. It returns the digest of the manifest.
+func (r *Registry) Push(repository, tag string, files map[string]string) digest.Digest {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	layers := make([]ocispec.Descriptor, 0, len(names))
+	for _, name := range names {
+		desc := r.addBlob("application/vnd.oci.image.layer.v1.tar", []byte(files[name]))
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		layers = append(layers, desc)
+	}
+	return r.pushManifest(repository, tag, layers)
+}
+
+// Sign signs the manifest with the given digest with key and pushes the
+// signature with the tag used by cosign.
+func (r *Registry) Sign(repository string, dgst digest.Digest, key crypto.Signer) {
+	var payload oci.SignaturePayload
+	payload.Critical.Identity.DockerReference = r.Host() + "/" + repository
+	payload.Critical.Image.DockerManifestDigest = dgst.String()
+	payload.Critical.Type = oci.SignaturePayloadType
+	_ = r.SignPayload(repository, dgst, payload, key)
+}
+
+// SignPayload signs payload with key and pushes the signature for the
+// manifest with the given digest. It allows tests to sign payloads which
+// don't match the manifest. It returns the digest of the payload blob.
+func (r *Registry) SignPayload(repository string, dgst digest.Digest, payload oci.SignaturePayload, key crypto.Signer) digest.Digest {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+
+	var sig []byte
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		sig, err = key.Sign(rand.Reader, payloadBytes, crypto.Hash(0))
+	} else {
+		hashed := sha256.Sum256(payloadBytes)
+		sig, err = key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	desc := r.addBlob(oci.SignaturePayloadMediaType, payloadBytes)
+	desc.Annotations = map[string]string{oci.SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)}
+
+	// Like cosign, signatures are added to the existing signature manifest.
+	tag := oci.SignatureTag(dgst)
+	var existing ocispec.Manifest
+	r.mut.Lock()
+	if manifest, ok := r.manifests[repository+":"+tag]; ok {
+		_ = json.Unmarshal(manifest, &existing)
+	}
+	r.mut.Unlock()
+	r.pushManifest(repository, tag, append(existing.Layers, desc))
+	return desc.Digest
+}
+
+// DeleteBlob deletes a blob, so that the manifests referencing it are
+// broken.
+func (r *Registry) DeleteBlob(dgst digest.Digest) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	delete(r.blobs, dgst)
+}
+
+func (r *Registry) addBlob(mediaType string, content []byte) ocispec.Descriptor {
+	dgst := digest.FromBytes(content)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.blobs[dgst] = content
+	return ocispec.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(content))}
+}
+
+func (r *Registry) pushManifest(repository, tag string, layers []ocispec.Descriptor) digest.Digest {
+	config := r.addBlob(ocispec.MediaTypeEmptyJSON, []byte("{}"))
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    layers,
+	})
+	if err != nil {
+		panic(err)
+	}
+	dgst := digest.FromBytes(manifest)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.manifests[repository+":"+tag] = manifest
+	r.manifests[repository+"@"+dgst.String()] = manifest
+	return dgst
+}
+
+func (r *Registry) handleToken(w http.ResponseWriter, req *http.Request) {
+	r.mut.Lock()
+	username, password := r.username, r.password
+	r.mut.Unlock()
+
+	if u, p, ok := req.BasicAuth(); !ok || u != username || p != password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+func (r *Registry) handleV2(w http.ResponseWriter, req *http.Request) {
+	r.mut.Lock()
+	r.requests++
+	requireAuth := r.username != ""
+	r.mut.Unlock()
+
+	if requireAuth && req.Header.Get("Authorization") != "Bearer "+token {
+		realm := url.URL{Scheme: "http", Host: r.Host(), Path: "/token"}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="ocitest"`, realm.String()))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	match := pathRegexp.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repository, kind, reference := match[1], match[2], match[3]
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	switch kind {
+	case "manifests":
+		sep := ":"
+		if strings.Contains(reference, ":") {
+			sep = "@"
+		}
+		manifest, ok := r.manifests[repository+sep+reference]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		_, _ = w.Write(manifest)
+	case "blobs":
+		blob, ok := r.blobs[digest.Digest(reference)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+	}
+}
@@ -0,0 +1,105 @@
+// Package oci implements a minimal client for OCI registries, which is used
+// to pull artifacts such as modules.
+package oci
+
+import (
+	// Register the hash functions of the digest algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// DefaultTag is the tag pulled for references which have neither a tag nor
+// a digest.
+const DefaultTag = "latest"
+
+var (
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRegexp        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+)
+
+// Reference is a reference to an artifact in an OCI registry, such as
+// registry.example.com/modules/kubernetes:v1.2.0. If a reference has both a
+// tag and a digest, the tag is pulled and must resolve to the digest.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     digest.Digest
+}
+
+// ParseReference parses a reference of the form
+// registry/repository[:tag][@digest]. The registry can't be omitted.
+func ParseReference(s string) (Reference, error) {
+	var ref Reference
+
+	name := s
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		dgst, err := digest.Parse(name[i+1:])
+		if err != nil {
+			return Reference{}, fmt.Errorf("invalid digest in reference %q: %w", s, err)
+		}
+		ref.Digest = dgst
+		name = name[:i]
+	}
+
+	registry, repository, ok := strings.Cut(name, "/")
+	if !ok || !isRegistryHost(registry) {
+		return Reference{}, fmt.Errorf("reference %q must start with a registry host, such as registry.example.com/", s)
+	}
+	ref.Registry = registry
+
+	// The registry was removed, so a colon can only separate the tag.
+	if i := strings.LastIndex(repository, ":"); i >= 0 {
+		ref.Tag = repository[i+1:]
+		repository = repository[:i]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return Reference{}, fmt.Errorf("invalid tag %q in reference %q", ref.Tag, s)
+		}
+	}
+	if !repositoryRegexp.MatchString(repository) {
+		return Reference{}, fmt.Errorf("invalid repository %q in reference %q", repository, s)
+	}
+	ref.Repository = repository
+	return ref, nil
+}
+
+// isRegistryHost reports whether the first component of a reference is a
+// registry host rather than part of the repository.
+func isRegistryHost(s string) bool {
+	return s == "localhost" || strings.ContainsAny(s, ".:")
+}
+
+// String returns the reference in the form accepted by ParseReference.
+func (r Reference) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Registry)
+	sb.WriteString("/")
+	sb.WriteString(r.Repository)
+	if r.Tag != "" {
+		sb.WriteString(":")
+		sb.WriteString(r.Tag)
+	}
+	if r.Digest != "" {
+		sb.WriteString("@")
+		sb.WriteString(r.Digest.String())
+	}
+	return sb.String()
+}
+
+// manifestReference returns the tag or the digest which identifies the
+// manifest of the artifact in the registry API.
+func (r Reference) manifestReference() string {
+	switch {
+	case r.Tag != "":
+		return r.Tag
+	case r.Digest != "":
+		return r.Digest.String()
+	default:
+		return DefaultTag
+	}
+}
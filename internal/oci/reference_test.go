@@ -0,0 +1,74 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	const dgst = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	tests := []struct {
+		input       string
+		expected    Reference
+		expectedErr string
+	}{
+		{
+			input:    "registry.example.com/modules/kubernetes",
+			expected: Reference{Registry: "registry.example.com", Repository: "modules/kubernetes"},
+		},
+		{
+			input:    "localhost:5000/kubernetes:v1.2.0",
+			expected: Reference{Registry: "localhost:5000", Repository: "kubernetes", Tag: "v1.2.0"},
+		},
+		{
+			input:    "registry.example.com/kubernetes@" + dgst,
+			expected: Reference{Registry: "registry.example.com", Repository: "kubernetes", Digest: digest.Digest(dgst)},
+		},
+		{
+			input:    "registry.example.com/kubernetes:v1@" + dgst,
+			expected: Reference{Registry: "registry.example.com", Repository: "kubernetes", Tag: "v1", Digest: digest.Digest(dgst)},
+		},
+		{
+			input:       "modules/kubernetes",
+			expectedErr: `reference "modules/kubernetes" must start with a registry host, such as registry.example.com/`,
+		},
+		{
+			input:       "registry.example.com/Kubernetes",
+			expectedErr: `invalid repository "Kubernetes" in reference "registry.example.com/Kubernetes"`,
+		},
+		{
+			input:       "registry.example.com/kubernetes:-v1",
+			expectedErr: `invalid tag "-v1" in reference "registry.example.com/kubernetes:-v1"`,
+		},
+		{
+			input:       "registry.example.com/kubernetes@sha256:1234",
+			expectedErr: `invalid digest in reference "registry.example.com/kubernetes@sha256:1234": invalid checksum digest length`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			ref, err := ParseReference(tc.input)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ref)
+			require.Equal(t, tc.input, ref.String())
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a:pull,push"`)
+	require.Equal(t, "Bearer", scheme)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:a:pull,push",
+	}, params)
+}
@@ -0,0 +1,155 @@
+package oci
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// SignatureAnnotation is the annotation of the layers of a signature
+	// manifest which holds the base64-encoded signature of the layer.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// SignaturePayloadMediaType is the media type of the signed payloads.
+	SignaturePayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// SignaturePayloadType is the type of the signed payloads of artifacts.
+	SignaturePayloadType = "cosign container image signature"
+)
+
+// SignaturePayload is the payload signed for an artifact. Only the fields
+// needed to verify signatures are decoded.
+type SignaturePayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// SignatureTag returns the tag of the signature of the manifest with the
+// given digest, following the convention used by cosign.
+func SignatureTag(dgst digest.Digest) string {
+	return fmt.Sprintf("%s-%s.sig", dgst.Algorithm(), dgst.Encoded())
+}
+
+// Verifier verifies the signatures of artifacts against a public key.
+type Verifier struct {
+	key crypto.PublicKey
+}
+
+// NewVerifier creates a Verifier from a PEM-encoded ECDSA, RSA or Ed25519
+// public key.
+func NewVerifier(publicKeyPEM string) (*Verifier, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.New("public key must be PEM-encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return &Verifier{key: key}, nil
+}
+
+// Verify checks that the artifact identified by ref and with the manifest
+// digest dgst has at least one signature which is valid for the key of v.
+// Signatures which can't be fetched are skipped, so that they don't prevent
+// other signatures from being verified.
+func (v *Verifier) Verify(ctx context.Context, c *Client, ref Reference, dgst digest.Digest) error {
+	sigRef := Reference{
+		Registry:   ref.Registry,
+		Repository: ref.Repository,
+		Tag:        SignatureTag(dgst),
+	}
+	m, err := c.FetchManifest(ctx, sigRef)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%s@%s is not signed", ref.Repository, dgst)
+	} else if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, layer := range m.Layers {
+		sig, ok := layer.Annotations[SignatureAnnotation]
+		if !ok || layer.MediaType != SignaturePayloadMediaType {
+			continue
+		}
+		payload, err := c.FetchBlob(ctx, sigRef, layer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := v.verifyPayload(payload, sig, ref, dgst); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("%s@%s has no signatures", ref.Repository, dgst)
+	}
+	return fmt.Errorf("no valid signature for %s@%s: %w", ref.Repository, dgst, errors.Join(errs...))
+}
+
+func (v *Verifier) verifyPayload(payload []byte, encodedSig string, ref Reference, dgst digest.Digest) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedSig))
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	hashed := sha256.Sum256(payload)
+	switch key := v.key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, hashed[:], sig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sig); err != nil {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, sig) {
+			return errors.New("invalid signature")
+		}
+	}
+
+	// The signature is only valid for the artifact if the signed payload
+	// refers to its manifest in its repository, so that signatures can't be
+	// copied to other repositories signed with the same key.
+	var p SignaturePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decoding signed payload: %w", err)
+	}
+	if p.Critical.Type != SignaturePayloadType {
+		return fmt.Errorf("signed payload has type %q, expected %q", p.Critical.Type, SignaturePayloadType)
+	}
+	if signed, expected := p.Critical.Identity.DockerReference, ref.Registry+"/"+ref.Repository; signed != expected {
+		return fmt.Errorf("signature is for repository %q, expected %q", signed, expected)
+	}
+	if signed := p.Critical.Image.DockerManifestDigest; signed != dgst.String() {
+		return fmt.Errorf("signature is for manifest %s", signed)
+	}
+	return nil
+}
@@ -12,7 +12,14 @@ import (
 	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
+
+	_ "github.com/grafana/alloy/internal/component/discovery/relabel"
+	_ "github.com/grafana/alloy/internal/component/otelcol/processor/batch"
+	_ "github.com/grafana/alloy/internal/component/prometheus/relabel"
 )
 
 type testCase struct {
@@ -327,6 +334,86 @@ func TestDeclare(t *testing.T) {
 			`,
 			expected: 10,
 		},
+		{
+			name: "TypedArgument",
+			config: `
+			declare "test" {
+				argument "input" {
+					type = number
+					validation {
+						condition     = value >= 0
+						error_message = "input must not be negative"
+					}
+				}
+
+				testcomponents.passthrough "pt" {
+					input = argument.input.value
+					lag = "1ms"
+				}
+
+				export "output" {
+					value = testcomponents.passthrough.pt.output
+				}
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 10,
+		},
+		{
+			name: "ConditionalArgument",
+			config: `
+			declare "test" {
+				argument "input" {
+					optional = false
+				}
+
+				argument "use_missing" {
+					optional = true
+					default  = false
+				}
+
+				argument "use_input" {
+					optional = false
+				}
+
+				// Only the values selected by the arguments may reference
+				// components which don't exist.
+				testcomponents.passthrough "pt" {
+					input = argument.use_missing.value ? testcomponents.passthrough.missing.output : (argument.use_input.value ? argument.input.value : testcomponents.passthrough.other.output)
+					lag = "1ms"
+				}
+
+				export "output" {
+					value = testcomponents.passthrough.pt.output
+				}
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+				use_input = true
+			}
+
+			testcomponents.summation "sum" {
+				input = false ? testcomponents.passthrough.missing.output : test.myModule.output
+			}
+			`,
+			expected: 10,
+		},
 	}
 
 	for _, tc := range tt {
@@ -475,6 +562,93 @@ func TestDeclareError(t *testing.T) {
 			`,
 			expectedError: regexp.MustCompile(`cannot find the definition of component name "b_1"`),
 		},
+		{
+			name: "ArgumentTypeMismatch",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = list(number)
+				}
+			}
+			a "example" {
+				input = ["1", true]
+			}
+			`,
+			expectedError: regexp.MustCompile(`8:13: invalid value for argument "input": input\[1\] should be number, got bool`),
+		},
+		{
+			name: "ArgumentStringForNumber",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = number
+				}
+			}
+			a "example" {
+				input = "15"
+			}
+			`,
+			expectedError: regexp.MustCompile(`8:13: invalid value for argument "input": input should be number, got string`),
+		},
+		{
+			name: "ArgumentNumberForString",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = map(list(string))
+				}
+			}
+			a "example" {
+				input = {"a" = ["b", 1]}
+			}
+			`,
+			expectedError: regexp.MustCompile(`8:13: invalid value for argument "input": input\["a"\]\[1\] should be string, got number`),
+		},
+		{
+			name: "ArgumentValidationFailed",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = number
+					validation {
+						condition     = value > 0
+						error_message = "input must be positive, got " + string.format("%d", value)
+					}
+				}
+			}
+			a "example" {
+				input = -1
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "input": input must be positive, got -1`),
+		},
+		{
+			name: "ArgumentUnknownType",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = list(strings)
+				}
+			}
+			a "example" {
+				input = []
+			}
+			`,
+			expectedError: regexp.MustCompile(`4:18: unknown type "strings"`),
+		},
+		{
+			name: "ConditionalReferenceWithComponentCondition",
+			config: `
+			testcomponents.passthrough "pt" {
+				input = "a"
+			}
+
+			testcomponents.passthrough "selected" {
+				input = testcomponents.passthrough.pt.output == "a" ? "b" : testcomponents.passthrough.missing.output
+			}
+			`,
+			expectedError: regexp.MustCompile(`component "testcomponents.passthrough.missing.output" does not exist or is out of scope; both values of a conditional expression must reference existing components when its condition depends on other components`),
+		},
 		{
 			name: "ForbiddenDeclareLabel",
 			config: `
@@ -518,6 +692,155 @@ func TestDeclareError(t *testing.T) {
 	}
 }
 
+func TestDeclareComponentTypes(t *testing.T) {
+	tt := []struct {
+		name          string
+		config        string
+		expectedError *regexp.Regexp // nil when the config is valid.
+	}{
+		{
+			name: "TargetsFromDiscovery",
+			config: `
+			declare "a" {
+				argument "targets" {
+					type = list(target)
+					validation {
+						condition     = value[0]["__address__"] == "localhost:9090"
+						error_message = "unexpected targets"
+					}
+				}
+			}
+			discovery.relabel "src" {
+				targets = [{"__address__" = "localhost:9090", "job" = "test"}]
+			}
+			a "example" {
+				targets = discovery.relabel.src.output
+			}
+			`,
+		},
+		{
+			name: "TargetsFromRules",
+			config: `
+			declare "a" {
+				argument "targets" {
+					type = list(target)
+				}
+			}
+			discovery.relabel "src" {
+				targets = []
+			}
+			a "example" {
+				targets = discovery.relabel.src.rules
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "targets": targets should be array, got capsule$`),
+		},
+		{
+			name: "ConsumersFromProcessor",
+			config: `
+			declare "a" {
+				argument "output" {
+					type = list(otelcol.Consumer)
+				}
+			}
+			otelcol.processor.batch "b" {
+				output {}
+			}
+			a "example" {
+				output = [otelcol.processor.batch.b.input]
+			}
+			`,
+		},
+		{
+			name: "ConsumersFromTargets",
+			config: `
+			declare "a" {
+				argument "output" {
+					type = list(otelcol.Consumer)
+				}
+			}
+			discovery.relabel "src" {
+				targets = [{"__address__" = "localhost:9090"}]
+			}
+			a "example" {
+				output = discovery.relabel.src.output
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "output": output\[0\] target::ConvertInto: conversion to '\*otelcol.Consumer' is not supported`),
+		},
+		{
+			name: "MetricsReceiverFromRelabel",
+			config: `
+			declare "a" {
+				argument "forward_to" {
+					type = list(MetricsReceiver)
+				}
+			}
+			prometheus.relabel "r" {
+				forward_to = []
+			}
+			a "example" {
+				forward_to = [prometheus.relabel.r.receiver]
+			}
+			`,
+		},
+		{
+			name: "MetricsReceiverFromProcessor",
+			config: `
+			declare "a" {
+				argument "forward_to" {
+					type = list(MetricsReceiver)
+				}
+			}
+			otelcol.processor.batch "b" {
+				output {}
+			}
+			a "example" {
+				forward_to = [otelcol.processor.batch.b.input]
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "forward_to": forward_to\[0\] expected capsule\("storage.Appendable"\), got capsule\("lazyconsumer.Consumer"\)`),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			s, err := logging.New(os.Stderr, logging.DefaultOptions)
+			require.NoError(t, err)
+			ctrl := runtime.New(runtime.Options{
+				Logger:       s,
+				DataPath:     t.TempDir(),
+				MinStability: featuregate.StabilityPublicPreview,
+				Reg:          nil,
+				Services: []service.Service{
+					livedebugging.New(),
+					labelstore.New(nil, prometheus.NewRegistry()),
+				},
+			})
+			f, err := runtime.ParseSource(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+			require.NotNil(t, f)
+
+			err = ctrl.LoadSource(f, nil, "")
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Regexp(t, tc.expectedError, err.Error())
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			cancel()
+			<-done
+		})
+	}
+}
+
 type testCaseUpdateConfig struct {
 	name        string
 	config      string
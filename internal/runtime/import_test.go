@@ -2,15 +2,25 @@ package runtime_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
 	"github.com/grafana/alloy/internal/runtime/logging"
@@ -166,6 +176,154 @@ func TestImportHTTP(t *testing.T) {
 	}
 }
 
+func TestImportS3(t *testing.T) {
+	directory := "./testdata/import_s3"
+	for _, file := range getTestFiles(directory, t) {
+		archive, err := txtar.ParseFile(filepath.Join(directory, file.Name()))
+		require.NoError(t, err)
+		t.Run(file.Name(), func(t *testing.T) {
+			// Files other than the main config are stored in the "modules" bucket.
+			objects := make(map[string]string)
+			for _, f := range archive.Files[1:] {
+				objects["/modules/"+f.Name] = string(f.Data)
+			}
+			srv := newS3Server(objects)
+			defer srv.Close()
+
+			testConfig(t, strings.ReplaceAll(string(archive.Files[0].Data), "S3_ENDPOINT", srv.URL), "", nil)
+		})
+	}
+}
+
+// newS3Server returns a server which serves objects by path like an
+// S3-compatible system using path-style requests.
+func newS3Server(objects map[string]string) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := objects[r.URL.Path]
+		if !ok || r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write([]byte(content))
+	}))
+	srv.Config.SetKeepAlivesEnabled(false)
+	srv.Start()
+	return srv
+}
+
+func TestImportOCI(t *testing.T) {
+	directory := "./testdata/import_oci"
+	for _, file := range getTestFiles(directory, t) {
+		archive, err := txtar.ParseFile(filepath.Join(directory, file.Name()))
+		require.NoError(t, err)
+		t.Run(file.Name(), func(t *testing.T) {
+			registry := ocitest.NewRegistry()
+			defer registry.Close()
+
+			// Files other than the main config are pushed to the artifact, and
+			// files in the update folder replace them in a new version of it.
+			var (
+				files   = make(map[string]string)
+				updated = make(map[string]string)
+			)
+			for _, f := range archive.Files[1:] {
+				if name, ok := strings.CutPrefix(f.Name, "update/"); ok {
+					updated[name] = string(f.Data)
+				} else {
+					files[f.Name] = string(f.Data)
+				}
+			}
+			registry.Push("modules/test", "v1", files)
+
+			var update func()
+			if len(updated) > 0 {
+				update = func() {
+					maps.Copy(files, updated)
+					registry.Push("modules/test", "v1", files)
+				}
+			}
+			testConfig(t, strings.ReplaceAll(string(archive.Files[0].Data), "REGISTRY", registry.Host()), "", update)
+		})
+	}
+}
+
+func TestImportOCIVerification(t *testing.T) {
+	const module = `
+declare "a" {
+  argument "input" {}
+
+  testcomponents.passthrough "pt" {
+    input = argument.input.value
+    lag = "1ms"
+  }
+
+  export "output" {
+    value = testcomponents.passthrough.pt.output
+  }
+}`
+
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	signed := registry.Push("modules/test", "signed", map[string]string{"module.alloy": module})
+	registry.Sign("modules/test", signed, key)
+	unsigned := registry.Push("modules/test", "unsigned", map[string]string{"module.alloy": module + "\n"})
+
+	config := func(importArgs string) string {
+		return `
+testcomponents.count "inc" {
+  frequency = "10ms"
+  max = 10
+}
+
+import.oci "testImport" {
+  plain_http = true
+  ` + importArgs + `
+}
+
+testImport.a "cc" {
+  input = testcomponents.count.inc.count
+}
+
+testcomponents.summation "sum" {
+  input = testImport.a.cc.output
+}`
+	}
+	reference := func(tag string) string {
+		return "reference = " + strconv.Quote(registry.Host()+"/modules/test:"+tag)
+	}
+
+	t.Run("signed and pinned", func(t *testing.T) {
+		testConfig(t, config(reference("signed")+`
+  digest = "`+signed.String()+`"
+  signature_public_key = `+strconv.Quote(publicKey)), "", nil)
+	})
+
+	t.Run("tag resolves to another digest", func(t *testing.T) {
+		testConfigError(t, config(reference("unsigned")+`
+  digest = "`+signed.String()+`"`), "has digest "+unsigned.String()+", expected "+signed.String())
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		testConfigError(t, config(reference("unsigned")+`
+  signature_public_key = `+strconv.Quote(publicKey)), "modules/test@"+unsigned.String()+" is not signed")
+	})
+
+	t.Run("authentication in client block", func(t *testing.T) {
+		testConfigError(t, config(reference("signed")+`
+  client {
+    bearer_token = "token"
+  }`), "the client block must not configure authentication, use the basic_auth block instead")
+	})
+}
+
 type testImportFileFolder struct {
 	description  string      // description at the top of the txtar file
 	main         string      // root config that the controller should load
@@ -2,11 +2,13 @@ package controller
 
 import (
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/alloy/internal/dag"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
@@ -43,17 +45,28 @@ type Reference struct {
 // other components.
 func ComponentReferences(cn dag.Node, g *dag.Graph, l log.Logger, scope *vm.Scope, minStability featuregate.Stability) ([]Reference, diag.Diagnostics) {
 	var (
-		traversals []Traversal
+		w = traversalWalker{
+			evalCondition: func(cond ast.Expr, traversals []Traversal) (bool, bool) {
+				return evalCondition(cond, traversals, g, scope)
+			},
+		}
 
 		diags diag.Diagnostics
 	)
 
 	switch cn := cn.(type) {
+	case *ArgumentConfigNode:
+		// The type and the validation rules of an argument are checked by the
+		// caller of the module, so they can't reference anything in it.
+		if cn.Block() != nil {
+			w.walkBody(argument.ValueBody(cn.Block().Body))
+		}
 	case BlockNode:
 		if cn.Block() != nil {
-			traversals = expressionsFromBody(cn.Block().Body)
+			w.walkBody(cn.Block().Body)
 		}
 	}
+	traversals := w.traversals
 
 	refs := make([]Reference, 0, len(traversals))
 	for _, t := range traversals {
@@ -72,6 +85,11 @@ func ComponentReferences(cn dag.Node, g *dag.Graph, l log.Logger, scope *vm.Scop
 			// This is not super clean, but it should not create any problem since that the errors will be caught either during evaluation or while linking components
 			// inside of the foreach.
 			if _, ok := cn.(*ForeachConfigNode); !ok {
+				if _, ok := w.branches[t[0]]; ok {
+					for i := range resolveDiags {
+						resolveDiags[i].Message += "; both values of a conditional expression must reference existing components when its condition depends on other components"
+					}
+				}
 				diags = append(diags, resolveDiags...)
 			}
 			continue
@@ -100,15 +118,55 @@ func ComponentReferences(cn dag.Node, g *dag.Graph, l log.Logger, scope *vm.Scop
 	return refs, diags
 }
 
-// expressionsFromSyntaxBody recurses through body and finds all variable
-// references.
-func expressionsFromBody(body ast.Body) []Traversal {
-	var w traversalWalker
-	ast.Walk(&w, body)
+// evalCondition evaluates the condition of a conditional expression when the
+// graph is wired, so that only the value it selects references other
+// components. traversals are the traversals of the condition. It returns false
+// if the condition can change without the graph being wired again, that is if
+// it references components other than the arguments of the module, or if it
+// can't be evaluated.
+func evalCondition(cond ast.Expr, traversals []Traversal, g *dag.Graph, scope *vm.Scope) (value bool, ok bool) {
+	var defaults map[string]any
+	for _, t := range traversals {
+		ref, diags := resolveTraversal(t, g)
+		if diags.HasErrors() {
+			continue
+		}
+		// The module is loaded again when its arguments change.
+		arg, isArg := ref.Target.(*ArgumentConfigNode)
+		if !isArg {
+			return false, false
+		}
+		if args, _ := scope.Variables[argumentLabel].(map[string]any); args[arg.Label()] != nil {
+			continue
+		}
+		// The arguments which aren't set by the caller of the module are only
+		// cached once evaluated, after the graph is wired.
+		var argArgs argument.Arguments
+		if err := vm.New(argument.ValueBody(arg.Block().Body)).Evaluate(scope, &argArgs); err != nil {
+			return false, false
+		}
+		if defaults == nil {
+			defaults = make(map[string]any)
+		}
+		defaults[arg.Label()] = map[string]any{"value": argArgs.Default}
+	}
 
-	// Flush after the walk in case there was an in-progress traversal.
-	w.flush()
-	return w.traversals
+	if len(defaults) > 0 {
+		vars := maps.Clone(scope.Variables)
+		args, _ := vars[argumentLabel].(map[string]any)
+		args = maps.Clone(args)
+		if args == nil {
+			args = make(map[string]any)
+		}
+		maps.Copy(args, defaults)
+		vars[argumentLabel] = args
+		scope = vm.NewScope(vars)
+	}
+
+	if err := vm.New(cond).Evaluate(scope, &value); err != nil {
+		return false, false
+	}
+	return value, true
 }
 
 type traversalWalker struct {
@@ -116,6 +174,21 @@ type traversalWalker struct {
 
 	buildTraversal   bool      // Whether
 	currentTraversal Traversal // currentTraversal being built.
+
+	// evalCondition returns the value of the condition of a conditional
+	// expression, and whether it could be evaluated.
+	evalCondition func(cond ast.Expr, traversals []Traversal) (bool, bool)
+	// branches are the first identifiers of the traversals of the values of
+	// the conditional expressions whose condition couldn't be evaluated.
+	branches map[*ast.Ident]struct{}
+}
+
+// walkBody recurses through body and finds all variable references.
+func (tw *traversalWalker) walkBody(body ast.Body) {
+	ast.Walk(tw, body)
+
+	// Flush after the walk in case there was an in-progress traversal.
+	tw.flush()
 }
 
 func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
@@ -151,6 +224,57 @@ func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
 			ast.Walk(tw, arg)
 		}
 		return nil
+
+	case *ast.ConditionalExpr:
+		tw.flush()
+		start := len(tw.traversals)
+		ast.Walk(tw, n.Condition)
+		tw.flush()
+
+		// Only the value selected by the condition is evaluated, so the other
+		// value may reference components which don't exist.
+		if tw.evalCondition != nil {
+			if cond, ok := tw.evalCondition(n.Condition, tw.traversals[start:]); ok {
+				if cond {
+					ast.Walk(tw, n.True)
+				} else {
+					ast.Walk(tw, n.False)
+				}
+				tw.flush()
+				return nil
+			}
+		}
+
+		start = len(tw.traversals)
+		ast.Walk(tw, n.True)
+		tw.flush()
+		ast.Walk(tw, n.False)
+		tw.flush()
+		if tw.branches == nil {
+			tw.branches = make(map[*ast.Ident]struct{})
+		}
+		for _, t := range tw.traversals[start:] {
+			tw.branches[t[0]] = struct{}{}
+		}
+		return nil
+
+	case *ast.FuncExpr:
+		// Function parameters are only defined in the body of the function, so
+		// traversals starting with them aren't references. The conditions
+		// referencing them can't be evaluated.
+		tw.flush()
+
+		inner := traversalWalker{evalCondition: tw.evalCondition, branches: tw.branches}
+		ast.Walk(&inner, n.Body)
+		inner.flush()
+		tw.branches = inner.branches
+
+		for _, t := range inner.traversals {
+			if !isFuncParam(n, t[0].Name) {
+				tw.traversals = append(tw.traversals, t)
+			}
+		}
+		return nil
 	}
 
 	return tw
@@ -166,6 +290,15 @@ func (tw *traversalWalker) flush() {
 	tw.currentTraversal = nil
 }
 
+func isFuncParam(fn *ast.FuncExpr, name string) bool {
+	for _, param := range fn.Params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func resolveTraversal(t Traversal, g *dag.Graph) (Reference, diag.Diagnostics) {
 	var (
 		diags diag.Diagnostics
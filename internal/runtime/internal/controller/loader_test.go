@@ -221,6 +221,62 @@ func TestLoader(t *testing.T) {
 		require.NoError(t, diags.ErrorOrNil())
 	})
 
+	t.Run("Load component with function expression", func(t *testing.T) {
+		file := `
+			testcomponents.passthrough "static" {
+				input = "hello"
+			}
+
+			testcomponents.passthrough "mapped" {
+				input = string.join(array.map(["a", "b"], x => x + testcomponents.passthrough.static.output), ",")
+			}
+		`
+		l := controller.NewLoader(newLoaderOptionsWithStability(featuregate.StabilityExperimental))
+		diags := applyFromContent(t, l, []byte(file), nil, nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		// Only the traversal which doesn't start with the parameter is a reference.
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: []string{
+				"testcomponents.passthrough.static",
+				"testcomponents.passthrough.mapped",
+				"logging",
+				"tracing",
+			},
+			OutEdges: []edge{
+				{From: "testcomponents.passthrough.mapped", To: "testcomponents.passthrough.static"},
+			},
+		})
+	})
+
+	t.Run("Load component with conditional expression", func(t *testing.T) {
+		file := `
+			testcomponents.passthrough "static" {
+				input = "hello"
+			}
+
+			testcomponents.passthrough "selected" {
+				input = string.join(["a", "b"], "") == "ab" ? testcomponents.passthrough.static.output : testcomponents.passthrough.missing.output
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, []byte(file), nil, nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		// Only the value selected by the condition is a reference.
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: []string{
+				"testcomponents.passthrough.static",
+				"testcomponents.passthrough.selected",
+				"logging",
+				"tracing",
+			},
+			OutEdges: []edge{
+				{From: "testcomponents.passthrough.selected", To: "testcomponents.passthrough.static"},
+			},
+		})
+	})
+
 	t.Run("Load with correct stability level", func(t *testing.T) {
 		l := controller.NewLoader(newLoaderOptionsWithStability(featuregate.StabilityPublicPreview))
 		diags := applyFromContent(t, l, []byte(testFile), nil, nil)
@@ -33,7 +33,7 @@ func NewArgumentConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *Argu
 		componentName: block.GetBlockName(),
 
 		block: block,
-		eval:  vm.New(block.Body),
+		eval:  vm.New(argument.ValueBody(block.Body)),
 	}
 }
 
@@ -95,5 +95,5 @@ func (cn *ArgumentConfigNode) UpdateBlock(b *ast.BlockStmt) {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
 	cn.block = b
-	cn.eval = vm.New(b.Body)
+	cn.eval = vm.New(argument.ValueBody(b.Body))
 }
@@ -263,7 +263,8 @@ func (cn *ImportConfigNode) processImportedContent(content *ast.File) error {
 		switch componentName {
 		case declareType:
 			cn.processDeclareBlock(blockStmt)
-		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+			importsource.BlockNameS3, importsource.BlockNameOCI:
 			err := cn.processImportBlock(blockStmt, componentName)
 			if err != nil {
 				return err
@@ -296,8 +297,9 @@ func (cn *ImportConfigNode) processImportBlock(stmt *ast.BlockStmt, fullName str
 	// Children data paths are nested inside their parents to avoid collisions.
 	childGlobals.DataPath = filepath.Join(childGlobals.DataPath, cn.globalID)
 
-	if importsource.GetSourceType(cn.block.GetBlockName()) == importsource.HTTP && sourceType == importsource.File {
-		return fmt.Errorf("importing a module via import.http (nodeID: %s) that contains an import.file block is not supported", cn.nodeID)
+	// Modules fetched from HTTP servers and S3 buckets aren't stored locally, so they can't import files.
+	if parentType := importsource.GetSourceType(cn.block.GetBlockName()); (parentType == importsource.HTTP || parentType == importsource.S3) && sourceType == importsource.File {
+		return fmt.Errorf("importing a module via %s (nodeID: %s) that contains an import.file block is not supported", cn.block.GetBlockName(), cn.nodeID)
 	}
 
 	cn.importConfigNodesChildren[stmt.Label] = NewImportConfigNode(stmt, childGlobals, sourceType)
@@ -11,8 +11,10 @@ import (
 	"github.com/go-kit/log"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
@@ -206,6 +208,10 @@ func (cn *CustomComponentNode) evaluate(evalScope *vm.Scope) error {
 		return fmt.Errorf("loading custom component controller: %w", err)
 	}
 
+	if diags := checkArguments(cn.block, template, args); diags.HasErrors() {
+		return diags
+	}
+
 	// Reload the custom component with new config
 	if err := cn.managed.LoadBody(template, args, customComponentRegistry); err != nil {
 		return fmt.Errorf("updating custom component: %w", err)
@@ -213,6 +219,37 @@ func (cn *CustomComponentNode) evaluate(evalScope *vm.Scope) error {
 	return nil
 }
 
+// checkArguments checks the arguments of a custom component block against the
+// type and the validation rules of the argument blocks in its template. The
+// diagnostics point at the attributes of the custom component block.
+func checkArguments(block *ast.BlockStmt, template ast.Body, args map[string]any) diag.Diagnostics {
+	decls, diags := argument.Declarations(template)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, stmt := range block.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok {
+			continue
+		}
+		// Arguments which aren't declared are reported by the module.
+		decl, ok := decls[attr.Name.Name]
+		if !ok {
+			continue
+		}
+		if err := decl.Check(args[attr.Name.Name]); err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("invalid value for argument %q: %s", attr.Name.Name, err),
+				StartPos: ast.StartPos(attr.Value).Position(),
+				EndPos:   ast.EndPos(attr.Value).Position(),
+			})
+		}
+	}
+	return diags
+}
+
 func (cn *CustomComponentNode) Run(ctx context.Context) error {
 	cn.mut.RLock()
 	managed := cn.managed
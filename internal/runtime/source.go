@@ -80,7 +80,8 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 			case "declare":
 				declares = append(declares, stmt)
 			case "logging", "tracing", argument.BlockName, export.BlockName, foreach.BlockName,
-				importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+				importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+				importsource.BlockNameS3, importsource.BlockNameOCI:
 				configs = append(configs, stmt)
 			default:
 				components = append(components, stmt)
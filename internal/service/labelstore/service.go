@@ -140,17 +140,21 @@ func (s *Service) GetOrAddLink(componentID string, localRefID uint64, lbls label
 
 	labelHash := lbls.Hash()
 	globalID, found := s.labelsHashToGlobal[labelHash]
-	if found {
+	if !found {
+		// We have a value we have never seen before so increment the globalrefid and assign
+		s.globalRefID++
+		globalID = s.globalRefID
+		s.labelsHashToGlobal[labelHash] = globalID
+	}
+	// A local refid of 0 means the component didn't store the series, for
+	// example because it failed to append metadata of an unknown series. It
+	// must not be linked, or the series would be sent to the component with
+	// an invalid local refid.
+	if localRefID != 0 {
 		m.localToGlobal[localRefID] = globalID
 		m.globalToLocal[globalID] = localRefID
-		return globalID
 	}
-	// We have a value we have never seen before so increment the globalrefid and assign
-	s.globalRefID++
-	s.labelsHashToGlobal[labelHash] = s.globalRefID
-	m.localToGlobal[localRefID] = s.globalRefID
-	m.globalToLocal[s.globalRefID] = localRefID
-	return s.globalRefID
+	return globalID
 }
 
 // GetOrAddGlobalRefID is used to create a global refid for a labelset
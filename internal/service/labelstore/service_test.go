@@ -111,6 +111,22 @@ func TestAddingLocalMappingsWithoutCreatingGlobalUpfront(t *testing.T) {
 	require.True(t, mapping.mappings["2"].localToGlobal[1] == shouldBeSameGlobalID2)
 }
 
+func TestAddingLocalMappingWithoutLocalID(t *testing.T) {
+	mapping := New(log.NewNopLogger(), prometheus.DefaultRegisterer)
+	l := labels.FromStrings("__name__", "test")
+
+	// A local id of 0 is returned by components which failed to store the
+	// series, and isn't linked.
+	globalID := mapping.GetOrAddLink("1", 0, l)
+	require.Equal(t, globalID, mapping.GetOrAddGlobalRefID(l))
+	require.Empty(t, mapping.mappings["1"].globalToLocal)
+	require.Empty(t, mapping.mappings["1"].localToGlobal)
+	require.Zero(t, mapping.GetLocalRefID("1", globalID))
+
+	require.Equal(t, globalID, mapping.GetOrAddLink("1", 1, l))
+	require.Equal(t, uint64(1), mapping.GetLocalRefID("1", globalID))
+}
+
 func TestStaleness(t *testing.T) {
 	mapping := New(log.NewNopLogger(), prometheus.DefaultRegisterer)
 	l := labels.Labels{}
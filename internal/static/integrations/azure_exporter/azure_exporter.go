@@ -94,7 +94,7 @@ func (e Exporter) MetricsHandler() (http.Handler, error) {
 			prober.Run()
 		}
 
-		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(resp, req)
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(resp, req)
 	})
 	return h, nil
 }
@@ -90,7 +90,7 @@ func (e *exporter) MetricsHandler() (http.Handler, error) {
 			return
 		}
 
-		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, req)
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(w, req)
 	})
 	return h, nil
 }
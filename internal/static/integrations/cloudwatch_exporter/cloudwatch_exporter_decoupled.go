@@ -69,7 +69,7 @@ func (e *asyncExporter) MetricsHandler() (http.Handler, error) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, req)
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(w, req)
 	})
 	return h, nil
 }
@@ -79,6 +79,10 @@ func (i *CollectorIntegration) MetricsHandler() (http.Handler, error) {
 		r,
 		promhttp.HandlerOpts{
 			ErrorHandling: promhttp.ContinueOnError,
+			// OpenMetrics is served to the scrapers which prefer it, so that
+			// exemplars are kept. The Protobuf format, which also keeps native
+			// histograms, is always served to the scrapers which prefer it.
+			EnableOpenMetrics: true,
 		},
 	)
 
@@ -0,0 +1,64 @@
+package integrations
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorIntegration_MetricsHandlerFormats(t *testing.T) {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test counter."})
+	counter.(prometheus.ExemplarAdder).AddWithExemplar(1, prometheus.Labels{"trace_id": "abc"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:                        "test_duration_seconds",
+		Help:                        "Test histogram.",
+		NativeHistogramBucketFactor: 1.1,
+	})
+	histogram.Observe(0.5)
+
+	handler, err := NewCollectorIntegration("test", WithCollectors(counter, histogram)).MetricsHandler()
+	require.NoError(t, err)
+
+	serve := func(accept string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	t.Run("OpenMetrics keeps exemplars", func(t *testing.T) {
+		resp := serve("application/openmetrics-text;version=1.0.0")
+		require.Equal(t, expfmt.TypeOpenMetrics, expfmt.Format(resp.Header.Get("Content-Type")).FormatType())
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `test_requests_total 1.0 # {trace_id="abc"} 1.0`)
+	})
+
+	t.Run("Protobuf keeps native histograms", func(t *testing.T) {
+		resp := serve("application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited")
+		format := expfmt.Format(resp.Header.Get("Content-Type"))
+		require.Equal(t, expfmt.TypeProtoDelim, format.FormatType())
+
+		dec := expfmt.NewDecoder(resp.Body, format)
+		var found bool
+		for {
+			var mf dto.MetricFamily
+			if err := dec.Decode(&mf); err != nil {
+				require.ErrorIs(t, err, io.EOF)
+				break
+			}
+			if mf.GetName() == "test_duration_seconds" {
+				found = true
+				require.NotEmpty(t, mf.GetMetric()[0].GetHistogram().GetPositiveSpan())
+			}
+		}
+		require.True(t, found)
+	})
+}
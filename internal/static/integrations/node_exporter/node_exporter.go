@@ -68,6 +68,7 @@ func (i *Integration) MetricsHandler() (http.Handler, error) {
 			ErrorHandling:       promhttp.ContinueOnError,
 			MaxRequestsInFlight: 0,
 			Registry:            i.exporterMetricsRegistry,
+			EnableOpenMetrics:   true,
 		},
 	)
 
@@ -64,6 +64,7 @@ func (i *Integration) MetricsHandler() (http.Handler, error) {
 		promhttp.HandlerOpts{
 			ErrorHandling:       promhttp.ContinueOnError,
 			MaxRequestsInFlight: 0,
+			EnableOpenMetrics:   true,
 		},
 	), nil
 }
@@ -128,7 +128,7 @@ func Handler(w http.ResponseWriter, r *http.Request, logger log.Logger, snmpCfg
 	c := collector.New(r.Context(), target, authName, snmpContext, auth, nmodules, slog.New(logging.NewSlogGoKitHandler(logger)), NewSNMPMetrics(registry), concurrency, false)
 	registry.MustRegister(c)
 	// Delegate http serving to Prometheus client library, which will call collector.Collect.
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
 	h.ServeHTTP(w, r)
 	duration := time.Since(start).Seconds()
 	level.Debug(logger).Log("msg", "Finished scrape", "duration_seconds", duration)
@@ -184,7 +184,8 @@ func New(l log.Logger, c *Config) (integrations.Integration, error) {
 // MetricsHandler returns the HTTP handler for the integration.
 func (e *Exporter) MetricsHandler() (http.Handler, error) {
 	return promhttp.HandlerFor(e.reg, promhttp.HandlerOpts{
-		ErrorHandling: promhttp.ContinueOnError,
+		ErrorHandling:     promhttp.ContinueOnError,
+		EnableOpenMetrics: true,
 	}), nil
 }
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 )
 
@@ -21,6 +22,10 @@ type memSeries struct {
 
 	// Last recorded timestamp. Used by gc to determine if a series is stale.
 	lastTs int64
+
+	// Last metadata logged for the series, to only log metadata records when
+	// the metadata changes.
+	meta *metadata.Metadata
 }
 
 // updateTimestamp obtains the lock on s and will attempt to update lastTs.
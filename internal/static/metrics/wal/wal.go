@@ -5,10 +5,13 @@ package wal
 // minor changes for metric names.
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -17,12 +20,14 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/model/timestamp"
 	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/scrape"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunks"
@@ -291,6 +296,11 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 				return []record.RefFloatHistogramSample{}
 			},
 		}
+		metadataPool = sync.Pool{
+			New: func() interface{} {
+				return []record.RefMetadata{}
+			},
+		}
 	)
 
 	go func() {
@@ -345,6 +355,18 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 					return
 				}
 				decoded <- floatHistograms
+			case record.Metadata:
+				meta := metadataPool.Get().([]record.RefMetadata)[:0]
+				meta, err := dec.Metadata(rec, meta)
+				if err != nil {
+					errCh <- &wlog.CorruptionErr{
+						Err:     fmt.Errorf("decode metadata: %w", err),
+						Segment: r.Segment(),
+						Offset:  r.Offset(),
+					}
+					return
+				}
+				decoded <- meta
 			case record.Tombstones, record.Exemplars:
 				// We don't care about decoding tombstones or exemplars
 				// TODO: If decide to decode exemplars, we should make sure to prepopulate
@@ -436,6 +458,22 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 
 			//nolint:staticcheck
 			floatHistogramsPool.Put(v)
+		case []record.RefMetadata:
+			for _, m := range v {
+				ref, ok := multiRef[m.Ref]
+				if !ok {
+					continue
+				}
+				series := w.series.GetByID(ref)
+				series.meta = &metadata.Metadata{
+					Type: record.ToMetricType(m.Type),
+					Unit: m.Unit,
+					Help: m.Help,
+				}
+			}
+
+			//nolint:staticcheck
+			metadataPool.Put(v)
 		default:
 			panic(fmt.Errorf("unexpected decoded type: %T", d))
 		}
@@ -577,6 +615,60 @@ func (w *Storage) gc(mint int64) {
 	w.metrics.numDeletedSeries.Set(float64(len(w.deleted)))
 }
 
+// ListMetadata returns the metadata of the metric families of the active
+// series. Each distinct metadata of a metric family is returned once, and the
+// series without metadata are skipped.
+func (w *Storage) ListMetadata() []scrape.MetricMetadata {
+	set := map[scrape.MetricMetadata]struct{}{}
+	it := w.series.iterator()
+	for series := range it.Channel() {
+		if series.meta == nil {
+			continue
+		}
+		md := scrape.MetricMetadata{
+			Metric: metricFamilyName(series.lset.Get(labels.MetricName), series.meta.Type),
+			Type:   series.meta.Type,
+			Help:   series.meta.Help,
+			Unit:   series.meta.Unit,
+		}
+		set[md] = struct{}{}
+	}
+
+	res := make([]scrape.MetricMetadata, 0, len(set))
+	for md := range set {
+		res = append(res, md)
+	}
+	slices.SortFunc(res, func(a, b scrape.MetricMetadata) int {
+		return cmp.Or(
+			strings.Compare(a.Metric, b.Metric),
+			strings.Compare(string(a.Type), string(b.Type)),
+			strings.Compare(a.Help, b.Help),
+			strings.Compare(a.Unit, b.Unit),
+		)
+	})
+	return res
+}
+
+// metricFamilyName returns the name of the metric family of a series, by
+// removing the suffixes of the series of histograms and summaries.
+func metricFamilyName(name string, typ model.MetricType) string {
+	var suffixes []string
+	switch typ {
+	case model.MetricTypeHistogram:
+		suffixes = []string{"_bucket", "_sum", "_count"}
+	case model.MetricTypeGaugeHistogram:
+		suffixes = []string{"_bucket", "_gsum", "_gcount"}
+	case model.MetricTypeSummary:
+		suffixes = []string{"_sum", "_count"}
+	}
+	for _, suffix := range suffixes {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok {
+			return trimmed
+		}
+	}
+	return name
+}
+
 // WriteStalenessMarkers appends a staleness sample for all active series.
 func (w *Storage) WriteStalenessMarkers(remoteTsFunc func() int64) error {
 	var lastErr error
@@ -660,6 +752,7 @@ type appender struct {
 	pendingExamplars       []record.RefExemplar
 	pendingHistograms      []record.RefHistogramSample
 	pendingFloatHistograms []record.RefFloatHistogramSample
+	pendingMetadata        []record.RefMetadata
 
 	// Pointers to the series referenced by each element of pendingSamples.
 	// Series lock is not held on elements.
@@ -855,14 +948,54 @@ func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int
 	return storage.SeriesRef(series.ref), nil
 }
 
-func (a *appender) AppendCTZeroSample(_ storage.SeriesRef, _ labels.Labels, _ int64, _ int64) (storage.SeriesRef, error) {
-	// TODO(ptodev): implement this later
-	return 0, nil
+// AppendCTZeroSample appends a sample with a value of 0 at the created
+// timestamp ct, mirroring the TSDB's headAppender.
+func (a *appender) AppendCTZeroSample(ref storage.SeriesRef, l labels.Labels, t int64, ct int64) (storage.SeriesRef, error) {
+	if ct >= t {
+		return 0, fmt.Errorf("CT is newer or the same as sample's timestamp, ignoring")
+	}
+
+	series := a.w.series.GetByID(chunks.HeadSeriesRef(ref))
+	if series != nil {
+		// Long-living counters share the same created timestamp, so the zero
+		// sample is only appended once.
+		series.Lock()
+		lastTs := series.lastTs
+		series.Unlock()
+		if ct <= lastTs {
+			return storage.SeriesRef(series.ref), storage.ErrOutOfOrderCT
+		}
+	}
+
+	return a.Append(ref, l, ct, 0)
 }
 
-func (a *appender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
-	// TODO(rfratto): implement pushing metadata to WAL
-	return 0, nil
+// UpdateMetadata logs the metadata of a series. A metadata record is only
+// written when the metadata of the series changes.
+func (a *appender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	series := a.w.series.GetByID(chunks.HeadSeriesRef(ref))
+	if series == nil {
+		series = a.w.series.GetByHash(l.Hash(), l)
+	}
+	if series == nil {
+		return 0, fmt.Errorf("unknown series when trying to add metadata with HeadSeriesRef: %d and labels: %s", ref, l)
+	}
+
+	series.Lock()
+	defer series.Unlock()
+
+	if series.meta != nil && *series.meta == m {
+		return storage.SeriesRef(series.ref), nil
+	}
+	series.meta = &m
+
+	a.pendingMetadata = append(a.pendingMetadata, record.RefMetadata{
+		Ref:  series.ref,
+		Type: record.GetMetricType(m.Type),
+		Unit: m.Unit,
+		Help: m.Help,
+	})
+	return storage.SeriesRef(series.ref), nil
 }
 
 // Commit submits the collected samples and purges the batch.
@@ -902,6 +1035,16 @@ func (a *appender) log() error {
 		buf = buf[:0]
 	}
 
+	// Metadata is logged before samples so that it's known by the remote
+	// write queues when the samples of the series are sent.
+	if len(a.pendingMetadata) > 0 {
+		buf = encoder.Metadata(a.pendingMetadata, buf)
+		if err := a.w.wal.Log(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+
 	if len(a.pendingSamples) > 0 {
 		buf = encoder.Samples(a.pendingSamples, buf)
 		if err := a.w.wal.Log(buf); err != nil {
@@ -968,6 +1111,7 @@ func (a *appender) clearData() {
 	a.pendingHistograms = a.pendingHistograms[:0]
 	a.pendingFloatHistograms = a.pendingFloatHistograms[:0]
 	a.pendingExamplars = a.pendingExamplars[:0]
+	a.pendingMetadata = a.pendingMetadata[:0]
 	a.sampleSeries = a.sampleSeries[:0]
 	a.histogramSeries = a.histogramSeries[:0]
 	a.floatHistogramSeries = a.floatHistogramSeries[:0]
@@ -976,7 +1120,8 @@ func (a *appender) clearData() {
 func (a *appender) Rollback() error {
 	// Series are created in-memory regardless of rollback. This means we must
 	// log them to the WAL, otherwise subsequent commits may reference a series
-	// which was never written to the WAL.
+	// which was never written to the WAL. Metadata is also kept in-memory
+	// regardless of rollback, so it's logged alongside the series.
 	if err := a.logSeries(); err != nil {
 		return err
 	}
@@ -986,7 +1131,7 @@ func (a *appender) Rollback() error {
 	return nil
 }
 
-// logSeries logs only pending series records to the WAL.
+// logSeries logs only pending series and metadata records to the WAL.
 func (a *appender) logSeries() error {
 	a.w.walMtx.RLock()
 	defer a.w.walMtx.RUnlock()
@@ -1009,5 +1154,18 @@ func (a *appender) logSeries() error {
 		buf = buf[:0]
 	}
 
+	if len(a.pendingMetadata) > 0 {
+		var encoder record.Encoder
+		buf := a.w.bufPool.Get().([]byte)
+		defer func() {
+			a.w.bufPool.Put(buf) //nolint:staticcheck
+		}()
+
+		buf = encoder.Metadata(a.pendingMetadata, buf)
+		if err := a.w.wal.Log(buf); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -10,9 +10,12 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/alloy/internal/util"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/scrape"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunks"
@@ -181,6 +184,139 @@ func TestStorage_DuplicateExemplarsIgnored(t *testing.T) {
 	require.Equal(t, 4, len(collector.exemplars))
 }
 
+func TestStorage_Metadata(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+
+	app := s.Appender(t.Context())
+
+	lbls := labels.FromStrings("__name__", "requests_total")
+	sRef, err := app.Append(0, lbls, 10, 1)
+	require.NoError(t, err)
+
+	// Metadata is only logged when it changes.
+	m := metadata.Metadata{Type: model.MetricTypeCounter, Unit: "requests", Help: "Total requests."}
+	_, err = app.UpdateMetadata(sRef, lbls, m)
+	require.NoError(t, err)
+	_, err = app.UpdateMetadata(sRef, lbls, m)
+	require.NoError(t, err)
+	m.Help = "Total number of requests."
+	_, err = app.UpdateMetadata(sRef, lbls, m)
+	require.NoError(t, err)
+
+	_, err = app.UpdateMetadata(0, labels.FromStrings("__name__", "unknown"), m)
+	require.Error(t, err, "should reject metadata of unknown series")
+
+	require.NoError(t, app.Commit())
+
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+
+	require.Equal(t, []record.RefMetadata{
+		{Ref: chunks.HeadSeriesRef(sRef), Type: uint8(record.Counter), Unit: "requests", Help: "Total requests."},
+		{Ref: chunks.HeadSeriesRef(sRef), Type: uint8(record.Counter), Unit: "requests", Help: "Total number of requests."},
+	}, collector.metadata)
+
+	// The metadata is restored when the WAL is replayed, so it isn't logged
+	// again if it didn't change.
+	require.NoError(t, s.Close())
+	s, err = NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	app = s.Appender(t.Context())
+	_, err = app.UpdateMetadata(0, lbls, m)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	collector = walDataCollector{}
+	replayer = walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+	require.Len(t, collector.metadata, 2)
+}
+
+func TestStorage_ListMetadata(t *testing.T) {
+	s, err := NewStorage(log.NewNopLogger(), nil, t.TempDir())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	counter := metadata.Metadata{Type: model.MetricTypeCounter, Unit: "requests", Help: "Total requests."}
+	histogram := metadata.Metadata{Type: model.MetricTypeHistogram, Unit: "seconds", Help: "Request duration."}
+
+	app := s.Appender(t.Context())
+	for _, series := range []struct {
+		lbls labels.Labels
+		meta *metadata.Metadata
+	}{
+		{labels.FromStrings("__name__", "requests_total", "job", "a"), &counter},
+		{labels.FromStrings("__name__", "requests_total", "job", "b"), &counter},
+		{labels.FromStrings("__name__", "duration_seconds_bucket", "le", "1"), &histogram},
+		{labels.FromStrings("__name__", "duration_seconds_sum"), &histogram},
+		{labels.FromStrings("__name__", "duration_seconds_count"), &histogram},
+		{labels.FromStrings("__name__", "no_metadata"), nil},
+	} {
+		ref, err := app.Append(0, series.lbls, 0, 1)
+		require.NoError(t, err)
+		if series.meta != nil {
+			_, err = app.UpdateMetadata(ref, series.lbls, *series.meta)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, app.Commit())
+
+	// The metadata is listed once per metric family.
+	require.Equal(t, []scrape.MetricMetadata{
+		{Metric: "duration_seconds", Type: model.MetricTypeHistogram, Unit: "seconds", Help: "Request duration."},
+		{Metric: "requests_total", Type: model.MetricTypeCounter, Unit: "requests", Help: "Total requests."},
+	}, s.ListMetadata())
+}
+
+func TestStorage_CTZeroSample(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	lbls := labels.FromStrings("__name__", "requests_total")
+
+	app := s.Appender(t.Context())
+	ref, err := app.AppendCTZeroSample(0, lbls, 100, 50)
+	require.NoError(t, err)
+	_, err = app.Append(ref, lbls, 100, 5)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	app = s.Appender(t.Context())
+	// The created timestamp didn't change, so it's out of order.
+	_, err = app.AppendCTZeroSample(ref, lbls, 200, 50)
+	require.ErrorIs(t, err, storage.ErrOutOfOrderCT)
+	_, err = app.AppendCTZeroSample(ref, lbls, 200, 200)
+	require.Error(t, err, "should reject created timestamps which aren't older than the sample")
+	_, err = app.Append(ref, lbls, 200, 7)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+
+	require.Equal(t, []record.RefSample{
+		{Ref: chunks.HeadSeriesRef(ref), T: 50, V: 0},
+		{Ref: chunks.HeadSeriesRef(ref), T: 100, V: 5},
+		{Ref: chunks.HeadSeriesRef(ref), T: 200, V: 7},
+	}, collector.samples)
+}
+
 func TestStorage_ExistingWAL(t *testing.T) {
 	walDir := t.TempDir()
 
@@ -23,17 +23,20 @@ type MetricSample struct {
 type CollectingAppender interface {
 	storage.Appender
 	CollectedSamples() map[string]*MetricSample
+	CollectedMetadata() map[string]metadata.Metadata
 	LatestSampleFor(labels string) *MetricSample
 }
 
 type collectingAppender struct {
-	mut           sync.Mutex
-	latestSamples map[string]*MetricSample
+	mut            sync.Mutex
+	latestSamples  map[string]*MetricSample
+	latestMetadata map[string]metadata.Metadata
 }
 
 func NewCollectingAppender() CollectingAppender {
 	return &collectingAppender{
-		latestSamples: map[string]*MetricSample{},
+		latestSamples:  map[string]*MetricSample{},
+		latestMetadata: map[string]metadata.Metadata{},
 	}
 }
 
@@ -45,6 +48,16 @@ func (c *collectingAppender) CollectedSamples() map[string]*MetricSample {
 	return cp
 }
 
+// CollectedMetadata returns the latest metadata of each series, keyed by the
+// labels of the series.
+func (c *collectingAppender) CollectedMetadata() map[string]metadata.Metadata {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	cp := map[string]metadata.Metadata{}
+	maps.Copy(cp, c.latestMetadata)
+	return cp
+}
+
 func (c *collectingAppender) LatestSampleFor(labels string) *MetricSample {
 	c.mut.Lock()
 	defer c.mut.Unlock()
@@ -79,7 +92,10 @@ func (c *collectingAppender) AppendHistogram(ref storage.SeriesRef, l labels.Lab
 }
 
 func (c *collectingAppender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
-	panic("not implemented yet")
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.latestMetadata[l.String()] = m
+	return ref, nil
 }
 
 func (c *collectingAppender) AppendCTZeroSample(ref storage.SeriesRef, l labels.Labels, t, ct int64) (storage.SeriesRef, error) {
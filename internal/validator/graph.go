@@ -4,6 +4,7 @@ import (
 	"iter"
 
 	"github.com/grafana/alloy/internal/dag"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
 	"github.com/grafana/alloy/syntax/typecheck"
@@ -44,7 +45,15 @@ func validateGraph(s *state) diag.Diagnostics {
 			// Add any diagnostic for node that should be before type check.
 			diags.Merge(node.diags)
 			if node.args != nil {
-				diags.Merge(typecheck.Block(node.block, node.args))
+				block := node.block
+				if block.GetBlockName() == argument.BlockName {
+					// The type and the validation blocks of arguments are checked
+					// with their declaration.
+					valueBlock := *block
+					valueBlock.Body = argument.ValueBody(block.Body)
+					block = &valueBlock
+				}
+				diags.Merge(typecheck.Block(block, node.args))
 			}
 		case *componentNode:
 			name := node.block.GetBlockName()
@@ -182,7 +182,7 @@ func (v *validator) validateConfigs(s *state) {
 		}
 
 		// In configs we store blocks for logging, tracing, argument, export, import.file,
-		// import.string, import.http, import.git and foreach.
+		// import.string, import.http, import.git, import.s3, import.oci and foreach.
 		switch node.block.GetBlockName() {
 		case "logging":
 			node.args = &logging.Options{}
@@ -200,6 +200,8 @@ func (v *validator) validateConfigs(s *state) {
 			v.validateForeach(node, s)
 		case argument.BlockName:
 			node.args = &argument.Arguments{}
+			_, declDiags := argument.ParseDeclaration(node.block)
+			node.diags.Merge(declDiags)
 			if s.root {
 				node.diags.Add(diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
@@ -258,6 +260,12 @@ func (v *validator) validateImport(node *blockNode, register bool, s *state) {
 	case importsource.BlockNameGit:
 		node.args = &importsource.GitArguments{}
 		s.graph.Add(node)
+	case importsource.BlockNameS3:
+		node.args = &importsource.S3Arguments{}
+		s.graph.Add(node)
+	case importsource.BlockNameOCI:
+		node.args = &importsource.OCIArguments{}
+		s.graph.Add(node)
 	}
 
 	if register {
@@ -396,6 +404,7 @@ func (v *validator) validateServices(s *state) {
 var configBlockNames = [...]string{
 	foreach.BlockName, argument.BlockName, export.BlockName, "logging", "tracing",
 	importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+	importsource.BlockNameS3, importsource.BlockNameOCI,
 }
 
 // extractBlocks extracts configs, declares and components blocks from body
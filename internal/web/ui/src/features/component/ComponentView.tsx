@@ -10,6 +10,7 @@ import ComponentBody from './ComponentBody';
 import ComponentList from './ComponentList';
 import ForeachList from './ForeachList';
 import { HealthLabel } from './HealthLabel';
+import ScrapeDebug from './ScrapeDebug';
 import { ComponentDetail, ComponentInfo, PartitionedBody } from './types';
 
 import styles from './ComponentView.module.css';
@@ -34,6 +35,7 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
 
   const isModule = props.component.moduleInfo && props.component.name !== 'foreach';
   const isForeach = props.component.moduleInfo && props.component.name === 'foreach';
+  const isScrape = props.component.name === 'prometheus.scrape';
   function partitionTOC(partition: PartitionedBody): ReactElement {
     return (
       <li>
@@ -79,6 +81,13 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           {argsPartition && partitionTOC(argsPartition)}
           {exportsPartition && partitionTOC(exportsPartition)}
           {debugPartition && partitionTOC(debugPartition)}
+          {isScrape && (
+            <li>
+              <Link to="#debug-scrape" target="_top">
+                Debug scrape
+              </Link>
+            </li>
+          )}
           {props.component.referencesTo.length > 0 && (
             <li>
               <Link to="#dependencies" target="_top">
@@ -146,6 +155,12 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
         <ComponentBody partition={argsPartition} />
         {exportsPartition && <ComponentBody partition={exportsPartition} />}
         {debugPartition && <ComponentBody partition={debugPartition} />}
+        {isScrape && (
+          <ScrapeDebug
+            componentID={pathJoin([props.component.moduleID, props.component.localID])}
+            debugInfo={props.component.debugInfo}
+          />
+        )}
 
         {props.component.referencesTo.length > 0 && (
           <section id="dependencies">
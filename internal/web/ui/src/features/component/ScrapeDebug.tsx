@@ -0,0 +1,180 @@
+import { FC, useState } from 'react';
+
+import { Body as AlloyBody, StmtType, ValueType } from '../alloy-syntax-js/types';
+
+import Table from './Table';
+
+import styles from './ComponentView.module.css';
+
+/**
+ * DebugScrapeResult is the result of an on-demand scrape of a target of a
+ * prometheus.scrape component.
+ */
+interface DebugScrapeResult {
+  job: string;
+  url: string;
+  labels: Record<string, string>;
+  duration: string;
+  status_code?: number;
+  response_headers?: Record<string, string[]>;
+  error?: string;
+  samples: DebugSample[];
+  sample_limit_exceeded: boolean;
+}
+
+interface DebugSample {
+  labels: string;
+  timestamp: number;
+  value: string;
+  rejected?: string;
+}
+
+interface ScrapeDebugProps {
+  /** The global ID of the prometheus.scrape component. */
+  componentID: string;
+  debugInfo?: AlloyBody;
+}
+
+/**
+ * targetURLs returns the URLs of the targets reported in the debug info of a
+ * prometheus.scrape component.
+ */
+function targetURLs(debugInfo?: AlloyBody): string[] {
+  const urls: string[] = [];
+  for (const stmt of debugInfo || []) {
+    if (stmt.type !== StmtType.BLOCK || stmt.name !== 'target') {
+      continue;
+    }
+    for (const attr of stmt.body) {
+      if (attr.type === StmtType.ATTR && attr.name === 'url' && attr.value.type === ValueType.STRING) {
+        urls.push(attr.value.value);
+      }
+    }
+  }
+  return urls.sort();
+}
+
+/**
+ * ScrapeDebug scrapes a target of a prometheus.scrape component on demand,
+ * and shows the response headers and the samples of the target.
+ */
+const ScrapeDebug: FC<ScrapeDebugProps> = ({ componentID, debugInfo }) => {
+  const urls = targetURLs(debugInfo);
+  const [selected, setSelected] = useState(urls[0] || '');
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState('');
+  const [result, setResult] = useState<DebugScrapeResult | undefined>(undefined);
+
+  const scrape = async () => {
+    setLoading(true);
+    setError('');
+    try {
+      // Request is relative to the <base> tag inside of <head>.
+      const resp = await fetch(`./api/v0/component/${componentID}/scrape?url=${encodeURIComponent(selected)}`, {
+        cache: 'no-cache',
+        credentials: 'same-origin',
+      });
+      if (!resp.ok) {
+        throw new Error(`Failed to scrape target, status code: ${resp.status}, reason: ${await resp.text()}`);
+      }
+      setResult((await resp.json()) as DebugScrapeResult);
+    } catch (err) {
+      setResult(undefined);
+      setError(err instanceof Error ? err.message : String(err));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  if (urls.length === 0) {
+    return (
+      <section id="debug-scrape">
+        <h2>Debug scrape</h2>
+        <div className={styles.sectionContent}>
+          <em className={styles.informative}>(No active targets)</em>
+        </div>
+      </section>
+    );
+  }
+
+  return (
+    <section id="debug-scrape">
+      <h2>Debug scrape</h2>
+      <div className={styles.sectionContent}>
+        <select value={selected} onChange={(e) => setSelected(e.target.value)}>
+          {urls.map((url) => (
+            <option key={url} value={url}>
+              {url}
+            </option>
+          ))}
+        </select>{' '}
+        <button onClick={scrape} disabled={loading || selected === ''}>
+          {loading ? 'Scraping…' : 'Scrape now'}
+        </button>
+        {error && <p>{error}</p>}
+        {result && <ScrapeDebugResult result={result} />}
+      </div>
+    </section>
+  );
+};
+
+const ScrapeDebugResult: FC<{ result: DebugScrapeResult }> = ({ result }) => {
+  const headers = Object.entries(result.response_headers || {}).sort(([a], [b]) => a.localeCompare(b));
+
+  return (
+    <>
+      <p>
+        Scraped <code>{result.url}</code> in {result.duration}
+        {result.status_code !== undefined && <> with status code {result.status_code}</>}.
+      </p>
+      {result.error && (
+        <blockquote>
+          <p>{result.error}</p>
+        </blockquote>
+      )}
+      {result.sample_limit_exceeded && (
+        <blockquote>
+          <p>The target exposes more samples than sample_limit: the whole scrape would be rejected.</p>
+        </blockquote>
+      )}
+
+      {headers.length > 0 && (
+        <>
+          <h3>Response headers</h3>
+          <Table
+            tableHeaders={['Name', 'Value']}
+            renderTableData={() =>
+              headers.map(([name, values]) => (
+                <tr key={name}>
+                  <td className={styles.nameColumn}>{name}</td>
+                  <td>{values.join(', ')}</td>
+                </tr>
+              ))
+            }
+          />
+        </>
+      )}
+
+      <h3>Samples ({result.samples.length})</h3>
+      <Table
+        tableHeaders={['Labels', 'Value', 'Timestamp', 'Rejected']}
+        renderTableData={() =>
+          result.samples.map((sample, idx) => (
+            <tr key={idx}>
+              <td>
+                <code>{sample.labels}</code>
+              </td>
+              <td>
+                <code>{sample.value}</code>
+              </td>
+              <td>{sample.timestamp}</td>
+              <td>{sample.rejected}</td>
+            </tr>
+          ))
+        }
+      />
+    </>
+  );
+};
+
+export default ScrapeDebug;
@@ -0,0 +1,96 @@
+package stdlib
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// The helpers below type check the arguments of raw functions. Type errors
+// are reported as value.ArgError so that diagnostics point at the invalid
+// argument.
+
+// checkArgCount returns an error if a function named name is called with a
+// different number of arguments than expected.
+func checkArgCount(name string, args []value.Value, expected int) error {
+	if len(args) != expected {
+		return fmt.Errorf("%s: expected %d arguments, got %d", name, expected, len(args))
+	}
+	return nil
+}
+
+// argError wraps inner into an ArgError for the argument at index i.
+func argError(funcValue value.Value, args []value.Value, i int, inner error) error {
+	return value.ArgError{
+		Function: funcValue,
+		Argument: args[i],
+		Index:    i,
+		Inner:    inner,
+	}
+}
+
+// stringArg returns the argument at index i as a string. Values which can be
+// converted to strings, such as numbers, are accepted.
+func stringArg(funcValue value.Value, args []value.Value, i int) (string, error) {
+	var s string
+	if err := value.Decode(args[i], &s); err != nil {
+		return "", argError(funcValue, args, i, err)
+	}
+	return s, nil
+}
+
+// stringsArg returns the argument at index i as an array of strings.
+func stringsArg(funcValue value.Value, args []value.Value, i int) ([]string, error) {
+	if args[i].Type() != value.TypeArray {
+		return nil, argError(funcValue, args, i, value.TypeError{Value: args[i], Expected: value.TypeArray})
+	}
+	var ss []string
+	if err := value.Decode(args[i], &ss); err != nil {
+		return nil, argError(funcValue, args, i, err)
+	}
+	return ss, nil
+}
+
+// regexpArg returns the argument at index i as a compiled regular
+// expression.
+func regexpArg(funcValue value.Value, args []value.Value, i int) (*regexp.Regexp, error) {
+	pattern, err := stringArg(funcValue, args, i)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, argError(funcValue, args, i, fmt.Errorf("invalid regular expression: %w", err))
+	}
+	return re, nil
+}
+
+// objectArg returns the argument at index i as an object. Capsules which can
+// be converted to objects, such as targets, are accepted.
+func objectArg(funcValue value.Value, args []value.Value, i int) (value.Value, error) {
+	arg := args[i]
+	if arg.Type() == value.TypeObject {
+		return arg, nil
+	}
+	if obj, ok := arg.TryConvertToObject(); ok {
+		return value.Object(obj), nil
+	}
+	return value.Null, argError(funcValue, args, i, value.TypeError{Value: arg, Expected: value.TypeObject})
+}
+
+// arrayArg returns an error if the argument at index i isn't an array.
+func arrayArg(funcValue value.Value, args []value.Value, i int) (value.Value, error) {
+	if args[i].Type() != value.TypeArray {
+		return value.Null, argError(funcValue, args, i, value.TypeError{Value: args[i], Expected: value.TypeArray})
+	}
+	return args[i], nil
+}
+
+// funcArg returns an error if the argument at index i isn't a function.
+func funcArg(funcValue value.Value, args []value.Value, i int) (value.Value, error) {
+	if args[i].Type() != value.TypeFunction {
+		return value.Null, argError(funcValue, args, i, value.TypeError{Value: args[i], Expected: value.TypeFunction})
+	}
+	return args[i], nil
+}
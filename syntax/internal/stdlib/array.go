@@ -0,0 +1,127 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// The functions below take a function as their last argument, which is
+// usually a function expression such as x => x * 2. Errors returned by the
+// function are returned unmodified so that they point at its body.
+
+// arrayMap returns an array with the result of calling a function on each
+// element of an array.
+var arrayMap = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("map", args, 2); err != nil {
+		return value.Null, err
+	}
+	arr, fn, err := arrayAndFuncArgs(funcValue, args)
+	if err != nil {
+		return value.Null, err
+	}
+
+	res := make([]value.Value, 0, arr.Len())
+	for i := 0; i < arr.Len(); i++ {
+		elem, err := fn.Call(arr.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		res = append(res, elem)
+	}
+	return value.Array(res...), nil
+})
+
+// arrayFilter returns an array with the elements of an array for which a
+// function returns true.
+var arrayFilter = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("filter", args, 2); err != nil {
+		return value.Null, err
+	}
+	arr, fn, err := arrayAndFuncArgs(funcValue, args)
+	if err != nil {
+		return value.Null, err
+	}
+
+	var res []value.Value
+	for i := 0; i < arr.Len(); i++ {
+		keep, err := fn.Call(arr.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		if keep.Type() != value.TypeBool {
+			return value.Null, argError(funcValue, args, 1, fmt.Errorf("must return a bool, got %s", keep.Type()))
+		}
+		if keep.Bool() {
+			res = append(res, arr.Index(i))
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// arrayReduce combines the elements of an array into a single value by
+// calling a function with the result so far and each element, starting from
+// an initial value.
+var arrayReduce = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("reduce", args, 3); err != nil {
+		return value.Null, err
+	}
+	arr, err := arrayArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	fn, err := funcArg(funcValue, args, 2)
+	if err != nil {
+		return value.Null, err
+	}
+
+	acc := args[1]
+	for i := 0; i < arr.Len(); i++ {
+		acc, err = fn.Call(acc, arr.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+	}
+	return acc, nil
+})
+
+// arrayFlatMap is like arrayMap, but the function returns arrays which are
+// concatenated.
+var arrayFlatMap = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("flat_map", args, 2); err != nil {
+		return value.Null, err
+	}
+	arr, fn, err := arrayAndFuncArgs(funcValue, args)
+	if err != nil {
+		return value.Null, err
+	}
+
+	var res []value.Value
+	for i := 0; i < arr.Len(); i++ {
+		elems, err := fn.Call(arr.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		if elems.Type() != value.TypeArray {
+			return value.Null, argError(funcValue, args, 1, fmt.Errorf("must return an array, got %s", elems.Type()))
+		}
+		for j := 0; j < elems.Len(); j++ {
+			res = append(res, elems.Index(j))
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// arrayAndFuncArgs returns the array and the function arguments of the
+// functions which call a function on each element of an array.
+func arrayAndFuncArgs(funcValue value.Value, args []value.Value) (value.Value, value.Value, error) {
+	arr, err := arrayArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, value.Null, err
+	}
+	fn, err := funcArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, value.Null, err
+	}
+	return arr, fn, nil
+}
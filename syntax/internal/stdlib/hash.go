@@ -0,0 +1,48 @@
+package stdlib
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// sha256Hash returns the hex-encoded SHA-256 hash of a string.
+var sha256Hash = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("sha256", args, 1); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	sum := sha256.Sum256([]byte(s))
+	return value.String(hex.EncodeToString(sum[:])), nil
+})
+
+// md5Hash returns the hex-encoded MD5 hash of a string.
+var md5Hash = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("md5", args, 1); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	sum := md5.Sum([]byte(s))
+	return value.String(hex.EncodeToString(sum[:])), nil
+})
+
+// crc32Hash returns the IEEE CRC-32 checksum of a string as a number.
+var crc32Hash = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("crc32", args, 1); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	return value.Uint(uint64(crc32.ChecksumIEEE([]byte(s)))), nil
+})
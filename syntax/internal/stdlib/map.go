@@ -0,0 +1,114 @@
+package stdlib
+
+import (
+	"slices"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// mapKeys returns the sorted keys of an object.
+var mapKeys = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("keys", args, 1); err != nil {
+		return value.Null, err
+	}
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := sortedKeys(obj)
+	res := make([]value.Value, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, value.String(key))
+	}
+	return value.Array(res...), nil
+})
+
+// mapValues returns the values of an object, ordered by their keys.
+var mapValues = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("values", args, 1); err != nil {
+		return value.Null, err
+	}
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := sortedKeys(obj)
+	res := make([]value.Value, 0, len(keys))
+	for _, key := range keys {
+		val, _ := obj.Key(key)
+		res = append(res, val)
+	}
+	return value.Array(res...), nil
+})
+
+// mapMerge merges objects. If a key exists in several objects, the value of
+// the last one is used.
+var mapMerge = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	res := make(map[string]value.Value)
+	for i := range args {
+		obj, err := objectArg(funcValue, args, i)
+		if err != nil {
+			return value.Null, err
+		}
+		for _, key := range obj.Keys() {
+			res[key], _ = obj.Key(key)
+		}
+	}
+	return value.Object(res), nil
+})
+
+// mapPick returns an object with only the given keys of an object. Keys
+// which don't exist are ignored.
+var mapPick = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("pick", args, 2); err != nil {
+		return value.Null, err
+	}
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	keys, err := stringsArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+
+	res := make(map[string]value.Value, len(keys))
+	for _, key := range keys {
+		if val, ok := obj.Key(key); ok {
+			res[key] = val
+		}
+	}
+	return value.Object(res), nil
+})
+
+// mapOmit returns an object without the given keys of an object.
+var mapOmit = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("omit", args, 2); err != nil {
+		return value.Null, err
+	}
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	keys, err := stringsArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+
+	res := make(map[string]value.Value, obj.Len())
+	for _, key := range obj.Keys() {
+		if slices.Contains(keys, key) {
+			continue
+		}
+		res[key], _ = obj.Key(key)
+	}
+	return value.Object(res), nil
+})
+
+func sortedKeys(obj value.Value) []string {
+	keys := obj.Keys()
+	slices.Sort(keys)
+	return keys
+}
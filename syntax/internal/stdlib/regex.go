@@ -0,0 +1,77 @@
+package stdlib
+
+import (
+	"strconv"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// regexMatch reports whether a string contains a match of a regular
+// expression.
+var regexMatch = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("regex_match", args, 2); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	re, err := regexpArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+	return value.Bool(re.MatchString(s)), nil
+})
+
+// regexReplace replaces the matches of a regular expression in a string.
+// The replacement can refer to capture groups with $1 or ${name}.
+var regexReplace = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("regex_replace", args, 3); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	re, err := regexpArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+	replacement, err := stringArg(funcValue, args, 2)
+	if err != nil {
+		return value.Null, err
+	}
+	return value.String(re.ReplaceAllString(s, replacement)), nil
+})
+
+// regexCapture returns the capture groups of the first match of a regular
+// expression in a string as an object. Groups are keyed by their index, with
+// "0" being the whole match, and named groups are also keyed by their name.
+// It returns null if the string doesn't match.
+var regexCapture = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("regex_capture", args, 2); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	re, err := regexpArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return value.Null, nil
+	}
+
+	res := make(map[string]value.Value, 2*len(match))
+	for i, name := range re.SubexpNames() {
+		res[strconv.Itoa(i)] = value.String(match[i])
+		if name != "" {
+			res[name] = value.String(match[i])
+		}
+	}
+	return value.Object(res), nil
+})
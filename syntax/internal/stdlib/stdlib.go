@@ -21,8 +21,26 @@ import (
 // ExperimentalIdentifiers contains the full name (namespace + identifier's name) of stdlib
 // identifiers that are considered "experimental".
 var ExperimentalIdentifiers = map[string]bool{
-	"array.combine_maps": true,
-	"array.group_by":     true,
+	"array.combine_maps":   true,
+	"array.filter":         true,
+	"array.flat_map":       true,
+	"array.group_by":       true,
+	"array.map":            true,
+	"array.reduce":         true,
+	"encoding.crc32":       true,
+	"encoding.md5":         true,
+	"encoding.sha256":      true,
+	"map.keys":             true,
+	"map.merge":            true,
+	"map.omit":             true,
+	"map.pick":             true,
+	"map.values":           true,
+	"string.regex_capture": true,
+	"string.regex_match":   true,
+	"string.regex_replace": true,
+	"time.format":          true,
+	"time.now":             true,
+	"time.parse_duration":  true,
 }
 
 // DeprecatedIdentifiers are deprecated in favour of the namespaced ones.
@@ -63,6 +81,8 @@ var Identifiers = map[string]interface{}{
 	"encoding": encoding,
 	"string":   str,
 	"file":     file,
+	"time":     timeFuncs,
+	"map":      mapFuncs,
 }
 
 func init() {
@@ -82,19 +102,39 @@ var encoding = map[string]interface{}{
 	"to_json":        jsonEncode,
 	"to_base64":      base64Encode,
 	"to_URLbase64":   base64URLEncode,
+	"sha256":         sha256Hash,
+	"md5":            md5Hash,
+	"crc32":          crc32Hash,
 }
 
 var str = map[string]interface{}{
-	"format":      fmt.Sprintf,
-	"join":        strings.Join,
-	"replace":     strings.ReplaceAll,
-	"split":       strings.Split,
-	"to_lower":    strings.ToLower,
-	"to_upper":    strings.ToUpper,
-	"trim":        strings.Trim,
-	"trim_prefix": strings.TrimPrefix,
-	"trim_suffix": strings.TrimSuffix,
-	"trim_space":  strings.TrimSpace,
+	"format":        fmt.Sprintf,
+	"join":          strings.Join,
+	"replace":       strings.ReplaceAll,
+	"split":         strings.Split,
+	"to_lower":      strings.ToLower,
+	"to_upper":      strings.ToUpper,
+	"trim":          strings.Trim,
+	"trim_prefix":   strings.TrimPrefix,
+	"trim_suffix":   strings.TrimSuffix,
+	"trim_space":    strings.TrimSpace,
+	"regex_match":   regexMatch,
+	"regex_replace": regexReplace,
+	"regex_capture": regexCapture,
+}
+
+var timeFuncs = map[string]interface{}{
+	"now":            timeNow,
+	"parse_duration": timeParseDuration,
+	"format":         timeFormat,
+}
+
+var mapFuncs = map[string]interface{}{
+	"keys":   mapKeys,
+	"values": mapValues,
+	"merge":  mapMerge,
+	"pick":   mapPick,
+	"omit":   mapOmit,
 }
 
 // groupBy takes an array of objects, a key to group by, and a boolean to determine
@@ -206,6 +246,10 @@ var array = map[string]interface{}{
 	"concat":       concat,
 	"combine_maps": combineMaps,
 	"group_by":     groupBy,
+	"map":          arrayMap,
+	"filter":       arrayFilter,
+	"reduce":       arrayReduce,
+	"flat_map":     arrayFlatMap,
 }
 
 var convert = map[string]interface{}{
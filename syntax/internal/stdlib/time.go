@@ -0,0 +1,53 @@
+package stdlib
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// timeNow returns the current time as an RFC 3339 string in UTC.
+var timeNow = value.RawFunction(func(_ value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("now", args, 0); err != nil {
+		return value.Null, err
+	}
+	return value.String(time.Now().UTC().Format(time.RFC3339Nano)), nil
+})
+
+// timeParseDuration parses a duration string such as "1h30m" and returns the
+// number of seconds.
+var timeParseDuration = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("parse_duration", args, 1); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return value.Null, argError(funcValue, args, 0, err)
+	}
+	return value.Float(d.Seconds()), nil
+})
+
+// timeFormat formats an RFC 3339 timestamp with a Go time layout.
+var timeFormat = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount("format", args, 2); err != nil {
+		return value.Null, err
+	}
+	s, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	layout, err := stringArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return value.Null, argError(funcValue, args, 0, fmt.Errorf("invalid RFC 3339 timestamp: %w", err))
+	}
+	return value.String(t.Format(layout)), nil
+})
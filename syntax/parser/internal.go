@@ -142,7 +142,13 @@ func (p *parser) expect(t token.Token) (pos token.Pos, tok token.Token, lit stri
 }
 
 func (p *parser) addErrorf(format string, args ...interface{}) {
-	pos := p.file.PositionFor(p.pos)
+	p.addErrorAtf(p.pos, format, args...)
+}
+
+// addErrorAtf is like addErrorf but records the error at pos instead of the
+// current token.
+func (p *parser) addErrorAtf(at token.Pos, format string, args ...interface{}) {
+	pos := p.file.PositionFor(at)
 
 	// Ignore errors which occur on the same line.
 	if p.lastError.Line == pos.Line {
@@ -379,9 +385,39 @@ func (n blockName) ValidAttribute() bool {
 
 // ParseExpression parses a single expression.
 //
-//	Expression = BinOpExpr
+//	Expression = CondExpr
 func (p *parser) ParseExpression() ast.Expr {
-	return p.parseBinOp(1)
+	return p.parseCondExpr()
+}
+
+// parseCondExpr parses a conditional expression. If there is no conditional
+// operator in the current state, a single binary expression will be returned
+// instead.
+//
+//	CondExpr = BinOpExpr [ "?" Expression ":" Expression ]
+//
+// The conditional operator has a lower precedence than all binary operators,
+// and is right-associative, so that a ? b : c ? d : e is parsed as
+// a ? b : (c ? d : e).
+func (p *parser) parseCondExpr() ast.Expr {
+	cond := p.parseBinOp(1)
+	if p.tok != token.QUESTION {
+		return cond
+	}
+
+	questionPos := p.pos
+	p.next() // Consume ?
+	trueExpr := p.ParseExpression()
+	colonPos, _, _ := p.expect(token.COLON)
+	falseExpr := p.ParseExpression()
+
+	return &ast.ConditionalExpr{
+		Condition:   cond,
+		QuestionPos: questionPos,
+		True:        trueExpr,
+		ColonPos:    colonPos,
+		False:       falseExpr,
+	}
 }
 
 // parseBinOp is the entrypoint for binary expressions. If there is no binary
@@ -569,13 +605,14 @@ func isUnaryOp(tok token.Token) bool {
 
 // parsePrimaryExpr parses a primary expression.
 //
-//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr
+//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr | FuncExpr
 //
 //	LiteralValue = identifier | string | number | float | bool | null |
 //	               "(" Expression ")"
 //
 //	ArrayExpr  = "[" [ ExpressionList ] "]"
 //	ObjectExpr = "{" [ FieldList ] "}"
+//	FuncExpr   = ( identifier | "(" [ ExpressionList ] ")" ) "=>" Expression
 func (p *parser) parsePrimaryExpr() ast.Expr {
 	switch p.tok {
 	case token.IDENT:
@@ -586,6 +623,9 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 			},
 		}
 		p.next()
+		if p.tok == token.ARROW {
+			return p.parseFuncExpr(token.NoPos, []ast.Expr{res}, token.NoPos)
+		}
 		return res
 
 	case token.STRING, token.NUMBER, token.FLOAT, token.BOOL, token.NULL:
@@ -599,8 +639,33 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 
 	case token.LPAREN:
 		lParen, _, _ := p.expect(token.LPAREN)
+		if p.tok == token.RPAREN {
+			// Only functions without parameters may have empty parentheses.
+			rParen, _, _ := p.expect(token.RPAREN)
+			if p.tok != token.ARROW {
+				p.addErrorAtf(rParen, "expected expression, got %s", token.RPAREN)
+				return &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: rParen}
+			}
+			return p.parseFuncExpr(lParen, nil, rParen)
+		}
+
 		expr := p.ParseExpression()
+		if p.tok == token.COMMA {
+			// Only functions with several parameters may have a list in
+			// parentheses.
+			params := []ast.Expr{expr}
+			p.next()
+			if p.tok != token.RPAREN {
+				params = append(params, p.parseExpressionList(token.RPAREN)...)
+			}
+			rParen, _, _ := p.expect(token.RPAREN)
+			return p.parseFuncExpr(lParen, params, rParen)
+		}
+
 		rParen, _, _ := p.expect(token.RPAREN)
+		if p.tok == token.ARROW {
+			return p.parseFuncExpr(lParen, []ast.Expr{expr}, rParen)
+		}
 
 		return &ast.ParenExpr{
 			LParenPos: lParen,
@@ -635,6 +700,44 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 	return res
 }
 
+// parseFuncExpr parses the remainder of a function expression once its
+// parameters have been parsed. Each parameter must be an identifier.
+func (p *parser) parseFuncExpr(lParen token.Pos, params []ast.Expr, rParen token.Pos) ast.Expr {
+	res := &ast.FuncExpr{
+		LParenPos: lParen,
+		RParenPos: rParen,
+	}
+
+	seen := make(map[string]struct{}, len(params))
+	for _, param := range params {
+		ident, ok := param.(*ast.IdentifierExpr)
+		if !ok {
+			p.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(param).Position(),
+				EndPos:   ast.EndPos(param).Position(),
+				Message:  "function parameters must be identifiers",
+			})
+			continue
+		}
+		if _, ok := seen[ident.Ident.Name]; ok {
+			p.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(param).Position(),
+				EndPos:   ast.EndPos(param).Position(),
+				Message:  fmt.Sprintf("duplicate function parameter %q", ident.Ident.Name),
+			})
+			continue
+		}
+		seen[ident.Ident.Name] = struct{}{}
+		res.Params = append(res.Params, ident.Ident)
+	}
+
+	res.ArrowPos, _, _ = p.expect(token.ARROW)
+	res.Body = p.ParseExpression()
+	return res
+}
+
 var statementEnd = map[token.Token]struct{}{
 	token.TERMINATOR: {},
 	token.RPAREN:     {},
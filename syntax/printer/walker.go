@@ -175,6 +175,16 @@ func (w *walker) walkExpr(e ast.Expr) {
 		w.p.Write(wsBlank, e.KindPos, e.Kind, wsBlank)
 		w.walkExpr(e.Right)
 
+	case *ast.ConditionalExpr:
+		w.walkExpr(e.Condition)
+		w.p.Write(wsBlank, e.QuestionPos, token.QUESTION, wsBlank)
+		w.walkExpr(e.True)
+		w.p.Write(wsBlank, e.ColonPos, token.COLON, wsBlank)
+		w.walkExpr(e.False)
+
+	case *ast.FuncExpr:
+		w.walkFuncExpr(e)
+
 	case *ast.ParenExpr:
 		w.p.Write(token.LPAREN)
 		w.walkExpr(e.Inner)
@@ -182,6 +192,28 @@ func (w *walker) walkExpr(e ast.Expr) {
 	}
 }
 
+func (w *walker) walkFuncExpr(e *ast.FuncExpr) {
+	// Parentheses are only omitted when they were omitted in the source, which
+	// is only possible with exactly one parameter.
+	parens := e.LParenPos.Valid() || len(e.Params) != 1
+
+	if parens {
+		w.p.Write(e.LParenPos, token.LPAREN)
+	}
+	for i, param := range e.Params {
+		if i > 0 {
+			w.p.Write(token.COMMA, wsBlank)
+		}
+		w.p.Write(param.NamePos, param)
+	}
+	if parens {
+		w.p.Write(e.RParenPos, token.RPAREN)
+	}
+
+	w.p.Write(wsBlank, e.ArrowPos, token.ARROW, wsBlank)
+	w.walkExpr(e.Body)
+}
+
 func (w *walker) walkArrayExpr(e *ast.ArrayExpr) {
 	w.p.Write(e.LBrackPos, token.LBRACK)
 	prevPos := e.LBrackPos
@@ -275,8 +275,13 @@ scanAgain:
 
 		case '!': // !, !=
 			tok = s.switch2(token.NOT, token.NEQ, '=')
-		case '=': // =, ==
-			tok = s.switch2(token.ASSIGN, token.EQ, '=')
+		case '=': // =, ==, =>
+			if s.ch == '>' {
+				s.next()
+				tok = token.ARROW
+			} else {
+				tok = s.switch2(token.ASSIGN, token.EQ, '=')
+			}
 		case '<': // <, <=
 			tok = s.switch2(token.LT, token.LTE, '=')
 		case '>': // >, >=
@@ -338,6 +343,10 @@ scanAgain:
 		case '.':
 			// NOTE: Fractions starting with '.' are handled by outer switch
 			tok = token.DOT
+		case '?':
+			tok = token.QUESTION
+		case ':':
+			tok = token.COLON
 
 		default:
 			// s.next() reports invalid BOMs so we don't need to repeat the error.
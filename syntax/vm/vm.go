@@ -459,6 +459,25 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 			}
 		}
 
+	case *ast.ConditionalExpr:
+		cond, err := vm.evaluateExpr(scope, assoc, expr.Condition)
+		if err != nil {
+			return value.Null, err
+		}
+		if cond.Type() != value.TypeBool {
+			return value.Null, value.TypeError{Value: cond, Expected: value.TypeBool}
+		}
+
+		// Only the selected value is evaluated, so the other value may refer to
+		// values which don't exist.
+		if cond.Bool() {
+			return vm.evaluateExpr(scope, assoc, expr.True)
+		}
+		return vm.evaluateExpr(scope, assoc, expr.False)
+
+	case *ast.FuncExpr:
+		return vm.evaluateFunc(scope, expr), nil
+
 	case *ast.ParenExpr:
 		return vm.evaluateExpr(scope, assoc, expr.Inner)
 
@@ -492,6 +511,34 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 	}
 }
 
+// evaluateFunc creates a function value from expr. The body of the function
+// is evaluated in a child of scope each time the function is called.
+func (vm *Evaluator) evaluateFunc(scope *Scope, expr *ast.FuncExpr) value.Value {
+	var fn value.RawFunction = func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		if len(args) != len(expr.Params) {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("expected %d args, got %d", len(expr.Params), len(args)),
+			}
+		}
+
+		variables := make(map[string]interface{}, len(args))
+		for i, param := range expr.Params {
+			variables[param.Name] = args[i]
+		}
+
+		// The function may be called outside of the evaluation which created
+		// it, so errors are decorated with their own associations.
+		assoc := make(map[value.Value]ast.Node)
+		res, err := vm.evaluateExpr(scope.child(variables), assoc, expr.Body)
+		if err != nil {
+			return value.Null, makeDiagnostic(err, assoc)
+		}
+		return res, nil
+	}
+	return value.Func(fn)
+}
+
 // A Scope exposes a set of variables available to use during evaluation.
 type Scope struct {
 	// Variables holds the list of available variable names that can be used when
@@ -501,6 +548,10 @@ type Scope struct {
 	// Evaluate; maps and slices will be copied by reference for performance
 	// optimizations.
 	Variables map[string]interface{}
+
+	// parent is the scope in which the scope was created, such as the scope of
+	// the definition of a function for the scope of its parameters.
+	parent *Scope
 }
 
 func NewScope(variables map[string]interface{}) *Scope {
@@ -509,10 +560,17 @@ func NewScope(variables map[string]interface{}) *Scope {
 	}
 }
 
-// Lookup looks up a named identifier from the scope and the stdlib.
+// child returns a new scope with the given variables, which falls back to s
+// for other identifiers.
+func (s *Scope) child(variables map[string]interface{}) *Scope {
+	return &Scope{Variables: variables, parent: s}
+}
+
+// Lookup looks up a named identifier from the scope, its parents, and the
+// stdlib.
 func (s *Scope) Lookup(name string) (interface{}, bool) {
-	// Check the scope first.
-	if s != nil {
+	// Check the scopes first, from the innermost one.
+	for ; s != nil; s = s.parent {
 		if val, ok := s.Variables[name]; ok {
 			return val, true
 		}
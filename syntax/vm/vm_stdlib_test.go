@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/grafana/alloy/syntax/alloytypes"
 	"github.com/grafana/alloy/syntax/internal/value"
@@ -42,6 +43,28 @@ func TestVM_Stdlib(t *testing.T) {
 		{"encoding.from_URLbase64", `encoding.from_URLbase64("c3RyaW5nMTIzIT8kKiYoKSctPUB-")`, string(`string123!?$*&()'-=@~`)},
 		{"encoding.to_base64", `encoding.to_base64("string123!?$*&()'-=@~")`, string(`c3RyaW5nMTIzIT8kKiYoKSctPUB+`)},
 		{"encoding.to_URLbase64", `encoding.to_URLbase64("string123!?$*&()'-=@~")`, string(`c3RyaW5nMTIzIT8kKiYoKSctPUB-`)},
+		{"encoding.sha256", `encoding.sha256("hello")`, string("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")},
+		{"encoding.md5", `encoding.md5("hello")`, string("5d41402abc4b2a76b9719d911017c592")},
+		{"encoding.crc32", `encoding.crc32("hello")`, uint32(907060870)},
+		{"time.parse_duration", `time.parse_duration("1h30m")`, float64(5400)},
+		{"time.parse_duration sub-second", `time.parse_duration("250ms")`, float64(0.25)},
+		{"time.format", `time.format("2025-03-04T05:06:07Z", "2006-01-02")`, string("2025-03-04")},
+		{"map.keys", `map.keys({"b" = 1, "a" = 2})`, []string{"a", "b"}},
+		{"map.values", `map.values({"b" = 1, "a" = 2})`, []int{2, 1}},
+		{"map.merge", `map.merge({"a" = 1, "b" = 2}, {"b" = 3}, {"c" = 4})`, map[string]int{"a": 1, "b": 3, "c": 4}},
+		{"map.merge no arguments", `map.merge()`, map[string]int{}},
+		{"map.pick", `map.pick({"a" = 1, "b" = 2, "c" = 3}, ["a", "c", "d"])`, map[string]int{"a": 1, "c": 3}},
+		{"map.omit", `map.omit({"a" = 1, "b" = 2, "c" = 3}, ["a", "d"])`, map[string]int{"b": 2, "c": 3}},
+		{"array.map", `array.map([1, 2, 3], x => x * 2)`, []int{2, 4, 6}},
+		{"array.map stdlib function", `array.map(["a", "b"], string.to_upper)`, []string{"A", "B"}},
+		{"array.map targets", `array.map([{"__address__" = "a:80"}, {"__address__" = "b:80"}], t => map.merge(t, {"job" = "web"}))`, []map[string]string{{"__address__": "a:80", "job": "web"}, {"__address__": "b:80", "job": "web"}}},
+		{"array.filter", `array.filter([1, 2, 3, 4], x => x % 2 == 0)`, []int{2, 4}},
+		{"array.filter none", `array.filter([1, 2], x => false)`, []int{}},
+		{"array.reduce", `array.reduce([1, 2, 3], 10, (acc, x) => acc + x)`, int(16)},
+		{"array.reduce empty", `array.reduce([], "init", (acc, x) => acc + x)`, string("init")},
+		{"array.flat_map", `array.flat_map([1, 2], x => [x, x * 10])`, []int{1, 10, 2, 20}},
+		{"array.map nested", `array.map([[1, 2], [3]], xs => array.reduce(xs, 0, (acc, x) => acc + x))`, []int{3, 3}},
+		{"array.map closure", `array.map([1, 2], x => array.map([10, 20], y => x + y))`, [][]int{{11, 21}, {12, 22}}},
 		{
 			"encoding.to_json object",
 			`encoding.to_json({"modules"={"http_2xx"={"prober"="http","timeout"="5s","http"={"headers"={"Authorization"=sys.env("TEST_VAR")}}}}})`,
@@ -184,6 +207,66 @@ func TestVM_Stdlib_Errors(t *testing.T) {
 			`encoding.to_json(12)`,
 			`encoding.to_json jsonEncode only supports map`,
 		},
+		{
+			"string.regex_match invalid regex",
+			`string.regex_match("foo", "(")`,
+			`invalid regular expression: error parsing regexp: missing closing ): ` + "`(`",
+		},
+		{
+			"string.regex_replace wrong number of arguments",
+			`string.regex_replace("foo", "o")`,
+			`regex_replace: expected 3 arguments, got 2`,
+		},
+		{
+			"encoding.sha256 wrong type",
+			`encoding.sha256(true)`,
+			`true should be string, got bool`,
+		},
+		{
+			"time.parse_duration invalid duration",
+			`time.parse_duration("1 hour")`,
+			`time: unknown unit " hour" in duration "1 hour"`,
+		},
+		{
+			"time.format invalid timestamp",
+			`time.format("yesterday", "2006-01-02")`,
+			`invalid RFC 3339 timestamp`,
+		},
+		{
+			"map.keys wrong type",
+			`map.keys(["a"])`,
+			`["a"] should be object, got array`,
+		},
+		{
+			"map.pick keys not strings",
+			`map.pick({"a" = 1}, [{}])`,
+			`should be string, got object`,
+		},
+		{
+			"array.map not a function",
+			`array.map([1], 1)`,
+			`1 should be function, got number`,
+		},
+		{
+			"array.map wrong number of parameters",
+			`array.map([1], (a, b) => a)`,
+			`(a, b) => a expected 2 args, got 1`,
+		},
+		{
+			"array.map error in function",
+			`array.map([1], x => x.field)`,
+			`cannot access field "field" on value of type number`,
+		},
+		{
+			"array.filter non-bool result",
+			`array.filter([1], x => x)`,
+			`x => x must return a bool, got number`,
+		},
+		{
+			"array.flat_map non-array result",
+			`array.flat_map([1], x => x)`,
+			`x => x must return an array, got number`,
+		},
 	}
 
 	for _, tc := range tt {
@@ -340,6 +423,12 @@ func TestStdlib_StringFunc(t *testing.T) {
 		{"string.trim2", `string.trim("   hello! world.!  ", "! ")`, "hello! world."},
 		{"string.trim_prefix", `string.trim_prefix("helloworld", "hello")`, "world"},
 		{"string.trim_suffix", `string.trim_suffix("helloworld", "world")`, "hello"},
+		{"string.regex_match", `string.regex_match("pod-123", "^pod-[0-9]+$")`, true},
+		{"string.regex_match unanchored", `string.regex_match("my-pod-123", "pod")`, true},
+		{"string.regex_match no match", `string.regex_match("pod-abc", "^pod-[0-9]+$")`, false},
+		{"string.regex_replace", `string.regex_replace("pod-123-abc", "([a-z]+)-([0-9]+)", "${2}_$1")`, "123_pod-abc"},
+		{"string.regex_capture", `string.regex_capture("app=web env=prod", "app=(?P<app>\\w+) env=(\\w+)")`, map[string]string{"0": "app=web env=prod", "1": "web", "2": "prod", "app": "web"}},
+		{"string.regex_capture no match", `string.regex_capture("env=prod", "app=(\\w+)")`, map[string]string(nil)},
 	}
 
 	for _, tc := range tt {
@@ -570,3 +659,15 @@ func TestStdlibGroupBy_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestStdlibTimeNow(t *testing.T) {
+	expr, err := parser.ParseExpression(`time.now()`)
+	require.NoError(t, err)
+
+	var actual string
+	require.NoError(t, vm.New(expr).Evaluate(nil, &actual))
+
+	now, err := time.Parse(time.RFC3339Nano, actual)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), now, time.Minute)
+}
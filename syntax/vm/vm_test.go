@@ -137,6 +137,24 @@ func TestVM_Evaluate(t *testing.T) {
 		{`!true`, bool(false)},
 		{`!false`, bool(true)},
 		{`-15`, int(-15)},
+
+		// Conditional
+		{`true ? 1 : 2`, int(1)},
+		{`false ? 1 : 2`, int(2)},
+		{`foobar > 40 ? "big" : "small"`, string("big")},
+		{`false ? 1 : true ? 2 : 3`, int(2)},   // Right-associative
+		{`(true ? [1] : [2])[0] + 1`, int(2)},  // Parenthesized
+		{`true ? 1 + 2 : 3 * 4`, int(3)},       // Lowest precedence
+		{`true ? 1 : does_not_exist`, int(1)},  // Short-circuit
+		{`false ? does_not_exist : 2`, int(2)}, // Short-circuit
+
+		// Functions
+		{`(x => x + 1)(1)`, int(2)},
+		{`((a, b) => a * b)(3, 4)`, int(12)},
+		{`(() => "empty")()`, string("empty")},
+		{`(() => foobar)()`, int(42)},       // Closure over the scope
+		{`(foobar => foobar)(1)`, int(1)},   // Parameters shadow the scope
+		{`(a => b => a + b)(1)(2)`, int(3)}, // Closure over parameters
 	}
 
 	for _, tc := range tt {